@@ -38,7 +38,16 @@ func DefaultAppOptions() *AppConfig {
 // RunApp 根据配置启动程序
 func RunApp(config *viper.Viper) error {
 
-	return RunAppWithDefaultAppOptions(config, DefaultAppOptions())
+	appConfig := DefaultAppOptions()
+	appConfig.AppOpts.AutoMigrate = config.GetBool("db.autoMigrate")
+	appConfig.AppOpts.EnableCache = config.GetBool("redis.enabled")
+	// 内存存储模式下不需要连接数据库
+	if config.GetString("server.store") == app.StoreMemory {
+		appConfig.AppOpts.EnableDB = false
+		appConfig.AppOpts.Store = app.StoreMemory
+	}
+
+	return RunAppWithDefaultAppOptions(config, appConfig)
 }
 
 // RunAppWithDefaultAppOptions  启动App应用 启动server服务
@@ -109,6 +109,7 @@ func mergeCommandLineOptions(v *viper.Viper, opts *options.Options) {
 	v.Set("server.http.readTimeout", opts.ServerOpts.ReadTimeout)
 	v.Set("server.http.writeTimeout", opts.ServerOpts.WriteTimeout)
 	v.Set("server.http.maxHeaderBytes", opts.ServerOpts.MaxHeaderBytes)
+	v.Set("server.store", opts.ServerOpts.Store)
 
 	// 日志设置
 	v.Set("log.level", opts.LogOpts.Level)
@@ -120,6 +121,9 @@ func mergeCommandLineOptions(v *viper.Viper, opts *options.Options) {
 
 	// 数据库设置 - 只有当值与默认值不同时，才可能是通过命令行设置的
 	defaultOpts := options.NewDBOptions()
+	if opts.DBOpts.Driver != defaultOpts.Driver {
+		v.Set("db.driver", opts.DBOpts.Driver)
+	}
 	if opts.DBOpts.Username != defaultOpts.Username {
 		v.Set("db.username", opts.DBOpts.Username)
 	}
@@ -147,9 +151,15 @@ func mergeCommandLineOptions(v *viper.Viper, opts *options.Options) {
 	if opts.DBOpts.ConnMaxLifetime != defaultOpts.ConnMaxLifetime {
 		v.Set("db.connMaxLifetime", opts.DBOpts.ConnMaxLifetime)
 	}
+	if opts.DBOpts.AutoMigrate != defaultOpts.AutoMigrate {
+		v.Set("db.autoMigrate", opts.DBOpts.AutoMigrate)
+	}
 
 	// 缓存设置 - 同理应用相同的逻辑
 	defaultCacheOpts := options.NewCacheOptions()
+	if opts.CacheOpts.Enabled != defaultCacheOpts.Enabled {
+		v.Set("redis.enabled", opts.CacheOpts.Enabled)
+	}
 	if opts.CacheOpts.Host != defaultCacheOpts.Host {
 		v.Set("redis.host", opts.CacheOpts.Host)
 	}
@@ -180,6 +190,7 @@ func setDefaultsFromOptions(v *viper.Viper, opts *options.Options) {
 	v.SetDefault("server.http.readTimeout", opts.ServerOpts.ReadTimeout)
 	v.SetDefault("server.http.writeTimeout", opts.ServerOpts.WriteTimeout)
 	v.SetDefault("server.http.maxHeaderBytes", opts.ServerOpts.MaxHeaderBytes)
+	v.SetDefault("server.store", opts.ServerOpts.Store)
 
 	// gRPC服务器默认值
 	v.SetDefault("server.grpc.port", 9090)
@@ -200,6 +211,7 @@ func setDefaultsFromOptions(v *viper.Viper, opts *options.Options) {
 	v.SetDefault("log.compress", opts.LogOpts.Compress)
 
 	// 数据库默认值
+	v.SetDefault("db.driver", opts.DBOpts.Driver)
 	v.SetDefault("db.host", opts.DBOpts.Host)
 	v.SetDefault("db.port", opts.DBOpts.Port)
 	v.SetDefault("db.username", opts.DBOpts.Username)
@@ -209,8 +221,10 @@ func setDefaultsFromOptions(v *viper.Viper, opts *options.Options) {
 	v.SetDefault("db.maxIdleConns", opts.DBOpts.MaxIdleConns)
 	v.SetDefault("db.maxOpenConns", opts.DBOpts.MaxOpenConns)
 	v.SetDefault("db.connMaxLifetime", opts.DBOpts.ConnMaxLifetime)
+	v.SetDefault("db.autoMigrate", opts.DBOpts.AutoMigrate)
 
 	// 缓存默认值
+	v.SetDefault("redis.enabled", opts.CacheOpts.Enabled)
 	v.SetDefault("redis.host", opts.CacheOpts.Host)
 	v.SetDefault("redis.port", opts.CacheOpts.Port)
 	v.SetDefault("redis.password", opts.CacheOpts.Password)
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/lichenglife/easyblog/cmd/apiserver/app/options"
+	"github.com/lichenglife/easyblog/internal/apiserver/migration"
+	"github.com/lichenglife/easyblog/internal/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+// NewMigrateCommand 创建数据库迁移命令
+
+func NewMigrateCommand() *cobra.Command {
+	opts := options.NewOptions()
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "执行easyblog数据库迁移",
+		Long:  `执行easyblog数据库迁移，支持 up、down、status、to <version> 子命令`,
+	}
+
+	// 迁移命令与服务启动命令共用配置文件和数据库参数
+	opts.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "执行全部未执行的迁移",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrate(opts, func(ctx context.Context, m *migration.Migrator) error {
+					changes, err := m.Up(ctx)
+					printChanges(changes)
+					return err
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "down",
+			Short: "回滚最近一次执行的迁移",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrate(opts, func(ctx context.Context, m *migration.Migrator) error {
+					changes, err := m.Down(ctx)
+					printChanges(changes)
+					return err
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "查看迁移执行状态",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrate(opts, func(ctx context.Context, m *migration.Migrator) error {
+					list, err := m.Status(ctx)
+					if err != nil {
+						return err
+					}
+					printStatus(list)
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "to <version>",
+			Short: "迁移到指定版本，0 表示回滚全部迁移",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.ParseUint(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("无效的迁移版本 %q", args[0])
+				}
+				return runMigrate(opts, func(ctx context.Context, m *migration.Migrator) error {
+					changes, err := m.To(ctx, uint(version))
+					printChanges(changes)
+					return err
+				})
+			},
+		},
+	)
+
+	return cmd
+}
+
+// runMigrate 加载配置并连接数据库后执行迁移操作
+func runMigrate(opts *options.Options, fn func(ctx context.Context, m *migration.Migrator) error) error {
+	return withDB(opts, func(ctx context.Context, database *db.DB) error {
+		return fn(ctx, migration.NewMigrator(database.DB))
+	})
+}
+
+// withDB 加载配置并连接数据库后执行 fn，供不启动服务的子命令使用
+func withDB(opts *options.Options, fn func(ctx context.Context, database *db.DB) error) error {
+	if err := opts.Complete(); err != nil {
+		return err
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	cfg, err := LoadConfig(opts)
+	if err != nil {
+		return fmt.Errorf("初始化配置失败%v", err)
+	}
+
+	database, err := db.NewDB(cfg.Viper)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	return fn(context.Background(), database)
+}
+
+// printChanges 打印迁移变更
+func printChanges(changes []migration.Change) {
+	if len(changes) == 0 {
+		fmt.Println("没有需要执行的迁移")
+		return
+	}
+	for _, c := range changes {
+		fmt.Printf("%-4s %d_%s\n", c.Direction, c.Version, c.Name)
+	}
+}
+
+// printStatus 打印迁移状态
+func printStatus(list []migration.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, st := range list {
+		status, appliedAt := "pending", "-"
+		if st.Applied {
+			status, appliedAt = "applied", st.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", st.Version, st.Name, status, appliedAt)
+	}
+	w.Flush()
+}
@@ -1,6 +1,10 @@
 package options
 
-import "github.com/spf13/pflag"
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
 
 // Options 定义全局变量
 
@@ -85,6 +89,8 @@ type ServerOptions struct {
 	WriteTimeout int
 	// MaxHeaderBytes 最大请求头大小
 	MaxHeaderBytes int
+	// Store 存储层实现类型 (db, memory)
+	Store string
 }
 
 // NewServerOptions 创建默认服务选项
@@ -96,6 +102,7 @@ func NewServerOptions() *ServerOptions {
 		ReadTimeout:    60,
 		WriteTimeout:   60,
 		MaxHeaderBytes: 1 << 20,
+		Store:          "db",
 	}
 }
 
@@ -107,6 +114,7 @@ func (o *ServerOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.IntVarP(&o.ReadTimeout, "read-timeout", "r", o.ReadTimeout, "server read timeout")
 	fs.IntVarP(&o.WriteTimeout, "write-timeout", "w", o.WriteTimeout, "server write timeout")
 	fs.IntVarP(&o.MaxHeaderBytes, "max-header-bytes", "b", o.MaxHeaderBytes, "server max header bytes")
+	fs.StringVar(&o.Store, "store", o.Store, "store type (db, memory)")
 }
 
 // Complete 完成选项
@@ -116,7 +124,12 @@ func (o *ServerOptions) Complete() error {
 
 // Validate 验证选项
 func (o *ServerOptions) Validate() error {
-	return nil
+	switch o.Store {
+	case "db", "memory":
+		return nil
+	default:
+		return fmt.Errorf("不支持的存储类型: %s", o.Store)
+	}
 }
 
 type LogOptions struct {
@@ -170,6 +183,8 @@ func (o *LogOptions) Validate() error {
 }
 
 type DBOptions struct {
+	// Driver 数据库驱动 (mysql, postgres, sqlite)
+	Driver string
 	// Host 数据库主机
 	Host string
 	// Port  数据库端口
@@ -178,7 +193,7 @@ type DBOptions struct {
 	Username string
 	// Password 密码
 	Password string
-	// Database  数据库名
+	// Database  数据库名，SQLite 下为数据库文件路径
 	Database string
 	// LogLevel 日志级别
 	LogLevel string
@@ -188,12 +203,15 @@ type DBOptions struct {
 	MaxOpenConns int
 	// ConnMaxLifetime 连接最大生命周期(秒)
 	ConnMaxLifetime int
+	// AutoMigrate 启动时自动执行数据库迁移
+	AutoMigrate bool
 }
 
 // NewDBOptions 默认数据库配置
 
 func NewDBOptions() *DBOptions {
 	return &DBOptions{
+		Driver:          "mysql",
 		Host:            "localhost",
 		Port:            3306,
 		Username:        "root",
@@ -209,15 +227,17 @@ func NewDBOptions() *DBOptions {
 // AddFlags 设置命令行标志
 
 func (o *DBOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Driver, "db-driver", o.Driver, "数据库驱动 (mysql, postgres, sqlite)")
 	fs.StringVar(&o.Host, "db-host", o.Host, "数据库主机")
 	fs.IntVar(&o.Port, "db-port", o.Port, "数据库端口")
 	fs.StringVar(&o.Username, "db-username", o.Username, "数据库用户名")
 	fs.StringVar(&o.Password, "db-password", o.Password, "数据库密码")
-	fs.StringVar(&o.Database, "db-database", o.Database, "数据库名")
+	fs.StringVar(&o.Database, "db-database", o.Database, "数据库名，SQLite 下为数据库文件路径 (:memory: 表示内存数据库)")
 	fs.StringVar(&o.LogLevel, "db-log-level", o.LogLevel, "数据库日志级别 (silent, error, warn, info)")
 	fs.IntVar(&o.MaxIdleConns, "db-max-idle-conns", o.MaxIdleConns, "最大空闲连接数")
 	fs.IntVar(&o.MaxOpenConns, "db-max-open-conns", o.MaxOpenConns, "最大打开连接数")
 	fs.IntVar(&o.ConnMaxLifetime, "db-conn-max-lifetime", o.ConnMaxLifetime, "连接最大生命周期(秒)")
+	fs.BoolVar(&o.AutoMigrate, "db-auto-migrate", o.AutoMigrate, "启动时自动执行数据库迁移")
 }
 
 // Complete 完成选项
@@ -227,11 +247,17 @@ func (o *DBOptions) Complete() error {
 
 // Validate 验证选项
 func (o *DBOptions) Validate() error {
-	return nil
+	switch o.Driver {
+	case "mysql", "postgres", "sqlite":
+		return nil
+	default:
+		return fmt.Errorf("不支持的数据库驱动: %s", o.Driver)
+	}
 }
 
 type CacheOpts struct {
-
+	// Enabled 是否启用 Redis 缓存
+	Enabled bool
 	// Host 缓存主机
 	Host string
 	// Port 缓存端口
@@ -263,6 +289,7 @@ func NewCacheOptions() *CacheOpts {
 
 // AddFlags 设置默认命令行标志
 func (o *CacheOpts) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "cache-enabled", o.Enabled, "是否启用 Redis 缓存，未启用时令牌注销状态保存在进程内存中")
 	fs.StringVar(&o.Host, "cache-host", o.Host, "缓存主机")
 	fs.IntVar(&o.Port, "cache-port", o.Port, "缓存端口")
 	fs.StringVar(&o.Password, "cache-password", o.Password, "缓存密码")
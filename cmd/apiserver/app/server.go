@@ -37,6 +37,8 @@ func NewAPIServerCommand() *cobra.Command {
 
 	// 添加子命令
 	cmd.AddCommand(NewVersionCommand())
+	cmd.AddCommand(NewMigrateCommand())
+	cmd.AddCommand(NewUserCommand())
 
 	return cmd
 }
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lichenglife/easyblog/cmd/apiserver/app/options"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/authz"
+	"github.com/lichenglife/easyblog/internal/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+// NewUserCommand 创建用户管理命令，用于初始化管理员等无法通过 API 完成的操作
+
+func NewUserCommand() *cobra.Command {
+	opts := options.NewOptions()
+
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "管理easyblog用户",
+		Long:  `管理easyblog用户，支持 set-role <username> <role>、verify-email <username>、reset-mfa <username> 子命令`,
+	}
+
+	// 用户管理命令与服务启动命令共用配置文件和数据库参数
+	opts.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "set-role <username> <role>",
+			Short: "修改用户角色 (admin, editor, author, reader)",
+			Args:  cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				username, role := args[0], args[1]
+				if !authz.IsRole(role) {
+					return fmt.Errorf("不支持的角色 %q", role)
+				}
+				return withDB(opts, func(ctx context.Context, database *db.DB) error {
+					users := store.NewStore(database.DB).User()
+					user, err := users.GetByUsername(ctx, username)
+					if err != nil {
+						return err
+					}
+					user.Role = role
+					if err := users.Update(ctx, user); err != nil {
+						return err
+					}
+					fmt.Printf("用户 %s 的角色已修改为 %s\n", username, role)
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "verify-email <username>",
+			Short: "将用户邮箱标记为已验证，用于启用邮箱验证前注册的用户",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				username := args[0]
+				return withDB(opts, func(ctx context.Context, database *db.DB) error {
+					users := store.NewStore(database.DB).User()
+					user, err := users.GetByUsername(ctx, username)
+					if err != nil {
+						return err
+					}
+					user.EmailVerified = true
+					if err := users.Update(ctx, user); err != nil {
+						return err
+					}
+					fmt.Printf("用户 %s 的邮箱已标记为已验证\n", username)
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "reset-mfa <username>",
+			Short: "关闭用户的两步验证，用于用户丢失身份验证器和恢复码时",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				username := args[0]
+				return withDB(opts, func(ctx context.Context, database *db.DB) error {
+					s := store.NewStore(database.DB)
+					user, err := s.User().GetByUsername(ctx, username)
+					if err != nil {
+						return err
+					}
+					if err := s.UserMFA().Delete(ctx, user.UserID); err != nil {
+						return err
+					}
+					fmt.Printf("用户 %s 的两步验证已关闭\n", username)
+					return nil
+				})
+			},
+		},
+	)
+
+	return cmd
+}
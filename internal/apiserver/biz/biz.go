@@ -1,9 +1,11 @@
 package biz
 
 import (
+	apikeyv1 "github.com/lichenglife/easyblog/internal/apiserver/biz/v1/apikey"
 	postv1 "github.com/lichenglife/easyblog/internal/apiserver/biz/v1/post"
 	userv1 "github.com/lichenglife/easyblog/internal/apiserver/biz/v1/user"
 	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
 )
 
 type IBiz interface {
@@ -11,25 +13,42 @@ type IBiz interface {
 	UserV1() userv1.UserBiz
 	// 博客业务接口V1版本
 	PostV1() postv1.PostBiz
+	// API 密钥业务接口V1版本
+	APIKeyV1() apikeyv1.APIKeyBiz
+}
+
+// Options 业务层依赖的组件
+type Options struct {
+	// User 用户业务依赖的组件
+	User userv1.Options
+	// Post 帖子业务依赖的配置
+	Post postv1.Options
 }
 
 // biz 实现 IBiz 接口
 type biz struct {
+	logger *log.Logger
 	// 存储层的业务逻辑
 	store store.IStore
+	opts  *Options
 }
 
 // PostV1 implements IBiz.
 func (b *biz) PostV1() postv1.PostBiz {
-	panic("unimplemented")
+	return postv1.NewPostBiz(b.logger, b.store, &b.opts.Post)
+}
+
+// APIKeyV1 implements IBiz.
+func (b *biz) APIKeyV1() apikeyv1.APIKeyBiz {
+	return apikeyv1.NewAPIKeyBiz(b.logger, b.store)
 }
 
 // UserV1 implements IBiz.
 func (b *biz) UserV1() userv1.UserBiz {
-	return userv1.NewUserBiz(b.store.User())
+	return userv1.NewUserBiz(b.logger, b.store, &b.opts.User)
 }
 
 // NewBiz 创建业务逻辑层实例
-func NewBiz(store store.IStore) IBiz {
-	return &biz{store: store}
+func NewBiz(logger *log.Logger, store store.IStore, opts *Options) IBiz {
+	return &biz{logger: logger, store: store, opts: opts}
 }
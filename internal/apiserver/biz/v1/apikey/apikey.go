@@ -0,0 +1,248 @@
+package biz
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/authz"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/lichenglife/easyblog/internal/pkg/token"
+	"go.uber.org/zap"
+)
+
+const (
+	// keyIDLength 密钥公开 ID 的随机字节数
+	keyIDLength = 8
+	// secretLength 密钥私密部分的随机字节数
+	secretLength = 32
+	// lastUsedInterval 最近使用时间的更新间隔，避免每次请求都写数据库
+	lastUsedInterval = time.Minute
+)
+
+// APIKeyBiz API 密钥业务接口
+type APIKeyBiz interface {
+	// CreateAPIKey 为当前用户创建 API 密钥，密钥明文只在创建时返回一次
+	CreateAPIKey(ctx context.Context, req *model.CreateAPIKeyRequest) (*model.CreateAPIKeyResponse, error)
+	// ListAPIKeys 获取当前用户的全部 API 密钥
+	ListAPIKeys(ctx context.Context) (*model.ListAPIKeyResponse, error)
+	// DeleteAPIKey 删除当前用户的 API 密钥
+	DeleteAPIKey(ctx context.Context, id uint) error
+	// Authenticate 校验 API 密钥并返回调用方身份
+	Authenticate(ctx context.Context, key string) (*contextx.Principal, error)
+}
+
+// NewAPIKeyBiz 创建 APIKeyBiz 实例
+func NewAPIKeyBiz(logger *log.Logger, store store.IStore) APIKeyBiz {
+	return &apiKeyBiz{
+		logger: logger,
+		store:  store,
+	}
+}
+
+var _ APIKeyBiz = (*apiKeyBiz)(nil)
+
+// apiKeyBiz 实现了 API 密钥业务接口
+type apiKeyBiz struct {
+	logger *log.Logger
+	store  store.IStore
+}
+
+// CreateAPIKey implements APIKeyBiz.
+func (a *apiKeyBiz) CreateAPIKey(ctx context.Context, req *model.CreateAPIKeyRequest) (*model.CreateAPIKeyResponse, error) {
+	principal, err := interactive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range req.Scopes {
+		if !authz.IsScope(scope) {
+			return nil, errno.ErrInvalidParams.WithMessage(fmt.Sprintf("无效的授权范围: %s", scope))
+		}
+		// 授权范围不能超过用户角色本身的权限
+		if scope == authz.ScopeUsersAdmin && principal.Role != authz.RoleAdmin {
+			return nil, errno.ErrForbidden.WithMessage("只有管理员可以创建 users:admin 授权范围的密钥")
+		}
+	}
+
+	keyID, err := randomString(keyIDLength, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomString(secretLength, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &model.APIKey{
+		KeyID:      keyID,
+		UserID:     principal.UserID,
+		Name:       req.Name,
+		Scopes:     strings.Join(dedup(req.Scopes), ","),
+		SecretHash: hashSecret(secret),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := a.store.APIKey().Create(ctx, key); err != nil {
+		a.logger.Error("创建API密钥失败", zap.String("userID", principal.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	a.logger.Info("创建API密钥成功",
+		zap.String("userID", principal.UserID),
+		zap.String("keyID", key.KeyID),
+		zap.String("scopes", key.Scopes),
+	)
+	return &model.CreateAPIKeyResponse{
+		Key:        token.APIKeyPrefix + keyID + "_" + secret,
+		APIKeyInfo: *apiKeyInfo(key),
+	}, nil
+}
+
+// ListAPIKeys implements APIKeyBiz.
+func (a *apiKeyBiz) ListAPIKeys(ctx context.Context) (*model.ListAPIKeyResponse, error) {
+	principal, err := interactive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := a.store.APIKey().ListByUserID(ctx, principal.UserID)
+	if err != nil {
+		a.logger.Error("查询API密钥列表失败", zap.String("userID", principal.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	list := make([]model.APIKeyInfo, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, *apiKeyInfo(key))
+	}
+	return &model.ListAPIKeyResponse{TotalCount: int64(len(list)), Keys: list}, nil
+}
+
+// DeleteAPIKey implements APIKeyBiz.
+func (a *apiKeyBiz) DeleteAPIKey(ctx context.Context, id uint) error {
+	principal, err := interactive(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := a.store.APIKey().Delete(ctx, principal.UserID, id); err != nil {
+		a.logger.Warn("删除API密钥失败", zap.String("userID", principal.UserID), zap.Uint("id", id), zap.Error(err))
+		return err
+	}
+
+	a.logger.Info("删除API密钥成功", zap.String("userID", principal.UserID), zap.Uint("id", id))
+	return nil
+}
+
+// Authenticate implements APIKeyBiz.
+func (a *apiKeyBiz) Authenticate(ctx context.Context, key string) (*contextx.Principal, error) {
+	keyID, secret, ok := strings.Cut(strings.TrimPrefix(key, token.APIKeyPrefix), "_")
+	if !ok || keyID == "" || secret == "" {
+		return nil, errno.ErrInvalidToken
+	}
+
+	apiKey, err := a.store.APIKey().GetByKeyID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, errno.ErrAPIKeyNotFound) {
+			return nil, errno.ErrInvalidToken
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.SecretHash), []byte(hashSecret(secret))) != 1 {
+		return nil, errno.ErrInvalidToken
+	}
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt) {
+		return nil, errno.ErrTokenExpired
+	}
+
+	// 使用用户当前的角色，角色变更对已创建的密钥立即生效
+	user, err := a.store.User().GetByUserID(ctx, apiKey.UserID)
+	if err != nil {
+		if errors.Is(err, errno.ErrUserNotFound) {
+			return nil, errno.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedInterval {
+		if err := a.store.APIKey().UpdateLastUsed(ctx, apiKey.ID, now); err != nil {
+			a.logger.Warn("更新API密钥使用时间失败", zap.String("keyID", apiKey.KeyID), zap.Error(err))
+		}
+	}
+
+	return &contextx.Principal{
+		UserID:   user.UserID,
+		Username: user.Username,
+		Role:     user.Role,
+		TokenID:  apiKey.KeyID,
+		APIKeyID: apiKey.KeyID,
+		Scopes:   strings.Split(apiKey.Scopes, ","),
+	}, nil
+}
+
+// interactive 返回当前调用方身份，API 密钥不能用于管理 API 密钥
+func interactive(ctx context.Context) (*contextx.Principal, error) {
+	principal, ok := contextx.PrincipalFrom(ctx)
+	if !ok {
+		return nil, errno.ErrUnauthorized
+	}
+	if principal.IsAPIKey() {
+		return nil, errno.ErrForbidden.WithMessage("不能使用API密钥管理API密钥")
+	}
+	return principal, nil
+}
+
+// randomString 生成 n 字节的随机数并编码为字符串
+func randomString(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %v", err)
+	}
+	return encode(b), nil
+}
+
+// hashSecret 计算密钥私密部分的哈希。密钥为高熵随机数，使用 SHA-256 即可抵御暴力破解
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// dedup 去除重复的授权范围并保持原有顺序
+func dedup(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	list := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			list = append(list, scope)
+		}
+	}
+	return list
+}
+
+// apiKeyInfo 将 API 密钥模型转换为响应结构
+func apiKeyInfo(key *model.APIKey) *model.APIKeyInfo {
+	return &model.APIKeyInfo{
+		ID:         key.ID,
+		KeyID:      key.KeyID,
+		Name:       key.Name,
+		Scopes:     strings.Split(key.Scopes, ","),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreateAt:   key.CreateAt,
+	}
+}
@@ -0,0 +1,197 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/apiserver/store/memory"
+	"github.com/lichenglife/easyblog/internal/pkg/authz"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/lichenglife/easyblog/internal/pkg/token"
+)
+
+// newTestBiz 创建使用内存存储的 API 密钥业务层，并创建角色为作者的用户 alice
+func newTestBiz(t *testing.T) (APIKeyBiz, store.IStore) {
+	t.Helper()
+	s := memory.NewStore()
+	user := &model.User{UserID: "user-1", Username: "alice", Email: "alice@example.com", Role: authz.RoleAuthor}
+	if err := s.User().Create(context.Background(), user); err != nil {
+		t.Fatalf("创建用户: %v", err)
+	}
+	return NewAPIKeyBiz(&log.Logger{Logger: zap.NewNop()}, s), s
+}
+
+// createKey 直接在存储中创建属于 alice 的 API 密钥，返回密钥明文
+func createKey(t *testing.T, s store.IStore, key *model.APIKey) string {
+	t.Helper()
+	key.UserID, key.SecretHash = "user-1", hashSecret("secret")
+	if key.Scopes == "" {
+		key.Scopes = authz.ScopePostsRead
+	}
+	if err := s.APIKey().Create(context.Background(), key); err != nil {
+		t.Fatalf("创建API密钥: %v", err)
+	}
+	return token.APIKeyPrefix + key.KeyID + "_secret"
+}
+
+func TestAuthenticate(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		key  *model.APIKey
+		// raw 不为空时使用 raw 作为请求中的密钥
+		raw  string
+		want error
+	}{
+		{name: "有效的密钥", key: &model.APIKey{KeyID: "k1", ExpiresAt: &future}},
+		{name: "永不过期的密钥", key: &model.APIKey{KeyID: "k1"}},
+		{name: "已过期的密钥", key: &model.APIKey{KeyID: "k1", ExpiresAt: &past}, want: errno.ErrTokenExpired},
+		{name: "错误的私密部分", key: &model.APIKey{KeyID: "k1"}, raw: token.APIKeyPrefix + "k1_wrong", want: errno.ErrInvalidToken},
+		{name: "不存在的密钥", key: &model.APIKey{KeyID: "k1"}, raw: token.APIKeyPrefix + "k2_secret", want: errno.ErrInvalidToken},
+		{name: "格式错误", key: &model.APIKey{KeyID: "k1"}, raw: token.APIKeyPrefix + "k1", want: errno.ErrInvalidToken},
+		{name: "缺少私密部分", key: &model.APIKey{KeyID: "k1"}, raw: token.APIKeyPrefix + "k1_", want: errno.ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, s := newTestBiz(t)
+			raw := createKey(t, s, tt.key)
+			if tt.raw != "" {
+				raw = tt.raw
+			}
+
+			principal, err := b.Authenticate(context.Background(), raw)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Authenticate err = %v，期望 %v", err, tt.want)
+			}
+			if err == nil && (principal.UserID != "user-1" || principal.APIKeyID != "k1" || !principal.IsAPIKey()) {
+				t.Errorf("Authenticate = %+v，期望 alice 的密钥 k1", principal)
+			}
+		})
+	}
+}
+
+func TestAuthenticateUsesCurrentRole(t *testing.T) {
+	ctx := context.Background()
+	b, s := newTestBiz(t)
+	raw := createKey(t, s, &model.APIKey{KeyID: "k1", Scopes: authz.ScopePostsRead + "," + authz.ScopePostsWrite})
+
+	user, err := s.User().GetByUserID(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetByUserID: %v", err)
+	}
+	user.Role = authz.RoleReader
+	if err := s.User().Update(ctx, user); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	principal, err := b.Authenticate(ctx, raw)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if principal.Role != authz.RoleReader {
+		t.Errorf("Role = %q，期望用户当前的角色 %q", principal.Role, authz.RoleReader)
+	}
+	if !slices.Equal(principal.Scopes, []string{authz.ScopePostsRead, authz.ScopePostsWrite}) {
+		t.Errorf("Scopes = %v", principal.Scopes)
+	}
+
+	// 用户被删除后密钥失效
+	if err := s.User().Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := b.Authenticate(ctx, raw); !errors.Is(err, errno.ErrInvalidToken) {
+		t.Errorf("用户删除后 Authenticate err = %v，期望 %v", err, errno.ErrInvalidToken)
+	}
+}
+
+func TestAuthenticateLastUsed(t *testing.T) {
+	ctx := context.Background()
+	recent, stale := time.Now().Add(-lastUsedInterval/2), time.Now().Add(-2*lastUsedInterval)
+
+	tests := []struct {
+		name       string
+		lastUsedAt *time.Time
+		wantUpdate bool
+	}{
+		{name: "从未使用", wantUpdate: true},
+		{name: "最近刚使用过", lastUsedAt: &recent, wantUpdate: false},
+		{name: "超过更新间隔", lastUsedAt: &stale, wantUpdate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, s := newTestBiz(t)
+			raw := createKey(t, s, &model.APIKey{KeyID: "k1", LastUsedAt: tt.lastUsedAt})
+
+			before := time.Now()
+			if _, err := b.Authenticate(ctx, raw); err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			key, err := s.APIKey().GetByKeyID(ctx, "k1")
+			if err != nil {
+				t.Fatalf("GetByKeyID: %v", err)
+			}
+			updated := key.LastUsedAt != nil && !key.LastUsedAt.Before(before)
+			if updated != tt.wantUpdate {
+				t.Errorf("LastUsedAt = %v，期望更新 %v", key.LastUsedAt, tt.wantUpdate)
+			}
+		})
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	author := &contextx.Principal{UserID: "user-1", Username: "alice", Role: authz.RoleAuthor}
+	admin := &contextx.Principal{UserID: "user-1", Username: "alice", Role: authz.RoleAdmin}
+	apiKey := &contextx.Principal{UserID: "user-1", Username: "alice", Role: authz.RoleAdmin, APIKeyID: "k1"}
+
+	tests := []struct {
+		name      string
+		principal *contextx.Principal
+		scopes    []string
+		want      error
+		// wantScopes 创建的密钥拥有的授权范围
+		wantScopes []string
+	}{
+		{name: "未认证", scopes: []string{authz.ScopePostsRead}, want: errno.ErrUnauthorized},
+		{name: "作者创建帖子授权范围", principal: author, scopes: []string{authz.ScopePostsRead, authz.ScopePostsWrite, authz.ScopePostsRead}, wantScopes: []string{authz.ScopePostsRead, authz.ScopePostsWrite}},
+		{name: "无效的授权范围", principal: author, scopes: []string{"posts:admin"}, want: errno.ErrInvalidParams},
+		{name: "作者不能创建 users:admin", principal: author, scopes: []string{authz.ScopeUsersAdmin}, want: errno.ErrForbidden},
+		{name: "管理员创建 users:admin", principal: admin, scopes: []string{authz.ScopeUsersAdmin}, wantScopes: []string{authz.ScopeUsersAdmin}},
+		{name: "不能使用 API 密钥创建密钥", principal: apiKey, scopes: []string{authz.ScopePostsRead}, want: errno.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBiz(t)
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = contextx.WithPrincipal(ctx, tt.principal)
+			}
+
+			resp, err := b.CreateAPIKey(ctx, &model.CreateAPIKeyRequest{Name: "ci", Scopes: tt.scopes})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("CreateAPIKey err = %v，期望 %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+
+			// 返回的密钥明文可以直接用于认证，重复的授权范围被去除
+			principal, err := b.Authenticate(context.Background(), resp.Key)
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if !slices.Equal(principal.Scopes, tt.wantScopes) {
+				t.Errorf("Scopes = %v，期望 %v", principal.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
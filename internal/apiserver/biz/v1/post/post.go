@@ -3,9 +3,20 @@ package biz
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/lichenglife/easyblog/internal/apiserver/model"
 	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/authz"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
 	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"go.uber.org/zap"
+)
+
+// 分页参数默认值
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
 )
 
 type PostBiz interface {
@@ -13,9 +24,9 @@ type PostBiz interface {
 	CreatePost(ctx context.Context, req *model.CreatePostRequest) (*model.Post, error)
 	// GetByID 根据 ID 获取帖子
 	GetPostByID(ctx context.Context, id uint) (*model.Post, error)
-	// Update 更新帖子
+	// Update 更新帖子，仅作者本人或管理员、编辑可以更新
 	UpdatePost(ctx context.Context, post *model.UpdatePostRequest) error
-	// Delete 删除帖子
+	// Delete 删除帖子，仅作者本人或管理员、编辑可以删除
 	DeletePost(ctx context.Context, id uint) error
 	// List 获取帖子列表
 	ListPosts(ctx context.Context, page, pageSize int) (*model.ListPostResponse, error)
@@ -25,52 +36,181 @@ type PostBiz interface {
 	GetPostByPostID(ctx context.Context, postID string) (*model.Post, error)
 }
 
+// Options 帖子业务依赖的配置
+type Options struct {
+	// RequireVerifiedEmail 为 true 时只有邮箱已验证的用户才能发布帖子
+	RequireVerifiedEmail bool
+}
+
 // NewPostBiz 实例化postBiz对象
-func NewPostBiz(logger *log.Logger, store store.PostStore) PostBiz {
+func NewPostBiz(logger *log.Logger, store store.IStore, opts *Options) PostBiz {
 
 	return &postBiz{
-		store: store,
+		logger:               logger,
+		store:                store,
+		requireVerifiedEmail: opts.RequireVerifiedEmail,
 	}
 }
 
 // postBiz	实现了post业务层接口
 type postBiz struct {
-	store store.PostStore
+	logger *log.Logger
+	store  store.IStore
+	// requireVerifiedEmail 是否只允许邮箱已验证的用户发布帖子
+	requireVerifiedEmail bool
 }
 
 // CreatePost implements PostBiz.
 func (p *postBiz) CreatePost(ctx context.Context, req *model.CreatePostRequest) (*model.Post, error) {
-	panic("unimplemented")
+	// 作者为当前登录用户，并确认作者存在
+	userID := contextx.UserID(ctx)
+	if userID == "" {
+		return nil, errno.ErrUnauthorized
+	}
+	user, err := p.store.User().GetByUserID(ctx, userID)
+	if err != nil {
+		p.logger.Warn("创建帖子失败，作者不存在", zap.String("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	if p.requireVerifiedEmail && !user.EmailVerified {
+		return nil, errno.ErrEmailNotVerified.WithMessage("邮箱验证后才能发布帖子")
+	}
+
+	post := &model.Post{
+		UserID:  userID,
+		PostID:  uuid.New().String(),
+		Title:   req.Title,
+		Content: req.Content,
+	}
+	if err := p.store.Post().Create(ctx, post); err != nil {
+		p.logger.Error("创建帖子失败", zap.String("userID", userID), zap.Error(err))
+		return nil, err
+	}
+
+	p.logger.Info("创建帖子成功", zap.String("userID", post.UserID), zap.String("postID", post.PostID))
+	return post, nil
 }
 
 // DeletePost implements PostBiz.
 func (p *postBiz) DeletePost(ctx context.Context, id uint) error {
-	panic("unimplemented")
+	post, err := p.store.Post().GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := p.checkOwner(ctx, post); err != nil {
+		return err
+	}
+
+	if err := p.store.Post().Delete(ctx, id); err != nil {
+		p.logger.Warn("删除帖子失败", zap.Uint("id", id), zap.Error(err))
+		return err
+	}
+
+	p.logger.Info("删除帖子成功", zap.Uint("id", id))
+	return nil
 }
 
 // GetPostByID implements PostBiz.
 func (p *postBiz) GetPostByID(ctx context.Context, id uint) (*model.Post, error) {
-	panic("unimplemented")
+	return p.store.Post().GetByID(ctx, id)
 }
 
 // GetPostByPostID implements PostBiz.
 func (p *postBiz) GetPostByPostID(ctx context.Context, postID string) (*model.Post, error) {
-	panic("unimplemented")
+	return p.store.Post().GetByPostID(ctx, postID)
 }
 
 // GetPostsByUserID implements PostBiz.
 func (p *postBiz) GetPostsByUserID(ctx context.Context, userID string, page int, pageSize int) (*model.ListPostResponse, error) {
-	panic("unimplemented")
+	page, pageSize = normalizePage(page, pageSize)
+
+	posts, total, err := p.store.Post().GetByUserID(ctx, userID, page, pageSize)
+	if err != nil {
+		p.logger.Error("查询用户帖子列表失败", zap.String("userID", userID), zap.Error(err))
+		return nil, err
+	}
+
+	return listPostResponse(posts, total, page, pageSize), nil
 }
 
 // ListPosts implements PostBiz.
 func (p *postBiz) ListPosts(ctx context.Context, page int, pageSize int) (*model.ListPostResponse, error) {
-	panic("unimplemented")
+	page, pageSize = normalizePage(page, pageSize)
+
+	posts, total, err := p.store.Post().List(ctx, page, pageSize)
+	if err != nil {
+		p.logger.Error("查询帖子列表失败", zap.Error(err))
+		return nil, err
+	}
+
+	return listPostResponse(posts, total, page, pageSize), nil
 }
 
 // UpdatePost implements PostBiz.
-func (p *postBiz) UpdatePost(ctx context.Context, post *model.UpdatePostRequest) error {
-	panic("unimplemented")
+func (p *postBiz) UpdatePost(ctx context.Context, req *model.UpdatePostRequest) error {
+	post, err := p.store.Post().GetByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	if err := p.checkOwner(ctx, post); err != nil {
+		return err
+	}
+
+	post.Title = req.Title
+	post.Content = req.Content
+	if err := p.store.Post().Update(ctx, post); err != nil {
+		p.logger.Error("更新帖子失败", zap.String("postID", post.PostID), zap.Error(err))
+		return err
+	}
+
+	p.logger.Info("更新帖子成功", zap.String("postID", post.PostID))
+	return nil
 }
 
 var _ PostBiz = (*postBiz)(nil)
+
+// checkOwner 校验调用方是否为帖子作者，管理员和编辑可以管理全部帖子
+func (p *postBiz) checkOwner(ctx context.Context, post *model.Post) error {
+	principal, ok := contextx.PrincipalFrom(ctx)
+	if !ok {
+		return errno.ErrUnauthorized
+	}
+	if principal.UserID == post.UserID || authz.IsPrivileged(principal.Role) {
+		return nil
+	}
+
+	p.logger.Warn("无权操作帖子",
+		zap.String("postID", post.PostID),
+		zap.String("owner", post.UserID),
+		zap.String("userID", principal.UserID),
+	)
+	return errno.ErrPostAccessDenied
+}
+
+// normalizePage 规范化分页参数
+func normalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
+
+// listPostResponse 构建帖子列表响应
+func listPostResponse(posts []*model.Post, total int64, page, pageSize int) *model.ListPostResponse {
+	list := make([]model.Post, 0, len(posts))
+	for _, post := range posts {
+		list = append(list, *post)
+	}
+
+	return &model.ListPostResponse{
+		TotalCount: total,
+		HasMore:    total > int64(page*pageSize),
+		Posts:      list,
+	}
+}
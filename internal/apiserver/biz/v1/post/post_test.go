@@ -0,0 +1,138 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/apiserver/store/memory"
+	"github.com/lichenglife/easyblog/internal/pkg/authz"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+)
+
+// newTestBiz 创建使用内存存储的帖子业务层
+func newTestBiz(opts *Options) (PostBiz, store.IStore) {
+	s := memory.NewStore()
+	return NewPostBiz(&log.Logger{Logger: zap.NewNop()}, s, opts), s
+}
+
+func TestPostOwnership(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *contextx.Principal
+		want      error
+	}{
+		{name: "未认证", want: errno.ErrUnauthorized},
+		{name: "作者本人", principal: &contextx.Principal{UserID: "owner", Role: authz.RoleAuthor}},
+		{name: "其他作者", principal: &contextx.Principal{UserID: "other", Role: authz.RoleAuthor}, want: errno.ErrPostAccessDenied},
+		{name: "读者", principal: &contextx.Principal{UserID: "other", Role: authz.RoleReader}, want: errno.ErrPostAccessDenied},
+		{name: "编辑", principal: &contextx.Principal{UserID: "editor", Role: authz.RoleEditor}},
+		{name: "管理员", principal: &contextx.Principal{UserID: "admin", Role: authz.RoleAdmin}},
+	}
+
+	operations := map[string]func(ctx context.Context, b PostBiz, id uint) error{
+		"update": func(ctx context.Context, b PostBiz, id uint) error {
+			return b.UpdatePost(ctx, &model.UpdatePostRequest{ID: id, Title: "新标题", Content: "新内容"})
+		},
+		"delete": func(ctx context.Context, b PostBiz, id uint) error {
+			return b.DeletePost(ctx, id)
+		},
+	}
+
+	for _, tt := range tests {
+		for op, run := range operations {
+			t.Run(tt.name+"/"+op, func(t *testing.T) {
+				b, s := newTestBiz(&Options{})
+				post := &model.Post{UserID: "owner", PostID: "post-1", Title: "标题", Content: "内容"}
+				if err := s.Post().Create(context.Background(), post); err != nil {
+					t.Fatalf("创建帖子: %v", err)
+				}
+
+				ctx := context.Background()
+				if tt.principal != nil {
+					ctx = contextx.WithPrincipal(ctx, tt.principal)
+				}
+				if err := run(ctx, b, post.ID); !errors.Is(err, tt.want) {
+					t.Fatalf("err = %v，期望 %v", err, tt.want)
+				}
+
+				// 被拒绝时帖子保持不变
+				got, err := s.Post().GetByID(context.Background(), post.ID)
+				switch {
+				case tt.want != nil && (err != nil || got.Title != "标题"):
+					t.Errorf("操作被拒绝后帖子 = %+v, err = %v，期望保持不变", got, err)
+				case tt.want == nil && op == "update" && (err != nil || got.Title != "新标题"):
+					t.Errorf("更新后帖子 = %+v, err = %v", got, err)
+				case tt.want == nil && op == "delete" && !errors.Is(err, errno.ErrPostNotFound):
+					t.Errorf("删除后查询帖子 err = %v，期望 %v", err, errno.ErrPostNotFound)
+				}
+			})
+		}
+	}
+}
+
+func TestCreatePostAuthor(t *testing.T) {
+	b, s := newTestBiz(&Options{})
+	if err := s.User().Create(context.Background(), &model.User{UserID: "owner", Username: "owner"}); err != nil {
+		t.Fatalf("创建用户: %v", err)
+	}
+
+	if _, err := b.CreatePost(context.Background(), &model.CreatePostRequest{Title: "标题", Content: "内容"}); !errors.Is(err, errno.ErrUnauthorized) {
+		t.Errorf("未认证时创建帖子 err = %v，期望 %v", err, errno.ErrUnauthorized)
+	}
+
+	ctx := contextx.WithPrincipal(context.Background(), &contextx.Principal{UserID: "owner", Role: authz.RoleAuthor})
+	post, err := b.CreatePost(ctx, &model.CreatePostRequest{Title: "标题", Content: "内容"})
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	if post.UserID != "owner" {
+		t.Errorf("帖子作者 = %q，期望当前登录用户 owner", post.UserID)
+	}
+}
+
+func TestCreatePostRequiresVerifiedEmail(t *testing.T) {
+	tests := []struct {
+		name          string
+		require       bool
+		emailVerified bool
+		want          error
+	}{
+		{name: "未开启验证要求", require: false, emailVerified: false},
+		{name: "邮箱未验证", require: true, emailVerified: false, want: errno.ErrEmailNotVerified},
+		{name: "邮箱已验证", require: true, emailVerified: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, s := newTestBiz(&Options{RequireVerifiedEmail: tt.require})
+			user := &model.User{UserID: "owner", Username: "owner", EmailVerified: tt.emailVerified}
+			if err := s.User().Create(context.Background(), user); err != nil {
+				t.Fatalf("创建用户: %v", err)
+			}
+
+			ctx := contextx.WithPrincipal(context.Background(), &contextx.Principal{UserID: "owner", Role: authz.RoleAuthor})
+			_, err := b.CreatePost(ctx, &model.CreatePostRequest{Title: "标题", Content: "内容"})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("CreatePost err = %v，期望 %v", err, tt.want)
+			}
+			_, total, err := s.Post().List(context.Background(), 1, 10)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			// 被拒绝时不创建帖子
+			wantTotal := int64(1)
+			if tt.want != nil {
+				wantTotal = 0
+			}
+			if total != wantTotal {
+				t.Errorf("帖子数 = %d，期望 %d", total, wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/notify"
+	"go.uber.org/zap"
+)
+
+// defaultVerifyExpire 邮箱验证链接默认有效期
+const defaultVerifyExpire = 24 * time.Hour
+
+// VerifyEmail implements UserBiz.
+func (u *userBiz) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := u.tokens.ParseEmailVerification(token)
+	if err != nil {
+		return errno.ErrVerifyLinkInvalid
+	}
+
+	user, err := u.store.User().GetByUserID(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, errno.ErrUserNotFound) {
+			return errno.ErrVerifyLinkInvalid
+		}
+		return err
+	}
+	// 验证链接发送后用户修改了邮箱，旧链接随即失效
+	if user.Email != claims.Email {
+		return errno.ErrVerifyLinkInvalid
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	user.EmailVerified = true
+	if err := u.store.User().Update(ctx, user); err != nil {
+		u.logger.Error("验证邮箱失败", zap.String("userID", user.UserID), zap.Error(err))
+		return err
+	}
+
+	u.logger.Info("验证邮箱成功", zap.String("userID", user.UserID))
+	return nil
+}
+
+// ResendVerification implements UserBiz.
+func (u *userBiz) ResendVerification(ctx context.Context) error {
+	userID := contextx.UserID(ctx)
+	if userID == "" {
+		return errno.ErrUnauthorized
+	}
+
+	user, err := u.store.User().GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return errno.ErrInvalidParams.WithMessage("邮箱已验证")
+	}
+
+	u.sendVerification(ctx, user)
+	return nil
+}
+
+// sendVerification 向用户邮箱发送验证链接，失败时只记录日志，用户可以重新申请发送
+func (u *userBiz) sendVerification(ctx context.Context, user *model.User) {
+	token, err := u.tokens.SignEmailVerification(user.UserID, user.Email, u.verifyExpire)
+	if err != nil {
+		u.logger.Error("签发邮箱验证令牌失败", zap.String("userID", user.UserID), zap.Error(err))
+		return
+	}
+
+	u.notify(ctx, user, &notify.Message{
+		To:      user.Email,
+		Subject: "验证邮箱",
+		Body: fmt.Sprintf("请在 %d 小时内访问以下链接验证账号 %s 的邮箱：\n%s\n如果不是您本人操作，请忽略该通知。",
+			int(u.verifyExpire.Hours()), user.Username, tokenLink(u.verifyURL, token)),
+	})
+}
@@ -0,0 +1,172 @@
+package biz
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/token"
+)
+
+// emailVerified 返回用户邮箱是否已验证
+func (env *testEnv) emailVerified(t *testing.T, username string) bool {
+	t.Helper()
+	user, err := env.store.User().GetByUsername(context.Background(), username)
+	if err != nil {
+		t.Fatalf("GetByUsername: %v", err)
+	}
+	return user.EmailVerified
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	env := newTestBiz(t)
+	env.register(t, "alice")
+	link := env.notifier.receive(t, "验证邮箱")
+
+	if env.emailVerified(t, "alice") {
+		t.Fatal("注册后邮箱应为未验证")
+	}
+	if err := env.biz.VerifyEmail(ctx, link); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if !env.emailVerified(t, "alice") {
+		t.Error("验证后邮箱应为已验证")
+	}
+	// 重复访问验证链接不报错
+	if err := env.biz.VerifyEmail(ctx, link); err != nil {
+		t.Errorf("重复 VerifyEmail: %v", err)
+	}
+}
+
+func TestVerifyEmailInvalidLink(t *testing.T) {
+	// otherTokens 使用其他密钥签名的令牌管理器
+	config := viper.New()
+	config.Set("jwt.secret", "another-secret-0123456789abcdef-xyz")
+	otherTokens, err := token.NewManager(config, cache.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		link func(t *testing.T, env *testEnv, user *model.User) string
+	}{
+		{
+			name: "链接已过期",
+			link: func(t *testing.T, env *testEnv, user *model.User) string {
+				link, err := env.tokens.SignEmailVerification(user.UserID, user.Email, -time.Minute)
+				if err != nil {
+					t.Fatalf("SignEmailVerification: %v", err)
+				}
+				return link
+			},
+		},
+		{
+			// 将 alice 的验证链接改为 bob 的用户 ID 和邮箱
+			name: "篡改链接中的用户",
+			link: func(t *testing.T, env *testEnv, user *model.User) string {
+				parts := strings.Split(env.notifier.receive(t, "验证邮箱"), ".")
+				bob := env.register(t, "bob")
+				payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+				if err != nil {
+					t.Fatalf("解析验证令牌: %v", err)
+				}
+				payload = []byte(strings.NewReplacer(user.UserID, bob.UserID, user.Email, bob.Email).Replace(string(payload)))
+				parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+				return strings.Join(parts, ".")
+			},
+		},
+		{
+			name: "其他密钥签名",
+			link: func(t *testing.T, _ *testEnv, user *model.User) string {
+				link, err := otherTokens.SignEmailVerification(user.UserID, user.Email, time.Hour)
+				if err != nil {
+					t.Fatalf("SignEmailVerification: %v", err)
+				}
+				return link
+			},
+		},
+		{
+			name: "使用访问令牌",
+			link: func(t *testing.T, env *testEnv, _ *model.User) string {
+				resp, err := env.login("alice", testPassword)
+				if err != nil {
+					t.Fatalf("Login: %v", err)
+				}
+				return resp.Token
+			},
+		},
+		{
+			name: "发送链接后修改了邮箱",
+			link: func(t *testing.T, env *testEnv, user *model.User) string {
+				link := env.notifier.receive(t, "验证邮箱")
+				user.Email = "alice@example.org"
+				if err := env.store.User().Update(context.Background(), user); err != nil {
+					t.Fatalf("Update: %v", err)
+				}
+				return link
+			},
+		},
+		{
+			name: "用户已删除",
+			link: func(t *testing.T, env *testEnv, user *model.User) string {
+				link := env.notifier.receive(t, "验证邮箱")
+				if err := env.store.User().Delete(context.Background(), user.ID); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+				return link
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestBiz(t)
+			env.register(t, "alice")
+			user, err := env.store.User().GetByUsername(context.Background(), "alice")
+			if err != nil {
+				t.Fatalf("GetByUsername: %v", err)
+			}
+
+			if err := env.biz.VerifyEmail(context.Background(), tt.link(t, env, user)); !errors.Is(err, errno.ErrVerifyLinkInvalid) {
+				t.Errorf("VerifyEmail err = %v，期望 %v", err, errno.ErrVerifyLinkInvalid)
+			}
+		})
+	}
+}
+
+func TestUpdateEmailRequiresVerification(t *testing.T) {
+	env := newTestBiz(t)
+	env.register(t, "alice")
+	if err := env.biz.VerifyEmail(context.Background(), env.notifier.receive(t, "验证邮箱")); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	user, err := env.store.User().GetByUsername(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("GetByUsername: %v", err)
+	}
+
+	ctx := contextx.WithPrincipal(context.Background(), &contextx.Principal{UserID: user.UserID, Username: user.Username, Role: user.Role})
+	if _, err := env.biz.UpdateUser(ctx, user.ID, &model.UpdateUser{Email: "alice@example.org"}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if env.emailVerified(t, "alice") {
+		t.Error("修改邮箱后应需要重新验证")
+	}
+	// 向新邮箱发送验证链接
+	if err := env.biz.VerifyEmail(context.Background(), env.notifier.receive(t, "验证邮箱")); err != nil {
+		t.Fatalf("验证新邮箱: %v", err)
+	}
+	if !env.emailVerified(t, "alice") {
+		t.Error("验证新邮箱后邮箱应为已验证")
+	}
+}
@@ -0,0 +1,249 @@
+package biz
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/mfa"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultChallengeExpire 两步验证挑战令牌默认有效期
+	defaultChallengeExpire = 5 * time.Minute
+	// recoveryCodeCount 启用两步验证时生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+// EnrollMFA implements UserBiz.
+func (u *userBiz) EnrollMFA(ctx context.Context) (*model.EnrollMFAResponse, error) {
+	user, err := u.interactiveUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := u.store.UserMFA().Get(ctx, user.UserID)
+	if err != nil && !errors.Is(err, errno.ErrMFANotEnabled) {
+		return nil, err
+	}
+	if record != nil && record.Enabled {
+		return nil, errno.ErrInvalidParams.WithMessage("已启用两步验证，请先关闭后再重新注册")
+	}
+
+	enrollment, err := u.mfa.Enroll(user.Username)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := u.mfa.Encrypt(enrollment.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// 未完成验证的注册会被新的注册覆盖
+	err = u.store.TX(ctx, func(ctx context.Context) error {
+		if err := u.store.UserMFA().Delete(ctx, user.UserID); err != nil {
+			return err
+		}
+		return u.store.UserMFA().Create(ctx, &model.UserMFA{UserID: user.UserID, Secret: secret})
+	})
+	if err != nil {
+		u.logger.Error("注册两步验证失败", zap.String("userID", user.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	u.logger.Info("注册两步验证成功，等待验证", zap.String("userID", user.UserID))
+	return &model.EnrollMFAResponse{
+		Secret: enrollment.Secret,
+		URL:    enrollment.URL,
+		QRCode: base64.StdEncoding.EncodeToString(enrollment.QRCode),
+	}, nil
+}
+
+// EnableMFA implements UserBiz.
+func (u *userBiz) EnableMFA(ctx context.Context, req *model.MFACodeRequest) (*model.EnableMFAResponse, error) {
+	user, err := u.interactiveUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := u.store.UserMFA().Get(ctx, user.UserID)
+	if err != nil {
+		if errors.Is(err, errno.ErrMFANotEnabled) {
+			return nil, errno.ErrMFANotEnabled.WithMessage("请先注册两步验证")
+		}
+		return nil, err
+	}
+	if record.Enabled {
+		return nil, errno.ErrInvalidParams.WithMessage("已启用两步验证")
+	}
+	// 启用时必须使用验证码，确认用户已正确保存密钥
+	step, err := u.validateCode(record, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := mfa.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, mfa.HashRecoveryCode(code))
+	}
+	record.Enabled = true
+	record.LastStep = step
+	record.RecoveryCodes = strings.Join(hashes, ",")
+	if err := u.store.UserMFA().Update(ctx, record); err != nil {
+		u.logger.Error("启用两步验证失败", zap.String("userID", user.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	// 注销未经过两步验证的会话，并为当前用户签发通过两步验证的新令牌
+	if err := u.tokens.RevokeUser(ctx, user.UserID); err != nil {
+		return nil, err
+	}
+	id := identity(user)
+	id.MFA = true
+	pair, err := u.tokens.Issue(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.logger.Info("启用两步验证成功", zap.String("userID", user.UserID))
+	return &model.EnableMFAResponse{
+		RecoveryCodes:    codes,
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	}, nil
+}
+
+// DisableMFA implements UserBiz.
+func (u *userBiz) DisableMFA(ctx context.Context, req *model.MFACodeRequest) error {
+	user, err := u.interactiveUser(ctx)
+	if err != nil {
+		return err
+	}
+	if u.mfaRequired(user.Role) {
+		return errno.ErrMFARequired.WithMessage("当前角色必须启用两步验证，不能关闭")
+	}
+
+	record, err := u.store.UserMFA().Get(ctx, user.UserID)
+	if err != nil {
+		return err
+	}
+	if !record.Enabled {
+		return errno.ErrMFANotEnabled
+	}
+	if err := u.checkSecondFactor(ctx, record, req); err != nil {
+		return err
+	}
+
+	if err := u.store.UserMFA().Delete(ctx, user.UserID); err != nil {
+		u.logger.Error("关闭两步验证失败", zap.String("userID", user.UserID), zap.Error(err))
+		return err
+	}
+
+	u.logger.Info("关闭两步验证成功", zap.String("userID", user.UserID))
+	return nil
+}
+
+// LoginMFA implements UserBiz.
+func (u *userBiz) LoginMFA(ctx context.Context, req *model.MFALoginRequest) (*model.UserLoginResponse, error) {
+	claims, err := u.tokens.ParseMFAChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.store.User().GetByUserID(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, errno.ErrUserNotFound) {
+			return nil, errno.ErrInvalidToken
+		}
+		return nil, err
+	}
+	// 验证码错误与密码错误共用失败次数，防止在挑战令牌有效期内穷举验证码
+	if err := u.lockout.Check(ctx, user.Username, contextx.ClientIP(ctx)); err != nil {
+		return nil, err
+	}
+	record, err := u.store.UserMFA().Get(ctx, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !record.Enabled {
+		return nil, errno.ErrMFANotEnabled
+	}
+	if err := u.checkSecondFactor(ctx, record, &req.MFACodeRequest); err != nil {
+		u.logger.Warn("两步验证登录失败，验证码错误", zap.String("userID", user.UserID))
+		if errors.Is(err, errno.ErrMFACodeInvalid) {
+			u.loginFailed(ctx, user.Username)
+		}
+		return nil, err
+	}
+
+	id := identity(user)
+	id.MFA = true
+	return u.issueLogin(ctx, user, id)
+}
+
+// mfaRequired 判断角色是否必须启用两步验证
+func (u *userBiz) mfaRequired(role string) bool {
+	return u.enforcer != nil && u.enforcer.MFARequired(role)
+}
+
+// interactiveUser 返回当前调用方对应的用户，API 密钥不能管理两步验证
+func (u *userBiz) interactiveUser(ctx context.Context) (*model.User, error) {
+	principal, err := interactivePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.store.User().GetByUserID(ctx, principal.UserID)
+}
+
+// validateCode 校验 TOTP 验证码，返回验证码对应的时间步
+func (u *userBiz) validateCode(record *model.UserMFA, code string) (int64, error) {
+	secret, err := u.mfa.Decrypt(record.Secret)
+	if err != nil {
+		u.logger.Error("解密 TOTP 密钥失败", zap.String("userID", record.UserID), zap.Error(err))
+		return 0, err
+	}
+
+	step, ok := u.mfa.Validate(secret, strings.TrimSpace(code), record.LastStep)
+	if !ok {
+		return 0, errno.ErrMFACodeInvalid
+	}
+	return step, nil
+}
+
+// checkSecondFactor 校验 TOTP 验证码或恢复码，验证码和恢复码都只能使用一次
+func (u *userBiz) checkSecondFactor(ctx context.Context, record *model.UserMFA, req *model.MFACodeRequest) error {
+	if req.Code != "" {
+		step, err := u.validateCode(record, req.Code)
+		if err != nil {
+			return err
+		}
+		return u.store.UserMFA().UseStep(ctx, record.ID, step)
+	}
+
+	hashes := strings.Split(record.RecoveryCodes, ",")
+	i := slices.Index(hashes, mfa.HashRecoveryCode(req.RecoveryCode))
+	if req.RecoveryCode == "" || record.RecoveryCodes == "" || i < 0 {
+		return errno.ErrMFACodeInvalid
+	}
+	remaining := slices.Delete(hashes, i, i+1)
+	if err := u.store.UserMFA().UseRecoveryCode(ctx, record.ID, record.RecoveryCodes, strings.Join(remaining, ",")); err != nil {
+		return err
+	}
+
+	u.logger.Warn("使用恢复码完成两步验证", zap.String("userID", record.UserID), zap.Int("remaining", len(remaining)))
+	return nil
+}
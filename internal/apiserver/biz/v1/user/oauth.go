@@ -0,0 +1,315 @@
+package biz
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/oauth"
+	"go.uber.org/zap"
+)
+
+// 自动创建用户时生成用户名的参数
+const (
+	usernameMinLen = 3
+	usernameMaxLen = 20
+	// usernameAttempts 用户名已被占用时追加随机后缀重试的次数
+	usernameAttempts = 5
+)
+
+// OAuthLogin implements UserBiz.
+func (u *userBiz) OAuthLogin(ctx context.Context, provider string) (*model.OAuthAuthorizeResponse, error) {
+	authURL, state, err := u.oauth.Begin(ctx, provider, "")
+	if err != nil {
+		if !errors.Is(err, errno.ErrOAuthProviderNotFound) {
+			u.logger.Error("开始第三方登录失败", zap.String("provider", provider), zap.Error(err))
+		}
+		return nil, err
+	}
+	return &model.OAuthAuthorizeResponse{AuthURL: authURL, State: state}, nil
+}
+
+// LinkIdentity implements UserBiz.
+func (u *userBiz) LinkIdentity(ctx context.Context, provider string) (*model.OAuthAuthorizeResponse, error) {
+	principal, err := interactivePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.oauth.Provider(provider); err != nil {
+		return nil, err
+	}
+	if err := u.checkNotLinked(ctx, principal.UserID, provider); err != nil {
+		return nil, err
+	}
+
+	authURL, state, err := u.oauth.Begin(ctx, provider, principal.UserID)
+	if err != nil {
+		u.logger.Error("开始关联第三方账号失败", zap.String("userID", principal.UserID), zap.String("provider", provider), zap.Error(err))
+		return nil, err
+	}
+	return &model.OAuthAuthorizeResponse{AuthURL: authURL, State: state}, nil
+}
+
+// OAuthCallback implements UserBiz.
+func (u *userBiz) OAuthCallback(ctx context.Context, provider string, req *model.OAuthCallbackRequest) (*model.OAuthCallbackResponse, error) {
+	result, err := u.oauth.Complete(ctx, provider, req.State, req.Code)
+	if err != nil {
+		u.logger.Warn("第三方登录失败", zap.String("provider", provider), zap.Error(err))
+		return nil, err
+	}
+
+	if result.UserID != "" {
+		if err := u.linkIdentity(ctx, result); err != nil {
+			return nil, err
+		}
+		return &model.OAuthCallbackResponse{Provider: provider, Linked: true}, nil
+	}
+
+	user, err := u.identityUser(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.completeLogin(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OAuthCallbackResponse{UserLoginResponse: resp, Provider: provider}, nil
+}
+
+// ListIdentities implements UserBiz.
+func (u *userBiz) ListIdentities(ctx context.Context) (*model.ListUserIdentityResponse, error) {
+	principal, err := interactivePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := u.store.UserIdentity().ListByUserID(ctx, principal.UserID)
+	if err != nil {
+		u.logger.Error("查询第三方账号失败", zap.String("userID", principal.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	list := make([]model.UserIdentityInfo, 0, len(identities))
+	for _, identity := range identities {
+		list = append(list, model.UserIdentityInfo{
+			Provider: identity.Provider,
+			Email:    identity.Email,
+			CreateAt: identity.CreateAt,
+		})
+	}
+	return &model.ListUserIdentityResponse{TotalCount: int64(len(list)), Identities: list}, nil
+}
+
+// UnlinkIdentity implements UserBiz.
+func (u *userBiz) UnlinkIdentity(ctx context.Context, provider string) error {
+	principal, err := interactivePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := u.store.UserIdentity().Delete(ctx, principal.UserID, provider); err != nil {
+		return err
+	}
+
+	u.logger.Info("解除第三方账号关联成功", zap.String("userID", principal.UserID), zap.String("provider", provider))
+	return nil
+}
+
+// linkIdentity 将第三方账号关联到发起关联的用户
+func (u *userBiz) linkIdentity(ctx context.Context, result *oauth.Result) error {
+	provider := result.Provider.Name()
+	// 发起关联后用户可能已被删除或已关联同一身份提供方的其他账号
+	if _, err := u.store.User().GetByUserID(ctx, result.UserID); err != nil {
+		return err
+	}
+	if err := u.checkNotLinked(ctx, result.UserID, provider); err != nil {
+		return err
+	}
+
+	if err := u.store.UserIdentity().Create(ctx, newIdentity(result.UserID, result)); err != nil {
+		u.logger.Warn("关联第三方账号失败", zap.String("userID", result.UserID), zap.String("provider", provider), zap.Error(err))
+		return err
+	}
+
+	u.logger.Info("关联第三方账号成功", zap.String("userID", result.UserID), zap.String("provider", provider))
+	return nil
+}
+
+// identityUser 返回第三方账号关联的用户。未关联时，按配置关联邮箱相同的已有用户或自动创建用户
+func (u *userBiz) identityUser(ctx context.Context, result *oauth.Result) (*model.User, error) {
+	provider, claims := result.Provider, result.Claims
+
+	identity, err := u.store.UserIdentity().Get(ctx, provider.Name(), claims.Subject)
+	if err == nil {
+		return u.store.User().GetByUserID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, errno.ErrIdentityNotLinked) {
+		return nil, err
+	}
+
+	// 双方都验证过邮箱时才按邮箱关联，避免通过未验证的邮箱接管他人账号
+	if provider.LinkByEmail() && claims.Email != "" && claims.EmailVerified {
+		user, err := u.store.User().GetByEmail(ctx, claims.Email)
+		switch {
+		case err == nil && user.EmailVerified:
+			if err := u.store.UserIdentity().Create(ctx, newIdentity(user.UserID, result)); err != nil {
+				return nil, err
+			}
+			u.logger.Info("按邮箱关联第三方账号成功", zap.String("userID", user.UserID), zap.String("provider", provider.Name()))
+			return user, nil
+		case err != nil && !errors.Is(err, errno.ErrUserNotFound):
+			return nil, err
+		}
+	}
+
+	if !provider.AutoProvision() {
+		u.logger.Warn("第三方账号未关联用户", zap.String("provider", provider.Name()), zap.String("subject", claims.Subject))
+		return nil, errno.ErrIdentityNotLinked
+	}
+	return u.provisionUser(ctx, result)
+}
+
+// provisionUser 为未关联的第三方账号创建用户，用户没有手机号，密码为随机值，需要通过重置密码设置
+func (u *userBiz) provisionUser(ctx context.Context, result *oauth.Result) (*model.User, error) {
+	provider, claims := result.Provider, result.Claims
+
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := u.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		UserID:        uuid.New().String(),
+		Password:      hash,
+		Email:         claims.Email,
+		EmailVerified: claims.Email != "" && claims.EmailVerified,
+		Role:          u.defaultRole,
+	}
+	err = u.store.TX(ctx, func(ctx context.Context) error {
+		username, err := u.availableUsername(ctx, claims)
+		if err != nil {
+			return err
+		}
+		user.Username = username
+		user.NickName = truncate(claims.Name, 30)
+		if user.NickName == "" {
+			user.NickName = username
+		}
+		if err := u.store.User().Create(ctx, user); err != nil {
+			return err
+		}
+		return u.store.UserIdentity().Create(ctx, newIdentity(user.UserID, result))
+	})
+	if err != nil {
+		u.logger.Error("通过第三方账号创建用户失败", zap.String("provider", provider.Name()), zap.String("subject", claims.Subject), zap.Error(err))
+		return nil, err
+	}
+
+	u.logger.Info("通过第三方账号创建用户成功",
+		zap.String("userID", user.UserID),
+		zap.String("username", user.Username),
+		zap.String("provider", provider.Name()),
+	)
+	if user.Email != "" && !user.EmailVerified {
+		u.sendVerification(ctx, user)
+	}
+	return user, nil
+}
+
+// checkNotLinked 校验用户尚未关联该身份提供方的账号
+func (u *userBiz) checkNotLinked(ctx context.Context, userID, provider string) error {
+	identities, err := u.store.UserIdentity().ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			return errno.ErrIdentityAlreadyLinked.WithMessage("已关联该身份提供方的账号，请先解除关联")
+		}
+	}
+	return nil
+}
+
+// availableUsername 根据第三方账号信息生成未被占用的用户名
+func (u *userBiz) availableUsername(ctx context.Context, claims *oauth.Claims) (string, error) {
+	base := sanitizeUsername(claims.PreferredUsername)
+	if base == "" {
+		local, _, _ := strings.Cut(claims.Email, "@")
+		base = sanitizeUsername(local)
+	}
+	if len(base) < usernameMinLen {
+		base = "user"
+	}
+
+	username := base
+	for range usernameAttempts {
+		_, err := u.store.User().GetByUsername(ctx, username)
+		if errors.Is(err, errno.ErrUserNotFound) {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return "", fmt.Errorf("生成随机数失败: %v", err)
+		}
+		suffix := fmt.Sprintf("_%06d", n.Int64())
+		username = truncate(base, usernameMaxLen-len(suffix)) + suffix
+	}
+	return "", errno.ErrUserAlreadyExist
+}
+
+// newIdentity 创建第三方账号关联记录
+func newIdentity(userID string, result *oauth.Result) *model.UserIdentity {
+	return &model.UserIdentity{
+		UserID:   userID,
+		Provider: result.Provider.Name(),
+		Subject:  result.Claims.Subject,
+		Email:    result.Claims.Email,
+	}
+}
+
+// sanitizeUsername 只保留字母、数字和下划线，并截断到用户名最大长度
+func sanitizeUsername(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		case r == '.' || r == '-':
+			b.WriteByte('_')
+		}
+	}
+	return truncate(b.String(), usernameMaxLen)
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// randomPassword 生成随机密码
+func randomPassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机密码失败: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,186 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/oauth"
+	"github.com/lichenglife/easyblog/internal/pkg/oauth/oauthtest"
+)
+
+// withOAuth 启动模拟身份提供方，并将其配置为名为 mock 的身份提供方
+func withOAuth(t *testing.T, autoProvision bool) (func(*Options), *oauthtest.Provider) {
+	t.Helper()
+
+	p, err := oauthtest.NewProvider("easyblog", "easyblog-secret")
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	t.Cleanup(p.Close)
+
+	config := viper.New()
+	config.Set("oauth.providers", []map[string]any{{
+		"name":          "mock",
+		"issuer":        p.Issuer(),
+		"clientID":      p.ClientID,
+		"clientSecret":  p.ClientSecret,
+		"redirectURL":   "https://blog.example.com/v1/oauth/mock/callback",
+		"autoProvision": autoProvision,
+	}})
+	manager, err := oauth.NewManager(config, cache.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return func(o *Options) { o.OAuth = manager }, p
+}
+
+// oauthCallback 由模拟用户同意授权后完成回调
+func (env *testEnv) oauthCallback(t *testing.T, p *oauthtest.Provider, authorize *model.OAuthAuthorizeResponse) (*model.OAuthCallbackResponse, error) {
+	t.Helper()
+	code, state, err := p.Authorize(authorize.AuthURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	return env.biz.OAuthCallback(context.Background(), "mock", &model.OAuthCallbackRequest{Code: code, State: state})
+}
+
+// oauthLogin 通过 mock 身份提供方登录
+func (env *testEnv) oauthLogin(t *testing.T, p *oauthtest.Provider) (*model.OAuthCallbackResponse, error) {
+	t.Helper()
+	authorize, err := env.biz.OAuthLogin(context.Background(), "mock")
+	if err != nil {
+		t.Fatalf("OAuthLogin: %v", err)
+	}
+	return env.oauthCallback(t, p, authorize)
+}
+
+// linkIdentity 为会话对应的用户关联当前的模拟用户
+func (env *testEnv) linkIdentity(t *testing.T, p *oauthtest.Provider, s *testSession) (*model.OAuthCallbackResponse, error) {
+	t.Helper()
+	authorize, err := env.biz.LinkIdentity(s.ctx, "mock")
+	if err != nil {
+		t.Fatalf("LinkIdentity: %v", err)
+	}
+	return env.oauthCallback(t, p, authorize)
+}
+
+func TestOAuthLoginProvision(t *testing.T) {
+	opt, p := withOAuth(t, true)
+	env := newTestBiz(t, opt)
+	p.SetUser(oauthtest.User{Subject: "carol", Email: "carol.lee@example.com", EmailVerified: true, Name: "Carol"})
+
+	resp, err := env.oauthLogin(t, p)
+	if err != nil {
+		t.Fatalf("首次登录: %v", err)
+	}
+	if resp.Token == "" || resp.Linked {
+		t.Fatalf("首次登录响应 = %+v，期望签发令牌", resp)
+	}
+	user := resp.User
+	if user.Username != "carol_lee" || user.Nickname != "Carol" || user.Email != "carol.lee@example.com" || user.Phone != "" {
+		t.Errorf("自动创建的用户 = %+v", user)
+	}
+	created, err := env.store.User().GetByUserID(context.Background(), user.UserID)
+	if err != nil {
+		t.Fatalf("GetByUserID: %v", err)
+	}
+	if !created.EmailVerified {
+		t.Error("身份提供方已验证邮箱时，自动创建的用户邮箱应为已验证")
+	}
+
+	// 再次登录使用已关联的用户
+	resp, err = env.oauthLogin(t, p)
+	if err != nil {
+		t.Fatalf("再次登录: %v", err)
+	}
+	if resp.User.UserID != user.UserID {
+		t.Errorf("再次登录的用户 = %s，期望 %s", resp.User.UserID, user.UserID)
+	}
+	// 用户名已被占用时追加随机后缀
+	p.SetUser(oauthtest.User{Subject: "carol-2", Email: "carol.lee@example.org"})
+	resp, err = env.oauthLogin(t, p)
+	if err != nil {
+		t.Fatalf("其他账号登录: %v", err)
+	}
+	if resp.User.UserID == user.UserID || len(resp.User.Username) != len("carol_lee_000000") {
+		t.Errorf("其他账号登录的用户 = %+v，期望创建新用户", resp.User)
+	}
+}
+
+func TestOAuthLoginNotLinked(t *testing.T) {
+	opt, p := withOAuth(t, false)
+	env := newTestBiz(t, opt)
+	env.register(t, "alice")
+	p.SetUser(oauthtest.User{Subject: "alice", Email: "alice@example.com", EmailVerified: true})
+
+	// 未开启自动创建用户和按邮箱关联时，未关联的账号不能登录
+	if _, err := env.oauthLogin(t, p); !errors.Is(err, errno.ErrIdentityNotLinked) {
+		t.Errorf("OAuthCallback err = %v，期望 %v", err, errno.ErrIdentityNotLinked)
+	}
+	if _, err := env.biz.OAuthLogin(context.Background(), "missing"); !errors.Is(err, errno.ErrOAuthProviderNotFound) {
+		t.Errorf("OAuthLogin err = %v，期望 %v", err, errno.ErrOAuthProviderNotFound)
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	opt, p := withOAuth(t, false)
+	env := newTestBiz(t, opt)
+	env.register(t, "alice")
+	env.register(t, "bob")
+	alice := env.loginFrom(t, "alice", "laptop")
+	bob := env.loginFrom(t, "bob", "laptop")
+	p.SetUser(oauthtest.User{Subject: "alice", Email: "alice@example.org", EmailVerified: true})
+
+	resp, err := env.linkIdentity(t, p, alice)
+	if err != nil {
+		t.Fatalf("关联第三方账号: %v", err)
+	}
+	if !resp.Linked || resp.UserLoginResponse != nil {
+		t.Errorf("关联响应 = %+v，期望只返回关联结果", resp)
+	}
+	identities, err := env.biz.ListIdentities(alice.ctx)
+	if err != nil {
+		t.Fatalf("ListIdentities: %v", err)
+	}
+	if identities.TotalCount != 1 || identities.Identities[0].Provider != "mock" || identities.Identities[0].Email != "alice@example.org" {
+		t.Errorf("ListIdentities = %+v", identities)
+	}
+
+	// 关联后可以通过第三方账号登录
+	login, err := env.oauthLogin(t, p)
+	if err != nil {
+		t.Fatalf("关联后登录: %v", err)
+	}
+	if login.User.UserID != alice.User.UserID {
+		t.Errorf("关联后登录的用户 = %s，期望 %s", login.User.UserID, alice.User.UserID)
+	}
+
+	// 同一身份提供方只能关联一个账号，同一个账号也不能关联多个用户
+	if _, err := env.biz.LinkIdentity(alice.ctx, "mock"); !errors.Is(err, errno.ErrIdentityAlreadyLinked) {
+		t.Errorf("重复关联 err = %v，期望 %v", err, errno.ErrIdentityAlreadyLinked)
+	}
+	if _, err := env.linkIdentity(t, p, bob); !errors.Is(err, errno.ErrIdentityAlreadyLinked) {
+		t.Errorf("关联已被其他用户关联的账号 err = %v，期望 %v", err, errno.ErrIdentityAlreadyLinked)
+	}
+
+	if err := env.biz.UnlinkIdentity(alice.ctx, "mock"); err != nil {
+		t.Fatalf("UnlinkIdentity: %v", err)
+	}
+	if _, err := env.oauthLogin(t, p); !errors.Is(err, errno.ErrIdentityNotLinked) {
+		t.Errorf("解除关联后登录 err = %v，期望 %v", err, errno.ErrIdentityNotLinked)
+	}
+	if err := env.biz.UnlinkIdentity(alice.ctx, "mock"); !errors.Is(err, errno.ErrIdentityNotLinked) {
+		t.Errorf("重复解除关联 err = %v，期望 %v", err, errno.ErrIdentityNotLinked)
+	}
+
+	// 解除关联后可以关联到其他用户
+	if _, err := env.linkIdentity(t, p, bob); err != nil {
+		t.Fatalf("关联到其他用户: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package biz
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/notify"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultResetExpire 密码重置令牌默认有效期
+	defaultResetExpire = 30 * time.Minute
+	// resetTokenLength 密码重置令牌的随机字节数
+	resetTokenLength = 32
+	// resetRequestTimeout 后台处理重置申请的超时时间
+	resetRequestTimeout = 30 * time.Second
+)
+
+// RequestPasswordReset implements UserBiz.
+// 查询用户、创建令牌和发送通知全部在后台执行，无论用户是否存在、处理是否成功，
+// 调用方都只会立即得到相同的成功响应，避免通过响应内容或耗时判断用户是否存在
+func (u *userBiz) RequestPasswordReset(ctx context.Context, req *model.PasswordResetRequest) error {
+	go func(ctx context.Context, username string) {
+		ctx, cancel := context.WithTimeout(ctx, resetRequestTimeout)
+		defer cancel()
+		if err := u.requestPasswordReset(ctx, username); err != nil {
+			u.logger.Error("处理密码重置申请失败", zap.String("username", username), zap.Error(err))
+		}
+	}(context.WithoutCancel(ctx), req.Username)
+	return nil
+}
+
+// requestPasswordReset 为用户创建密码重置令牌并发送通知，用户不存在时不做任何处理
+func (u *userBiz) requestPasswordReset(ctx context.Context, username string) error {
+	user, err := u.store.User().GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, errno.ErrUserNotFound) {
+			u.logger.Info("申请重置密码的用户不存在", zap.String("username", username))
+			return nil
+		}
+		return err
+	}
+
+	b := make([]byte, resetTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("生成重置令牌失败: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	// 同一用户只保留最近一次申请的令牌
+	reset := &model.PasswordReset{
+		UserID:    user.UserID,
+		TokenHash: hashResetToken(token),
+		ExpiresAt: time.Now().Add(u.resetExpire),
+	}
+	err = u.store.TX(ctx, func(ctx context.Context) error {
+		if err := u.store.PasswordReset().DeleteByUserID(ctx, user.UserID); err != nil {
+			return err
+		}
+		return u.store.PasswordReset().Create(ctx, reset)
+	})
+	if err != nil {
+		return fmt.Errorf("创建密码重置令牌失败: %w", err)
+	}
+
+	u.notify(ctx, user, &notify.Message{
+		To:      user.Email,
+		Subject: "重置密码",
+		Body: fmt.Sprintf("您正在重置账号 %s 的密码，以下重置凭证 %d 分钟内有效且只能使用一次：\n%s\n如果不是您本人操作，请忽略该通知。",
+			user.Username, int(u.resetExpire.Minutes()), tokenLink(u.resetURL, token)),
+	})
+
+	u.logger.Info("申请重置密码成功", zap.String("userID", user.UserID))
+	return nil
+}
+
+// ResetPassword implements UserBiz.
+// 先校验并使用重置令牌再计算新密码的哈希，无效的令牌不会触发高成本的哈希计算
+func (u *userBiz) ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error {
+	var user *model.User
+	err := u.store.TX(ctx, func(ctx context.Context) error {
+		reset, err := u.store.PasswordReset().GetByTokenHash(ctx, hashResetToken(req.Token))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !now.Before(reset.ExpiresAt) {
+			return errno.ErrResetTokenInvalid
+		}
+		// 条件更新保证并发请求中只有一个能够使用该令牌
+		if err := u.store.PasswordReset().MarkUsed(ctx, reset.ID, now); err != nil {
+			return err
+		}
+
+		user, err = u.store.User().GetByUserID(ctx, reset.UserID)
+		if err != nil {
+			if errors.Is(err, errno.ErrUserNotFound) {
+				return errno.ErrResetTokenInvalid
+			}
+			return err
+		}
+		hash, err := u.hasher.Hash(req.NewPassword)
+		if err != nil {
+			return err
+		}
+		user.Password = hash
+		return u.store.User().Update(ctx, user)
+	})
+	if err != nil {
+		u.logger.Warn("重置密码失败", zap.Error(err))
+		return err
+	}
+
+	// 密码重置后注销用户已登录的全部会话
+	if err := u.tokens.RevokeUser(ctx, user.UserID); err != nil {
+		u.logger.Error("重置密码后注销会话失败", zap.String("userID", user.UserID), zap.Error(err))
+		return err
+	}
+
+	u.logger.Info("重置密码成功", zap.String("userID", user.UserID))
+	return nil
+}
+
+// hashResetToken 计算密码重置令牌的哈希，令牌为高熵随机数，使用 SHA-256 即可
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package biz
+
+import (
+	"context"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"go.uber.org/zap"
+)
+
+// ListSessions implements UserBiz.
+func (u *userBiz) ListSessions(ctx context.Context) (*model.ListSessionResponse, error) {
+	principal, err := interactivePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.tokens.Sessions(ctx, principal.UserID)
+	if err != nil {
+		u.logger.Error("查询会话列表失败", zap.String("userID", principal.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	list := make([]model.SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, model.SessionInfo{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    s.ID == principal.Family,
+		})
+	}
+	return &model.ListSessionResponse{TotalCount: int64(len(list)), Sessions: list}, nil
+}
+
+// RevokeSession implements UserBiz.
+func (u *userBiz) RevokeSession(ctx context.Context, id string) error {
+	principal, err := interactivePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := u.tokens.RevokeSession(ctx, principal.UserID, id); err != nil {
+		return err
+	}
+
+	u.logger.Info("注销会话成功", zap.String("userID", principal.UserID), zap.String("sessionID", id))
+	return nil
+}
+
+// RevokeAllSessions implements UserBiz.
+func (u *userBiz) RevokeAllSessions(ctx context.Context) error {
+	principal, err := interactivePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := u.tokens.RevokeUser(ctx, principal.UserID); err != nil {
+		u.logger.Error("注销全部会话失败", zap.String("userID", principal.UserID), zap.Error(err))
+		return err
+	}
+
+	u.logger.Info("注销全部会话成功", zap.String("userID", principal.UserID))
+	return nil
+}
+
+// interactivePrincipal 返回当前调用方身份，API 密钥没有会话，不能管理会话和两步验证
+func interactivePrincipal(ctx context.Context) (*contextx.Principal, error) {
+	principal, ok := contextx.PrincipalFrom(ctx)
+	if !ok {
+		return nil, errno.ErrUnauthorized
+	}
+	if principal.IsAPIKey() {
+		return nil, errno.ErrForbidden.WithMessage("API密钥不支持该操作，请使用账号登录")
+	}
+	return principal, nil
+}
@@ -0,0 +1,144 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// testSession 一次登录产生的会话
+type testSession struct {
+	*model.UserLoginResponse
+	// ctx 携带该会话访问令牌对应的调用方身份
+	ctx context.Context
+	// family 会话 ID，即令牌家族 ID
+	family string
+}
+
+// loginFrom 使用 userAgent 设备登录，并返回携带该会话身份的 context
+func (env *testEnv) loginFrom(t *testing.T, username, userAgent string) *testSession {
+	t.Helper()
+	ctx := contextx.WithUserAgent(context.Background(), userAgent)
+	resp, err := env.biz.Login(ctx, &model.UserLoginRequest{Username: username, Password: testPassword})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	claims, err := env.tokens.Verify(ctx, resp.Token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	principal := &contextx.Principal{
+		UserID:   claims.Subject,
+		Username: claims.Username,
+		Role:     claims.Role,
+		TokenID:  claims.ID,
+		Family:   claims.Family,
+	}
+	return &testSession{UserLoginResponse: resp, ctx: contextx.WithPrincipal(ctx, principal), family: claims.Family}
+}
+
+// assertRevoked 检查会话的访问令牌和刷新令牌是否均已失效
+func (env *testEnv) assertRevoked(t *testing.T, s *testSession, want bool) {
+	t.Helper()
+	_, err := env.tokens.Verify(context.Background(), s.Token)
+	if got := errors.Is(err, errno.ErrTokenRevoked); got != want {
+		t.Errorf("会话 %s 访问令牌 Verify err = %v，期望已注销 %v", s.family, err, want)
+	}
+	_, err = env.biz.RefreshToken(context.Background(), &model.RefreshTokenRequest{RefreshToken: s.RefreshToken})
+	if got := err != nil; got != want {
+		t.Errorf("会话 %s 刷新令牌 RefreshToken err = %v，期望已注销 %v", s.family, err, want)
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	env := newTestBiz(t)
+	env.register(t, "alice")
+	laptop := env.loginFrom(t, "alice", "laptop")
+	phone := env.loginFrom(t, "alice", "phone")
+
+	resp, err := env.biz.ListSessions(phone.ctx)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if resp.TotalCount != 2 {
+		t.Fatalf("会话数 = %d，期望 2", resp.TotalCount)
+	}
+	// 最近活跃的会话排在前面，当前会话被标记
+	if got := resp.Sessions[0]; got.ID != phone.family || got.UserAgent != "phone" || !got.Current {
+		t.Errorf("Sessions[0] = %+v，期望当前会话 %s", got, phone.family)
+	}
+	if got := resp.Sessions[1]; got.ID != laptop.family || got.UserAgent != "laptop" || got.Current {
+		t.Errorf("Sessions[1] = %+v，期望会话 %s", got, laptop.family)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	env := newTestBiz(t)
+	env.register(t, "alice")
+	env.register(t, "bob")
+	laptop := env.loginFrom(t, "alice", "laptop")
+	phone := env.loginFrom(t, "alice", "phone")
+	bob := env.loginFrom(t, "bob", "laptop")
+
+	if err := env.biz.RevokeSession(phone.ctx, laptop.family); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	env.assertRevoked(t, laptop, true)
+	env.assertRevoked(t, phone, false)
+
+	resp, err := env.biz.ListSessions(phone.ctx)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if resp.TotalCount != 1 || resp.Sessions[0].ID != phone.family {
+		t.Errorf("注销后的会话列表 = %+v，期望只剩 %s", resp.Sessions, phone.family)
+	}
+
+	// 不能注销其他用户的会话，也不能重复注销
+	for name, id := range map[string]string{"其他用户的会话": bob.family, "已注销的会话": laptop.family, "不存在的会话": "missing"} {
+		if err := env.biz.RevokeSession(phone.ctx, id); !errors.Is(err, errno.ErrSessionNotFound) {
+			t.Errorf("注销%s err = %v，期望 %v", name, err, errno.ErrSessionNotFound)
+		}
+	}
+	env.assertRevoked(t, bob, false)
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	env := newTestBiz(t)
+	env.register(t, "alice")
+	env.register(t, "bob")
+	laptop := env.loginFrom(t, "alice", "laptop")
+	phone := env.loginFrom(t, "alice", "phone")
+	bob := env.loginFrom(t, "bob", "laptop")
+
+	if err := env.biz.RevokeAllSessions(phone.ctx); err != nil {
+		t.Fatalf("RevokeAllSessions: %v", err)
+	}
+	env.assertRevoked(t, laptop, true)
+	env.assertRevoked(t, phone, true)
+	env.assertRevoked(t, bob, false)
+
+	sessions, err := env.tokens.Sessions(context.Background(), laptop.User.UserID)
+	if err != nil {
+		t.Fatalf("Sessions: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("注销全部会话后仍有 %d 个会话", len(sessions))
+	}
+}
+
+func TestSessionsRejectAPIKey(t *testing.T) {
+	env := newTestBiz(t)
+	ctx := contextx.WithPrincipal(context.Background(), &contextx.Principal{UserID: "user-1", APIKeyID: "key"})
+
+	if _, err := env.biz.ListSessions(ctx); !errors.Is(err, errno.ErrForbidden) {
+		t.Errorf("ListSessions err = %v，期望 %v", err, errno.ErrForbidden)
+	}
+	if err := env.biz.RevokeAllSessions(ctx); !errors.Is(err, errno.ErrForbidden) {
+		t.Errorf("RevokeAllSessions err = %v，期望 %v", err, errno.ErrForbidden)
+	}
+}
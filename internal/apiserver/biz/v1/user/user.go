@@ -2,30 +2,158 @@ package biz
 
 import (
 	"context"
+	"errors"
+	"net/url"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/lichenglife/easyblog/internal/apiserver/model"
 	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/auth"
+	"github.com/lichenglife/easyblog/internal/pkg/authz"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/lockout"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/lichenglife/easyblog/internal/pkg/mfa"
+	"github.com/lichenglife/easyblog/internal/pkg/notify"
+	"github.com/lichenglife/easyblog/internal/pkg/oauth"
+	"github.com/lichenglife/easyblog/internal/pkg/token"
+	"go.uber.org/zap"
+)
+
+// 分页参数默认值
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
 )
 
 // UserBiz 用户业务接口
 type UserBiz interface {
-	// Create 创建用户
-	CreteUser(ctx context.Context, req *model.CreatePostRequest) (*model.UserInfo, error)
+	// CreateUser 注册用户
+	CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.UserInfo, error)
+	// Login 用户登录，启用两步验证的用户只返回挑战令牌
+	Login(ctx context.Context, req *model.UserLoginRequest) (*model.UserLoginResponse, error)
+	// LoginMFA 使用挑战令牌和验证码完成两步验证登录
+	LoginMFA(ctx context.Context, req *model.MFALoginRequest) (*model.UserLoginResponse, error)
+	// EnrollMFA 为当前用户生成 TOTP 密钥，验证通过后才启用两步验证
+	EnrollMFA(ctx context.Context) (*model.EnrollMFAResponse, error)
+	// EnableMFA 校验验证码并启用两步验证，返回一次性恢复码
+	EnableMFA(ctx context.Context, req *model.MFACodeRequest) (*model.EnableMFAResponse, error)
+	// DisableMFA 校验验证码或恢复码并关闭两步验证
+	DisableMFA(ctx context.Context, req *model.MFACodeRequest) error
+	// OAuthLogin 开始第三方登录，返回身份提供方的授权地址
+	OAuthLogin(ctx context.Context, provider string) (*model.OAuthAuthorizeResponse, error)
+	// OAuthCallback 完成第三方登录或账号关联，未关联的账号按配置关联已有用户或自动创建用户
+	OAuthCallback(ctx context.Context, provider string, req *model.OAuthCallbackRequest) (*model.OAuthCallbackResponse, error)
+	// LinkIdentity 为当前用户开始关联第三方账号，返回身份提供方的授权地址
+	LinkIdentity(ctx context.Context, provider string) (*model.OAuthAuthorizeResponse, error)
+	// ListIdentities 获取当前用户关联的第三方账号
+	ListIdentities(ctx context.Context) (*model.ListUserIdentityResponse, error)
+	// UnlinkIdentity 解除当前用户与第三方账号的关联
+	UnlinkIdentity(ctx context.Context, provider string) error
+	// RefreshToken 使用刷新令牌换取新的令牌对
+	RefreshToken(ctx context.Context, req *model.RefreshTokenRequest) (*model.RefreshTokenResponse, error)
+	// Logout 注销当前调用方使用的令牌
+	Logout(ctx context.Context) error
+	// ListSessions 获取当前用户已登录的会话列表
+	ListSessions(ctx context.Context) (*model.ListSessionResponse, error)
+	// RevokeSession 注销当前用户的指定会话
+	RevokeSession(ctx context.Context, id string) error
+	// RevokeAllSessions 注销当前用户的全部会话，包括当前会话
+	RevokeAllSessions(ctx context.Context) error
+	// ChangePassword 修改密码
+	ChangePassword(ctx context.Context, id uint, req *model.ChangePasswordRequest) error
+	// RequestPasswordReset 申请重置密码，向用户发送一次性的重置凭证
+	RequestPasswordReset(ctx context.Context, req *model.PasswordResetRequest) error
+	// ResetPassword 使用重置凭证设置新密码，并注销用户已登录的全部会话
+	ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error
+	// VerifyEmail 使用验证链接中的令牌验证用户邮箱
+	VerifyEmail(ctx context.Context, token string) error
+	// ResendVerification 重新向当前用户发送邮箱验证链接
+	ResendVerification(ctx context.Context) error
 	// GetByID 根据 ID 获取用户
 	GetUserByID(ctx context.Context, id uint) (*model.UserInfo, error)
 	// GetByUsername 根据用户名获取用户
 	GetUserByUsername(ctx context.Context, username string) (*model.UserInfo, error)
 	// Update 更新用户
-	UpdateUser(ctx context.Context, user *model.UpdateUser) error
-	// Delete 删除用户
+	UpdateUser(ctx context.Context, id uint, user *model.UpdateUser) (*model.UserInfo, error)
+	// UpdateRole 修改用户角色
+	UpdateRole(ctx context.Context, id uint, req *model.UpdateRoleRequest) (*model.UserInfo, error)
+	// Delete 删除用户及其全部帖子、API 密钥和关联的第三方账号
 	DeleteUser(ctx context.Context, id uint) error
 	// List 获取用户列表
 	ListUsers(ctx context.Context, page, pageSize int) (*model.ListUserResponse, error)
 }
 
-func NewUserBiz(store store.UserStore) UserBiz {
+// Options 用户业务依赖的组件
+type Options struct {
+	// Hasher 密码哈希组件
+	Hasher auth.PasswordHasher
+	// Tokens 令牌签发组件
+	Tokens *token.Manager
+	// DefaultRole 新注册用户的角色，为空时使用作者角色
+	DefaultRole string
+	// Notifier 通知组件，为空时将通知内容写入日志
+	Notifier notify.Notifier
+	// ResetExpire 密码重置凭证有效期，为空时使用 30 分钟
+	ResetExpire time.Duration
+	// ResetURL 重置密码页面地址，重置凭证以 token 参数附加在地址后
+	ResetURL string
+	// VerifyExpire 邮箱验证链接有效期，为空时使用 24 小时
+	VerifyExpire time.Duration
+	// VerifyURL 邮箱验证地址，验证令牌以 token 参数附加在地址后
+	VerifyURL string
+	// MFA 两步验证组件
+	MFA *mfa.Manager
+	// Enforcer 访问控制组件，用于判断角色是否必须启用两步验证
+	Enforcer *authz.Enforcer
+	// ChallengeExpire 两步验证挑战令牌有效期，为空时使用 5 分钟
+	ChallengeExpire time.Duration
+	// Lockout 登录防护组件，为空时不限制登录失败次数
+	Lockout *lockout.Guard
+	// OAuth 第三方登录组件，为空时第三方登录不可用
+	OAuth *oauth.Manager
+}
+
+func NewUserBiz(logger *log.Logger, store store.IStore, opts *Options) UserBiz {
+	defaultRole := opts.DefaultRole
+	if defaultRole == "" {
+		defaultRole = authz.RoleAuthor
+	}
+	notifier := opts.Notifier
+	if notifier == nil {
+		notifier = notify.NewLogNotifier(logger)
+	}
+	resetExpire := opts.ResetExpire
+	if resetExpire <= 0 {
+		resetExpire = defaultResetExpire
+	}
+	verifyExpire := opts.VerifyExpire
+	if verifyExpire <= 0 {
+		verifyExpire = defaultVerifyExpire
+	}
+	challengeExpire := opts.ChallengeExpire
+	if challengeExpire <= 0 {
+		challengeExpire = defaultChallengeExpire
+	}
+
 	return &userBiz{
-		store: store,
+		logger:          logger,
+		store:           store,
+		hasher:          opts.Hasher,
+		tokens:          opts.Tokens,
+		defaultRole:     defaultRole,
+		notifier:        notifier,
+		resetExpire:     resetExpire,
+		resetURL:        opts.ResetURL,
+		verifyExpire:    verifyExpire,
+		verifyURL:       opts.VerifyURL,
+		mfa:             opts.MFA,
+		enforcer:        opts.Enforcer,
+		challengeExpire: challengeExpire,
+		lockout:         opts.Lockout,
+		oauth:           opts.OAuth,
 	}
 }
 
@@ -33,35 +161,456 @@ var _ UserBiz = (*userBiz)(nil)
 
 // userBiz 定义了用户业务逻辑层
 type userBiz struct {
-	store store.UserStore
+	logger *log.Logger
+	store  store.IStore
+	hasher auth.PasswordHasher
+	tokens *token.Manager
+	// defaultRole 新注册用户的角色
+	defaultRole string
+	// notifier 通知组件
+	notifier notify.Notifier
+	// resetExpire 密码重置凭证有效期
+	resetExpire time.Duration
+	// resetURL 重置密码页面地址
+	resetURL string
+	// verifyExpire 邮箱验证链接有效期
+	verifyExpire time.Duration
+	// verifyURL 邮箱验证地址
+	verifyURL string
+	// mfa 两步验证组件
+	mfa      *mfa.Manager
+	enforcer *authz.Enforcer
+	// challengeExpire 两步验证挑战令牌有效期
+	challengeExpire time.Duration
+	// lockout 登录防护组件
+	lockout *lockout.Guard
+	// oauth 第三方登录组件
+	oauth *oauth.Manager
+}
+
+// CreateUser implements UserBiz.
+func (u *userBiz) CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.UserInfo, error) {
+	hash, err := u.hasher.Hash(req.Password)
+	if err != nil {
+		u.logger.Error("注册用户失败", zap.String("username", req.Username), zap.Error(err))
+		return nil, err
+	}
+
+	user := &model.User{
+		UserID:   uuid.New().String(),
+		Username: req.Username,
+		Password: hash,
+		NickName: req.Nickname,
+		Email:    req.Email,
+		Phone:    &req.Phone,
+		Role:     u.defaultRole,
+	}
+	if err := u.store.User().Create(ctx, user); err != nil {
+		u.logger.Warn("注册用户失败", zap.String("username", req.Username), zap.Error(err))
+		return nil, err
+	}
+
+	u.logger.Info("注册用户成功", zap.String("userID", user.UserID), zap.String("username", user.Username))
+	u.sendVerification(ctx, user)
+	return userInfo(user), nil
+}
+
+// Login implements UserBiz.
+func (u *userBiz) Login(ctx context.Context, req *model.UserLoginRequest) (*model.UserLoginResponse, error) {
+	if err := u.lockout.Check(ctx, req.Username, contextx.ClientIP(ctx)); err != nil {
+		u.logger.Warn("用户登录失败，失败次数过多", zap.String("username", req.Username), zap.String("ip", contextx.ClientIP(ctx)))
+		return nil, err
+	}
+
+	user, err := u.store.User().GetByUsername(ctx, req.Username)
+	if err != nil {
+		// 用户不存在时同样校验一次密码并返回密码错误，避免通过错误信息或响应耗时泄露用户是否存在
+		if errors.Is(err, errno.ErrUserNotFound) {
+			u.hasher.CompareDummy(req.Password)
+			u.loginFailed(ctx, req.Username)
+			return nil, errno.ErrPasswordIncorrect
+		}
+		return nil, err
+	}
+	if err := u.hasher.Compare(user.Password, req.Password); err != nil {
+		u.logger.Warn("用户登录失败，密码错误", zap.String("username", req.Username))
+		u.loginFailed(ctx, req.Username)
+		return nil, err
+	}
+
+	// 哈希算法或参数调整后，在登录成功时使用新参数重新计算哈希
+	if u.hasher.NeedsRehash(user.Password) {
+		u.rehash(ctx, user, req.Password)
+	}
+
+	return u.completeLogin(ctx, user)
+}
+
+// completeLogin 为通过身份校验的用户完成登录，已启用两步验证时只返回挑战令牌，提交验证码后才签发访问令牌
+func (u *userBiz) completeLogin(ctx context.Context, user *model.User) (*model.UserLoginResponse, error) {
+	record, err := u.store.UserMFA().Get(ctx, user.UserID)
+	if err != nil && !errors.Is(err, errno.ErrMFANotEnabled) {
+		return nil, err
+	}
+	if record != nil && record.Enabled {
+		challenge, expiresAt, err := u.tokens.SignMFAChallenge(user.UserID, u.challengeExpire)
+		if err != nil {
+			return nil, err
+		}
+		u.logger.Info("用户身份校验通过，等待两步验证", zap.String("userID", user.UserID))
+		return &model.UserLoginResponse{
+			User:               *userInfo(user),
+			MFARequired:        true,
+			ChallengeToken:     challenge,
+			ChallengeExpiresAt: &expiresAt,
+		}, nil
+	}
+
+	resp, err := u.issueLogin(ctx, user, identity(user))
+	if err != nil {
+		return nil, err
+	}
+	// 必须启用两步验证的角色在启用前只能访问两步验证相关接口
+	resp.MFAEnrollRequired = u.mfaRequired(user.Role)
+	return resp, nil
+}
+
+// loginFailed 记录一次登录失败，记录失败不影响返回给调用方的错误
+func (u *userBiz) loginFailed(ctx context.Context, username string) {
+	if err := u.lockout.Fail(ctx, username, contextx.ClientIP(ctx)); err != nil {
+		u.logger.Error("记录登录失败次数失败", zap.String("username", username), zap.Error(err))
+	}
 }
 
-// CreteUser implements UserBiz.
-func (u *userBiz) CreteUser(ctx context.Context, req *model.CreatePostRequest) (*model.UserInfo, error) {
-	panic("unimplemented")
+// issueLogin 为登录成功的用户签发令牌
+func (u *userBiz) issueLogin(ctx context.Context, user *model.User, id token.Identity) (*model.UserLoginResponse, error) {
+	pair, err := u.tokens.Issue(ctx, id)
+	if err != nil {
+		u.logger.Error("签发令牌失败", zap.String("userID", user.UserID), zap.Error(err))
+		return nil, err
+	}
+	// 完成全部验证后才清除失败记录，避免通过密码校验重置两步验证的失败次数
+	if err := u.lockout.Succeed(ctx, user.Username); err != nil {
+		u.logger.Error("清除登录失败记录失败", zap.String("userID", user.UserID), zap.Error(err))
+	}
+
+	u.logger.Info("用户登录成功", zap.String("userID", user.UserID), zap.Bool("mfa", id.MFA))
+	return &model.UserLoginResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+		User:             *userInfo(user),
+	}, nil
+}
+
+// RefreshToken implements UserBiz.
+func (u *userBiz) RefreshToken(ctx context.Context, req *model.RefreshTokenRequest) (*model.RefreshTokenResponse, error) {
+	claims, err := u.tokens.ParseRefresh(req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 用户已被删除时注销令牌家族，不再签发新令牌
+	user, err := u.store.User().GetByUserID(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, errno.ErrUserNotFound) {
+			if err := u.tokens.RevokeFamily(ctx, claims.Family); err != nil {
+				u.logger.Error("注销令牌家族失败", zap.String("family", claims.Family), zap.Error(err))
+			}
+			return nil, errno.ErrTokenRevoked
+		}
+		return nil, err
+	}
+
+	// 使用最新的用户信息签发令牌，角色变更在刷新后生效，两步验证状态保持不变
+	id := identity(user)
+	id.MFA = claims.MFA
+	pair, err := u.tokens.Rotate(ctx, claims, id)
+	if err != nil {
+		if errors.Is(err, errno.ErrTokenRevoked) {
+			u.logger.Warn("刷新令牌已失效或被重复使用，已注销令牌家族",
+				zap.String("userID", user.UserID), zap.String("family", claims.Family))
+		} else {
+			u.logger.Error("轮换刷新令牌失败", zap.String("userID", user.UserID), zap.Error(err))
+		}
+		return nil, err
+	}
+
+	return &model.RefreshTokenResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	}, nil
+}
+
+// Logout implements UserBiz.
+func (u *userBiz) Logout(ctx context.Context) error {
+	principal, ok := contextx.PrincipalFrom(ctx)
+	if !ok {
+		return errno.ErrUnauthorized
+	}
+	// API 密钥需要通过删除密钥的方式注销
+	if principal.IsAPIKey() {
+		return errno.ErrForbidden.WithMessage("API密钥不支持登出，请删除该密钥")
+	}
+
+	if err := u.tokens.Revoke(ctx, principal.TokenID, principal.Family, principal.ExpiresAt); err != nil {
+		u.logger.Error("用户登出失败", zap.String("userID", principal.UserID), zap.Error(err))
+		return err
+	}
+
+	u.logger.Info("用户登出成功", zap.String("userID", principal.UserID))
+	return nil
+}
+
+// ChangePassword implements UserBiz.
+func (u *userBiz) ChangePassword(ctx context.Context, id uint, req *model.ChangePasswordRequest) error {
+	user, err := u.store.User().GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkSelf(ctx, user); err != nil {
+		return err
+	}
+	if err := u.hasher.Compare(user.Password, req.OldPassword); err != nil {
+		return err
+	}
+
+	hash, err := u.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+	if err := u.store.User().Update(ctx, user); err != nil {
+		u.logger.Error("修改密码失败", zap.String("userID", user.UserID), zap.Error(err))
+		return err
+	}
+
+	u.logger.Info("修改密码成功", zap.String("userID", user.UserID))
+	return nil
 }
 
 // DeleteUser implements UserBiz.
 func (u *userBiz) DeleteUser(ctx context.Context, id uint) error {
-	panic("unimplemented")
+	err := u.store.TX(ctx, func(ctx context.Context) error {
+		user, err := u.store.User().GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := u.store.Post().DeleteByUserID(ctx, user.UserID); err != nil {
+			return err
+		}
+		if err := u.store.APIKey().DeleteByUserID(ctx, user.UserID); err != nil {
+			return err
+		}
+		if err := u.store.PasswordReset().DeleteByUserID(ctx, user.UserID); err != nil {
+			return err
+		}
+		if err := u.store.UserMFA().Delete(ctx, user.UserID); err != nil {
+			return err
+		}
+		if err := u.store.UserIdentity().DeleteByUserID(ctx, user.UserID); err != nil {
+			return err
+		}
+		return u.store.User().Delete(ctx, id)
+	})
+	if err != nil {
+		u.logger.Warn("删除用户失败", zap.Uint("id", id), zap.Error(err))
+		return err
+	}
+
+	u.logger.Info("删除用户成功", zap.Uint("id", id))
+	return nil
 }
 
 // GetUserByID implements UserBiz.
 func (u *userBiz) GetUserByID(ctx context.Context, id uint) (*model.UserInfo, error) {
-	panic("unimplemented")
+	user, err := u.store.User().GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return userInfo(user), nil
 }
 
 // GetUserByUsername implements UserBiz.
 func (u *userBiz) GetUserByUsername(ctx context.Context, username string) (*model.UserInfo, error) {
-	panic("unimplemented")
+	user, err := u.store.User().GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return userInfo(user), nil
 }
 
 // ListUsers implements UserBiz.
 func (u *userBiz) ListUsers(ctx context.Context, page int, pageSize int) (*model.ListUserResponse, error) {
-	panic("unimplemented")
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	users, total, err := u.store.User().List(ctx, page, pageSize)
+	if err != nil {
+		u.logger.Error("查询用户列表失败", zap.Error(err))
+		return nil, err
+	}
+
+	list := make([]model.UserInfo, 0, len(users))
+	for _, user := range users {
+		list = append(list, *userInfo(user))
+	}
+
+	return &model.ListUserResponse{
+		TotalCount: total,
+		HasMore:    total > int64(page*pageSize),
+		User:       list,
+	}, nil
 }
 
 // UpdateUser implements UserBiz.
-func (u *userBiz) UpdateUser(ctx context.Context, user *model.UpdateUser) error {
-	panic("unimplemented")
+func (u *userBiz) UpdateUser(ctx context.Context, id uint, req *model.UpdateUser) (*model.UserInfo, error) {
+	user, err := u.store.User().GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSelf(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// 只更新请求中携带的字段
+	if req.Nickname != "" {
+		user.NickName = req.Nickname
+	}
+	// 修改邮箱后需要重新验证
+	emailChanged := req.Email != "" && req.Email != user.Email
+	if emailChanged {
+		user.Email = req.Email
+		user.EmailVerified = false
+	}
+	if req.Phone != "" {
+		user.Phone = &req.Phone
+	}
+	if err := u.store.User().Update(ctx, user); err != nil {
+		u.logger.Warn("更新用户失败", zap.String("userID", user.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	u.logger.Info("更新用户成功", zap.String("userID", user.UserID))
+	if emailChanged {
+		u.sendVerification(ctx, user)
+	}
+	return userInfo(user), nil
+}
+
+// UpdateRole implements UserBiz.
+func (u *userBiz) UpdateRole(ctx context.Context, id uint, req *model.UpdateRoleRequest) (*model.UserInfo, error) {
+	if !authz.IsRole(req.Role) {
+		return nil, errno.ErrInvalidParams.WithMessage("无效的角色")
+	}
+
+	user, err := u.store.User().GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// 禁止修改自己的角色，避免管理员误操作后失去管理权限
+	if user.UserID == contextx.UserID(ctx) {
+		return nil, errno.ErrForbidden.WithMessage("不能修改自己的角色")
+	}
+
+	oldRole := user.Role
+	user.Role = req.Role
+	if err := u.store.User().Update(ctx, user); err != nil {
+		u.logger.Error("修改用户角色失败", zap.String("userID", user.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	u.logger.Info("修改用户角色成功",
+		zap.String("userID", user.UserID),
+		zap.String("oldRole", oldRole),
+		zap.String("role", user.Role),
+		zap.String("operator", contextx.UserID(ctx)),
+	)
+	return userInfo(user), nil
+}
+
+// rehash 使用当前配置重新计算密码哈希，失败时只记录日志，不影响登录
+func (u *userBiz) rehash(ctx context.Context, user *model.User, password string) {
+	hash, err := u.hasher.Hash(password)
+	if err == nil {
+		user.Password = hash
+		err = u.store.User().Update(ctx, user)
+	}
+	if err != nil {
+		u.logger.Warn("更新密码哈希失败", zap.String("userID", user.UserID), zap.Error(err))
+		return
+	}
+
+	u.logger.Info("更新密码哈希成功", zap.String("userID", user.UserID))
+}
+
+// notify 异步向用户发送通知，发送失败时只记录日志
+func (u *userBiz) notify(ctx context.Context, user *model.User, msg *notify.Message) {
+	go func(ctx context.Context) {
+		if err := u.notifier.Send(ctx, msg); err != nil {
+			u.logger.Error("发送通知失败", zap.String("userID", user.UserID), zap.String("subject", msg.Subject), zap.Error(err))
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+// tokenLink 将令牌以 token 参数附加在地址后，未配置地址时直接返回令牌
+func tokenLink(base, token string) string {
+	if base == "" {
+		return token
+	}
+	link, err := url.Parse(base)
+	if err != nil {
+		return token
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// userInfo 将用户模型转换为用户响应结构
+func userInfo(user *model.User) *model.UserInfo {
+	info := &model.UserInfo{
+		UserID:        user.UserID,
+		Username:      user.Username,
+		Nickname:      user.NickName,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+	}
+	// 通过第三方账号自动创建的用户没有手机号
+	if user.Phone != nil {
+		info.Phone = *user.Phone
+	}
+	return info
+}
+
+// identity 返回用于签发令牌的用户身份
+func identity(user *model.User) token.Identity {
+	return token.Identity{UserID: user.UserID, Username: user.Username, Role: user.Role}
+}
+
+// checkSelf 校验调用方是否为用户本人或管理员
+func checkSelf(ctx context.Context, user *model.User) error {
+	principal, ok := contextx.PrincipalFrom(ctx)
+	if !ok {
+		return errno.ErrUnauthorized
+	}
+	if principal.UserID != user.UserID && principal.Role != authz.RoleAdmin {
+		return errno.ErrForbidden
+	}
+	return nil
 }
@@ -0,0 +1,239 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/apiserver/store/memory"
+	"github.com/lichenglife/easyblog/internal/pkg/auth"
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/lichenglife/easyblog/internal/pkg/notify"
+	"github.com/lichenglife/easyblog/internal/pkg/token"
+)
+
+// testPassword 测试用户的初始密码
+const testPassword = "Passw0rd!"
+
+// fakeNotifier 将发送的通知写入 channel，通知在后台发送，需要等待接收
+type fakeNotifier chan *notify.Message
+
+func (f fakeNotifier) Send(_ context.Context, msg *notify.Message) error {
+	f <- msg
+	return nil
+}
+
+// receive 等待接收一条标题为 subject 的通知，并返回通知中链接携带的令牌
+func (f fakeNotifier) receive(t *testing.T, subject string) string {
+	t.Helper()
+	for {
+		select {
+		case msg := <-f:
+			if msg.Subject != subject {
+				continue
+			}
+			link, err := url.Parse(linkPattern.FindString(msg.Body))
+			if err != nil || link.Query().Get("token") == "" {
+				t.Fatalf("通知中没有链接: %q", msg.Body)
+			}
+			return link.Query().Get("token")
+		case <-time.After(time.Second):
+			t.Fatalf("没有收到通知: %s", subject)
+			return ""
+		}
+	}
+}
+
+// testEnv 用户业务层及其依赖的组件
+type testEnv struct {
+	biz      UserBiz
+	store    store.IStore
+	tokens   *token.Manager
+	notifier fakeNotifier
+	// users 已注册的用户数，用于生成不重复的手机号
+	users int
+}
+
+// newTestBiz 创建使用内存存储、内存缓存和低计算成本密码哈希的用户业务层，
+// opts 用于修改默认的业务配置
+func newTestBiz(t *testing.T, opts ...func(*Options)) *testEnv {
+	t.Helper()
+
+	hasher, err := auth.NewPasswordHasherWithOptions(&auth.PasswordOptions{
+		Algorithm: auth.AlgorithmArgon2id,
+		Argon2:    auth.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLength: 32, SaltLength: 16},
+	})
+	if err != nil {
+		t.Fatalf("NewPasswordHasherWithOptions: %v", err)
+	}
+	config := viper.New()
+	config.Set("jwt.secret", "easyblog-test-secret-0123456789abcdef")
+	tokens, err := token.NewManager(config, cache.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	env := &testEnv{store: memory.NewStore(), tokens: tokens, notifier: make(fakeNotifier, 8)}
+	o := &Options{
+		Hasher:    hasher,
+		Tokens:    tokens,
+		Notifier:  env.notifier,
+		ResetURL:  "https://blog.example.com/reset",
+		VerifyURL: "https://blog.example.com/verify",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	env.biz = NewUserBiz(&log.Logger{Logger: zap.NewNop()}, env.store, o)
+	return env
+}
+
+// register 注册用户名为 username、密码为 testPassword 的用户
+func (env *testEnv) register(t *testing.T, username string) *model.UserInfo {
+	t.Helper()
+	env.users++
+	user, err := env.biz.CreateUser(context.Background(), &model.CreateUserRequest{
+		Username: username,
+		Password: testPassword,
+		Nickname: username,
+		Email:    username + "@example.com",
+		Phone:    fmt.Sprintf("1380000%04d", env.users),
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+// login 使用用户名和密码登录
+func (env *testEnv) login(username, password string) (*model.UserLoginResponse, error) {
+	return env.biz.Login(context.Background(), &model.UserLoginRequest{Username: username, Password: password})
+}
+
+// linkPattern 匹配通知正文中的链接
+var linkPattern = regexp.MustCompile(`https://blog\.example\.com/\S+`)
+
+// requestReset 申请重置密码并返回通知中的重置凭证
+func (env *testEnv) requestReset(t *testing.T, username string) string {
+	t.Helper()
+	if err := env.biz.RequestPasswordReset(context.Background(), &model.PasswordResetRequest{Username: username}); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	return env.notifier.receive(t, "重置密码")
+}
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	env := newTestBiz(t)
+	env.register(t, "alice")
+	session, err := env.login("alice", testPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	resetToken := env.requestReset(t, "alice")
+	if err := env.biz.ResetPassword(ctx, &model.ResetPasswordRequest{Token: resetToken, NewPassword: "N3wPassw0rd!"}); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+
+	if _, err := env.login("alice", testPassword); !errors.Is(err, errno.ErrPasswordIncorrect) {
+		t.Errorf("使用旧密码登录 err = %v，期望 %v", err, errno.ErrPasswordIncorrect)
+	}
+	if _, err := env.login("alice", "N3wPassw0rd!"); err != nil {
+		t.Errorf("使用新密码登录: %v", err)
+	}
+	// 重置密码后注销已登录的会话
+	if _, err := env.tokens.Verify(ctx, session.Token); !errors.Is(err, errno.ErrTokenRevoked) {
+		t.Errorf("重置密码前签发的令牌 Verify err = %v，期望 %v", err, errno.ErrTokenRevoked)
+	}
+	// 重置凭证只能使用一次
+	if err := env.biz.ResetPassword(ctx, &model.ResetPasswordRequest{Token: resetToken, NewPassword: "Ag4inPassw0rd!"}); !errors.Is(err, errno.ErrResetTokenInvalid) {
+		t.Errorf("重复使用重置凭证 err = %v，期望 %v", err, errno.ErrResetTokenInvalid)
+	}
+}
+
+// countingHasher 记录计算密码哈希的次数
+type countingHasher struct {
+	auth.PasswordHasher
+	hashes int
+}
+
+func (h *countingHasher) Hash(password string) (string, error) {
+	h.hashes++
+	return h.PasswordHasher.Hash(password)
+}
+
+func TestPasswordResetInvalidToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []func(*Options)
+		token func(t *testing.T, env *testEnv) string
+	}{
+		{
+			name:  "不存在的重置凭证",
+			token: func(*testing.T, *testEnv) string { return "missing" },
+		},
+		{
+			name: "已过期的重置凭证",
+			opts: []func(*Options){func(o *Options) { o.ResetExpire = time.Nanosecond }},
+			token: func(t *testing.T, env *testEnv) string {
+				return env.requestReset(t, "alice")
+			},
+		},
+		{
+			name: "再次申请后之前的重置凭证失效",
+			token: func(t *testing.T, env *testEnv) string {
+				first := env.requestReset(t, "alice")
+				env.requestReset(t, "alice")
+				return first
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := &countingHasher{}
+			env := newTestBiz(t, append(tt.opts, func(o *Options) {
+				hasher.PasswordHasher, o.Hasher = o.Hasher, hasher
+			})...)
+			env.register(t, "alice")
+
+			req := &model.ResetPasswordRequest{Token: tt.token(t, env), NewPassword: "N3wPassw0rd!"}
+			hasher.hashes = 0
+			if err := env.biz.ResetPassword(context.Background(), req); !errors.Is(err, errno.ErrResetTokenInvalid) {
+				t.Fatalf("ResetPassword err = %v，期望 %v", err, errno.ErrResetTokenInvalid)
+			}
+			// 重置凭证无效时不计算新密码的哈希
+			if hasher.hashes != 0 {
+				t.Errorf("重置凭证无效时计算了 %d 次密码哈希", hasher.hashes)
+			}
+			// 密码保持不变
+			if _, err := env.login("alice", testPassword); err != nil {
+				t.Errorf("使用原密码登录: %v", err)
+			}
+		})
+	}
+}
+
+func TestRequestPasswordResetUnknownUser(t *testing.T) {
+	env := newTestBiz(t)
+	// 用户不存在时同样返回成功，且不发送通知
+	if err := env.biz.RequestPasswordReset(context.Background(), &model.PasswordResetRequest{Username: "nobody"}); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	select {
+	case msg := <-env.notifier:
+		t.Errorf("不应发送通知，收到 %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
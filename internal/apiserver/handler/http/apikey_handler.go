@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/lichenglife/easyblog/internal/apiserver/biz"
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/core"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+)
+
+// APIKeyHandler 定义了 API 密钥相关接口
+type APIKeyHandler interface {
+	// CreateAPIKey 创建 API 密钥
+	CreateAPIKey(c *gin.Context)
+	// ListAPIKeys 获取当前用户的 API 密钥列表
+	ListAPIKeys(c *gin.Context)
+	// DeleteAPIKey 删除 API 密钥
+	DeleteAPIKey(c *gin.Context)
+}
+
+// apiKeyHandler 实现了 APIKeyHandler 接口
+type apiKeyHandler struct {
+	logger *log.Logger
+	biz    biz.IBiz
+}
+
+// NewAPIKeyHandler 创建 APIKeyHandler 实例
+func NewAPIKeyHandler(logger *log.Logger, biz biz.IBiz) APIKeyHandler {
+	return &apiKeyHandler{
+		logger: logger,
+		biz:    biz,
+	}
+}
+
+var _ APIKeyHandler = (*apiKeyHandler)(nil)
+
+// CreateAPIKey implements APIKeyHandler.
+func (a *apiKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req model.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	resp, err := a.biz.APIKeyV1().CreateAPIKey(c.Request.Context(), &req)
+	core.WriteResponse(c, err, resp)
+}
+
+// ListAPIKeys implements APIKeyHandler.
+func (a *apiKeyHandler) ListAPIKeys(c *gin.Context) {
+	resp, err := a.biz.APIKeyV1().ListAPIKeys(c.Request.Context())
+	core.WriteResponse(c, err, resp)
+}
+
+// DeleteAPIKey implements APIKeyHandler.
+func (a *apiKeyHandler) DeleteAPIKey(c *gin.Context) {
+	id, err := getIDParam(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	err = a.biz.APIKeyV1().DeleteAPIKey(c.Request.Context(), id)
+	core.WriteResponse(c, err, nil)
+}
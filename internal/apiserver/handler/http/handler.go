@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
 	"github.com/lichenglife/easyblog/internal/apiserver/biz"
-	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
 	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/lichenglife/easyblog/internal/pkg/validation"
 )
 
 // 定义Handler接口
@@ -14,26 +19,29 @@ type Handler interface {
 
 	// Post 帖子相关接口
 	Posts() PostHandler
+
+	// APIKeys API 密钥相关接口
+	APIKeys() APIKeyHandler
 }
 
 // handler 定义了Handler接口的实现
 type handler struct {
 	logger      *log.Logger
-	store       store.IStore
 	UserHandler UserHandler
 	PostHandler PostHandler
+
+	APIKeyHandler APIKeyHandler
 }
 
-// NewHandler 创建Handler实例
-func NewHandler(logger *log.Logger, store store.IStore) Handler {
+// NewHandler 使用业务层实例创建Handler实例
+func NewHandler(logger *log.Logger, biz biz.IBiz) Handler {
 	h := &handler{
 		logger: logger,
-		store:  store,
 	}
-	biz := biz.NewBiz(store)
 
 	h.UserHandler = NewUserHandler(logger, biz)
 	h.PostHandler = NewPostHandler(logger, biz)
+	h.APIKeyHandler = NewAPIKeyHandler(logger, biz)
 	return h
 }
 
@@ -47,3 +55,22 @@ func (h *handler) Posts() PostHandler {
 
 	return h.PostHandler
 }
+
+// APIKeys API 密钥相关接口
+func (h *handler) APIKeys() APIKeyHandler {
+	return h.APIKeyHandler
+}
+
+// getIDParam 获取路径中的 ID 参数
+func getIDParam(c *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, errno.ErrInvalidParams.WithMessage("无效的ID")
+	}
+	return uint(id), nil
+}
+
+// bindError 将请求参数绑定错误转换为按请求语言翻译的参数错误
+func bindError(c *gin.Context, err error) error {
+	return validation.Translate(err, contextx.Locale(c.Request.Context()))
+}
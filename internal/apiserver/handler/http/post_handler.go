@@ -3,6 +3,8 @@ package handler
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/lichenglife/easyblog/internal/apiserver/biz"
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/core"
 	"github.com/lichenglife/easyblog/internal/pkg/log"
 )
 
@@ -30,37 +32,79 @@ type postHandler struct {
 
 // createPost implements PostHandler.
 func (p *postHandler) CreatePost(c *gin.Context) {
-	panic("unimplemented")
+	var req model.CreatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	post, err := p.postBiz.PostV1().CreatePost(c.Request.Context(), &req)
+	core.WriteResponse(c, err, post)
 }
 
 // deletePost implements PostHandler.
 func (p *postHandler) DeletePost(c *gin.Context) {
-	panic("unimplemented")
+	id, err := getIDParam(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	err = p.postBiz.PostV1().DeletePost(c.Request.Context(), id)
+	core.WriteResponse(c, err, nil)
 }
 
 // getPostByID implements PostHandler.
 func (p *postHandler) GetPostByID(c *gin.Context) {
-	panic("unimplemented")
+	id, err := getIDParam(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	post, err := p.postBiz.PostV1().GetPostByID(c.Request.Context(), id)
+	core.WriteResponse(c, err, post)
 }
 
 // getPostsByUserID implements PostHandler.
 func (p *postHandler) GetPostsByUserID(c *gin.Context) {
-	panic("unimplemented")
+	page, pageSize := core.GetPaginationParams(c)
+
+	resp, err := p.postBiz.PostV1().GetPostsByUserID(c.Request.Context(), c.Param("userID"), page, pageSize)
+	core.WriteResponse(c, err, resp)
 }
 
 // listPosts implements PostHandler.
 func (p *postHandler) ListPosts(c *gin.Context) {
-	panic("unimplemented")
+	page, pageSize := core.GetPaginationParams(c)
+
+	resp, err := p.postBiz.PostV1().ListPosts(c.Request.Context(), page, pageSize)
+	core.WriteResponse(c, err, resp)
 }
 
 // updatePost implements PostHandler.
 func (p *postHandler) UpdatePost(c *gin.Context) {
-	panic("unimplemented")
+	id, err := getIDParam(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+	var req model.UpdatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+	// 帖子 ID 以路径参数为准
+	req.ID = id
+
+	err = p.postBiz.PostV1().UpdatePost(c.Request.Context(), &req)
+	core.WriteResponse(c, err, nil)
 }
 
 // NewPostHandler 创建PostHandler实例
 func NewPostHandler(logger *log.Logger, postBiz biz.IBiz) PostHandler {
 	return &postHandler{
+		logger:  logger,
 		postBiz: postBiz,
 	}
 }
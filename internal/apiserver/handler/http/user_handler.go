@@ -1,27 +1,69 @@
 package handler
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/lichenglife/easyblog/internal/apiserver/biz"
 	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/core"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
 	"github.com/lichenglife/easyblog/internal/pkg/log"
 )
 
+// 第三方登录 state Cookie，只在回调路径下发送
+const (
+	oauthStateCookie = "oauth_state"
+	oauthCookiePath  = "/v1/user/oauth"
+)
+
 // UserHandler 用户相关接口
 type UserHandler interface {
 
 	// CreteUser 创建用户
 	CreateUser(c *gin.Context)
 	// ChangePassword 修改密码
+	ChangePassword(c *gin.Context)
+	// RequestPasswordReset 申请重置密码
+	RequestPasswordReset(c *gin.Context)
 	// ResetPassword 重置密码
 	ResetPassword(c *gin.Context)
-	// UserInfo 获取用户信息
-	GetUserInfo(c *gin.Context)
+	// VerifyEmail 验证邮箱
+	VerifyEmail(c *gin.Context)
+	// ResendVerification 重新发送邮箱验证链接
+	ResendVerification(c *gin.Context)
 
 	// UserLogin 用户登录
 	UserLogin(c *gin.Context)
+	// LoginMFA 两步验证登录
+	LoginMFA(c *gin.Context)
+	// EnrollMFA 注册两步验证
+	EnrollMFA(c *gin.Context)
+	// EnableMFA 启用两步验证
+	EnableMFA(c *gin.Context)
+	// DisableMFA 关闭两步验证
+	DisableMFA(c *gin.Context)
 	// UserLogout 用户登出
 	UserLogout(c *gin.Context)
+	// RefreshToken 刷新访问令牌
+	RefreshToken(c *gin.Context)
+	// ListSessions 获取当前用户的会话列表
+	ListSessions(c *gin.Context)
+	// RevokeSession 注销指定会话
+	RevokeSession(c *gin.Context)
+	// RevokeAllSessions 注销全部会话
+	RevokeAllSessions(c *gin.Context)
+	// OAuthLogin 跳转到身份提供方登录
+	OAuthLogin(c *gin.Context)
+	// OAuthCallback 身份提供方登录回调
+	OAuthCallback(c *gin.Context)
+	// LinkIdentity 关联第三方账号
+	LinkIdentity(c *gin.Context)
+	// ListIdentities 获取当前用户关联的第三方账号
+	ListIdentities(c *gin.Context)
+	// UnlinkIdentity 解除第三方账号关联
+	UnlinkIdentity(c *gin.Context)
 	// UserInfo 获取用户信息
 	UserInfo(c *gin.Context)
 	// ListUsers 获取用户列表
@@ -32,6 +74,8 @@ type UserHandler interface {
 	UpdateUser(c *gin.Context)
 	// DeleteUser 删除用户
 	DeleteUser(c *gin.Context)
+	// UpdateUserRole 修改用户角色
+	UpdateUserRole(c *gin.Context)
 }
 
 // userHandler 实现了 UserHandler 接口
@@ -55,52 +99,309 @@ func (u *userHandler) CreateUser(c *gin.Context) {
 	// 解析请求参数
 	var req model.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	user, err := u.userBiz.UserV1().CreateUser(c.Request.Context(), &req)
+	core.WriteResponse(c, err, user)
+}
+
+// ChangePassword implements UserHandler.
+func (u *userHandler) ChangePassword(c *gin.Context) {
+	id, err := getIDParam(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+	var req model.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
 		return
 	}
+
+	err = u.userBiz.UserV1().ChangePassword(c.Request.Context(), id, &req)
+	core.WriteResponse(c, err, nil)
 }
 
 // DeleteUser implements UserHandler.
 func (u *userHandler) DeleteUser(c *gin.Context) {
-	panic("unimplemented")
+	id, err := getIDParam(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	err = u.userBiz.UserV1().DeleteUser(c.Request.Context(), id)
+	core.WriteResponse(c, err, nil)
 }
 
 // GetUserByID implements UserHandler.
 func (u *userHandler) GetUserByID(c *gin.Context) {
-	panic("unimplemented")
-}
+	id, err := getIDParam(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
 
-// GetUserInfo implements UserHandler.
-func (u *userHandler) GetUserInfo(c *gin.Context) {
-	panic("unimplemented")
+	user, err := u.userBiz.UserV1().GetUserByID(c.Request.Context(), id)
+	core.WriteResponse(c, err, user)
 }
 
 // ListUsers implements UserHandler.
 func (u *userHandler) ListUsers(c *gin.Context) {
-	panic("unimplemented")
+	page, pageSize := core.GetPaginationParams(c)
+
+	resp, err := u.userBiz.UserV1().ListUsers(c.Request.Context(), page, pageSize)
+	core.WriteResponse(c, err, resp)
+}
+
+// RequestPasswordReset implements UserHandler.
+// 无论用户是否存在都返回成功
+func (u *userHandler) RequestPasswordReset(c *gin.Context) {
+	var req model.PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	err := u.userBiz.UserV1().RequestPasswordReset(c.Request.Context(), &req)
+	core.WriteResponse(c, err, nil)
 }
 
 // ResetPassword implements UserHandler.
 func (u *userHandler) ResetPassword(c *gin.Context) {
-	panic("unimplemented")
+	var req model.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	err := u.userBiz.UserV1().ResetPassword(c.Request.Context(), &req)
+	core.WriteResponse(c, err, nil)
+}
+
+// VerifyEmail implements UserHandler.
+// 验证令牌通过 token 查询参数传递，便于用户直接点击邮件中的链接
+func (u *userHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		core.WriteResponse(c, errno.ErrInvalidParams.WithMessage("缺少验证令牌"), nil)
+		return
+	}
+
+	err := u.userBiz.UserV1().VerifyEmail(c.Request.Context(), token)
+	core.WriteResponse(c, err, nil)
+}
+
+// ResendVerification implements UserHandler.
+func (u *userHandler) ResendVerification(c *gin.Context) {
+	err := u.userBiz.UserV1().ResendVerification(c.Request.Context())
+	core.WriteResponse(c, err, nil)
 }
 
 // UpdateUser implements UserHandler.
 func (u *userHandler) UpdateUser(c *gin.Context) {
-	panic("unimplemented")
+	id, err := getIDParam(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+	var req model.UpdateUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	user, err := u.userBiz.UserV1().UpdateUser(c.Request.Context(), id, &req)
+	core.WriteResponse(c, err, user)
+}
+
+// UpdateUserRole implements UserHandler.
+func (u *userHandler) UpdateUserRole(c *gin.Context) {
+	id, err := getIDParam(c)
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+	var req model.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	user, err := u.userBiz.UserV1().UpdateRole(c.Request.Context(), id, &req)
+	core.WriteResponse(c, err, user)
 }
 
 // UserInfo implements UserHandler.
+// 获取当前登录用户的信息
 func (u *userHandler) UserInfo(c *gin.Context) {
-	panic("unimplemented")
+	ctx := c.Request.Context()
+
+	user, err := u.userBiz.UserV1().GetUserByUsername(ctx, contextx.Username(ctx))
+	core.WriteResponse(c, err, user)
 }
 
 // UserLogin implements UserHandler.
 func (u *userHandler) UserLogin(c *gin.Context) {
-	panic("unimplemented")
+	var req model.UserLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	resp, err := u.userBiz.UserV1().Login(c.Request.Context(), &req)
+	core.WriteResponse(c, err, resp)
+}
+
+// LoginMFA implements UserHandler.
+func (u *userHandler) LoginMFA(c *gin.Context) {
+	var req model.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	resp, err := u.userBiz.UserV1().LoginMFA(c.Request.Context(), &req)
+	core.WriteResponse(c, err, resp)
+}
+
+// EnrollMFA implements UserHandler.
+func (u *userHandler) EnrollMFA(c *gin.Context) {
+	resp, err := u.userBiz.UserV1().EnrollMFA(c.Request.Context())
+	core.WriteResponse(c, err, resp)
+}
+
+// EnableMFA implements UserHandler.
+func (u *userHandler) EnableMFA(c *gin.Context) {
+	var req model.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	resp, err := u.userBiz.UserV1().EnableMFA(c.Request.Context(), &req)
+	core.WriteResponse(c, err, resp)
+}
+
+// DisableMFA implements UserHandler.
+func (u *userHandler) DisableMFA(c *gin.Context) {
+	var req model.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	err := u.userBiz.UserV1().DisableMFA(c.Request.Context(), &req)
+	core.WriteResponse(c, err, nil)
 }
 
 // UserLogout implements UserHandler.
 func (u *userHandler) UserLogout(c *gin.Context) {
-	panic("unimplemented")
+	err := u.userBiz.UserV1().Logout(c.Request.Context())
+	core.WriteResponse(c, err, nil)
+}
+
+// ListSessions implements UserHandler.
+func (u *userHandler) ListSessions(c *gin.Context) {
+	resp, err := u.userBiz.UserV1().ListSessions(c.Request.Context())
+	core.WriteResponse(c, err, resp)
+}
+
+// RevokeSession implements UserHandler.
+func (u *userHandler) RevokeSession(c *gin.Context) {
+	err := u.userBiz.UserV1().RevokeSession(c.Request.Context(), c.Param("id"))
+	core.WriteResponse(c, err, nil)
+}
+
+// RevokeAllSessions implements UserHandler.
+func (u *userHandler) RevokeAllSessions(c *gin.Context) {
+	err := u.userBiz.UserV1().RevokeAllSessions(c.Request.Context())
+	core.WriteResponse(c, err, nil)
+}
+
+// OAuthLogin implements UserHandler.
+// 重定向到身份提供方的授权地址，并将 state 写入 Cookie
+func (u *userHandler) OAuthLogin(c *gin.Context) {
+	resp, err := u.userBiz.UserV1().OAuthLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	setStateCookie(c, resp.State)
+	c.Redirect(http.StatusFound, resp.AuthURL)
+}
+
+// OAuthCallback implements UserHandler.
+// 回调中的 state 必须与发起授权时写入的 Cookie 一致，防止登录 CSRF
+func (u *userHandler) OAuthCallback(c *gin.Context) {
+	var req model.OAuthCallbackRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		// 用户拒绝授权时身份提供方只返回 error 参数
+		if reason := c.Query("error"); reason != "" {
+			core.WriteResponse(c, errno.ErrOAuthLoginFailed.WithMessage("身份提供方拒绝授权: "+reason), nil)
+			return
+		}
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+	state, err := c.Cookie(oauthStateCookie)
+	setStateCookie(c, "")
+	if err != nil || state != req.State {
+		core.WriteResponse(c, errno.ErrOAuthStateInvalid, nil)
+		return
+	}
+
+	resp, err := u.userBiz.UserV1().OAuthCallback(c.Request.Context(), c.Param("provider"), &req)
+	core.WriteResponse(c, err, resp)
+}
+
+// LinkIdentity implements UserHandler.
+// 返回授权地址，由前端跳转到身份提供方，回调时完成关联
+func (u *userHandler) LinkIdentity(c *gin.Context) {
+	resp, err := u.userBiz.UserV1().LinkIdentity(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		core.WriteResponse(c, err, nil)
+		return
+	}
+
+	setStateCookie(c, resp.State)
+	core.WriteResponse(c, nil, resp)
+}
+
+// ListIdentities implements UserHandler.
+func (u *userHandler) ListIdentities(c *gin.Context) {
+	resp, err := u.userBiz.UserV1().ListIdentities(c.Request.Context())
+	core.WriteResponse(c, err, resp)
+}
+
+// UnlinkIdentity implements UserHandler.
+func (u *userHandler) UnlinkIdentity(c *gin.Context) {
+	err := u.userBiz.UserV1().UnlinkIdentity(c.Request.Context(), c.Param("provider"))
+	core.WriteResponse(c, err, nil)
+}
+
+// setStateCookie 写入第三方登录的 state Cookie，state 为空时删除 Cookie
+func setStateCookie(c *gin.Context, state string) {
+	maxAge := 0
+	if state == "" {
+		maxAge = -1
+	}
+	// 身份提供方通过顶层跳转回调，SameSite 需要为 Lax 才会携带 Cookie
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, maxAge, oauthCookiePath, "", c.Request.TLS != nil, true)
+}
+
+// RefreshToken implements UserHandler.
+func (u *userHandler) RefreshToken(c *gin.Context) {
+	var req model.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		core.WriteResponse(c, bindError(c, err), nil)
+		return
+	}
+
+	resp, err := u.userBiz.UserV1().RefreshToken(c.Request.Context(), &req)
+	core.WriteResponse(c, err, resp)
 }
@@ -0,0 +1,34 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userV1 为版本 1 时的用户表结构快照
+type userV1 struct {
+	ID       uint      `gorm:"primaryKey"`
+	UserID   string    `gorm:"column:userID;type:varchar(36);not null;uniqueIndex:idx_user_userID;comment:用户唯一 ID"`
+	Username string    `gorm:"column:username;type:varchar(36);not null;uniqueIndex:idx_user_username;comment:用户名"`
+	Password string    `gorm:"column:password;type:varchar(36);not null;comment:密码"`
+	NickName string    `gorm:"column:nickName;type:varchar(36);not null;comment:昵称"`
+	Email    string    `gorm:"column:email;type:varchar(36);not null;comment:邮箱"`
+	Phone    string    `gorm:"column:phone;type:varchar(36);not null;uniqueIndex:idx_user_phone;comment:手机"`
+	CreateAt time.Time `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间"`
+	UpdateAt time.Time `gorm:"column:updateAt;not null;default:CURRENT_TIMESTAMP;comment:更新时间"`
+}
+
+func (userV1) TableName() string { return "user" }
+
+// createUserTable 创建用户表
+var createUserTable = Migration{
+	Version: 1,
+	Name:    "create_user_table",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&userV1{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&userV1{})
+	},
+}
@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// postV2 为版本 2 时的帖子表结构快照
+type postV2 struct {
+	ID       uint      `gorm:"primarykey"`
+	UserID   string    `gorm:"column:userID;type:varchar(36);not null;index:idx_post_userID;comment:用户唯一 ID"`
+	PostID   string    `gorm:"column:postID;type:varchar(36);not null;uniqueIndex:idx_post_postID;comment:帖子唯一 ID"`
+	Content  string    `gorm:"column:content;type:varchar(255);not null;comment:内容"`
+	Title    string    `gorm:"column:title;type:varchar(255);not null;comment:标题"`
+	CreateAt time.Time `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间"`
+	UpdateAt time.Time `gorm:"column:updateAt;not null;default:CURRENT_TIMESTAMP;comment:更新时间"`
+}
+
+func (postV2) TableName() string { return "post" }
+
+// createPostTable 创建帖子表
+var createPostTable = Migration{
+	Version: 2,
+	Name:    "create_post_table",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&postV2{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&postV2{})
+	},
+}
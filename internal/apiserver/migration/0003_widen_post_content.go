@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// postV3 为版本 3 时的帖子内容字段，varchar(255) 无法容纳正常篇幅的博客正文
+type postV3 struct {
+	Content string `gorm:"column:content;type:text;not null;comment:内容"`
+}
+
+func (postV3) TableName() string { return "post" }
+
+// widenPostContent 将帖子内容字段扩展为 text 类型
+var widenPostContent = Migration{
+	Version: 3,
+	Name:    "widen_post_content",
+	Up: func(tx *gorm.DB) error {
+		// SQLite 不限制 varchar 长度，且修改字段需要重建表并会丢失索引，直接跳过
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		return tx.Migrator().AlterColumn(&postV3{}, "Content")
+	},
+	Down: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		return tx.Migrator().AlterColumn(&postV2{}, "Content")
+	},
+}
@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// userV4 为版本 4 时的密码字段，需要容纳带参数的 argon2id/bcrypt 哈希
+type userV4 struct {
+	Password string `gorm:"column:password;type:varchar(255);not null;comment:密码哈希"`
+}
+
+func (userV4) TableName() string { return "user" }
+
+// widenUserPassword 扩展密码字段长度以保存密码哈希
+var widenUserPassword = Migration{
+	Version: 4,
+	Name:    "widen_user_password",
+	Up: func(tx *gorm.DB) error {
+		// SQLite 不限制 varchar 长度，且修改字段需要重建表并会丢失索引，直接跳过
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		return tx.Migrator().AlterColumn(&userV4{}, "Password")
+	},
+	Down: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		return tx.Migrator().AlterColumn(&userV1{}, "Password")
+	},
+}
@@ -0,0 +1,24 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// userV5 为版本 5 时新增的角色字段，已有用户默认为作者
+type userV5 struct {
+	Role string `gorm:"column:role;type:varchar(16);not null;default:author;comment:角色"`
+}
+
+func (userV5) TableName() string { return "user" }
+
+// addUserRole 为用户表增加角色字段
+var addUserRole = Migration{
+	Version: 5,
+	Name:    "add_user_role",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().AddColumn(&userV5{}, "Role")
+	},
+	Down: func(tx *gorm.DB) error {
+		return dropColumn(tx, &userV5{}, "role")
+	},
+}
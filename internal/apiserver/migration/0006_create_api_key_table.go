@@ -0,0 +1,35 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyV6 为版本 6 时的 API 密钥表结构快照
+type apiKeyV6 struct {
+	ID         uint       `gorm:"primaryKey"`
+	KeyID      string     `gorm:"column:keyID;type:varchar(32);not null;uniqueIndex:idx_api_key_keyID;comment:密钥公开 ID"`
+	UserID     string     `gorm:"column:userID;type:varchar(36);not null;index:idx_api_key_userID;comment:用户唯一 ID"`
+	Name       string     `gorm:"column:name;type:varchar(64);not null;comment:密钥名称"`
+	Scopes     string     `gorm:"column:scopes;type:varchar(255);not null;comment:授权范围，逗号分隔"`
+	SecretHash string     `gorm:"column:secretHash;type:varchar(64);not null;comment:密钥哈希"`
+	ExpiresAt  *time.Time `gorm:"column:expiresAt;comment:过期时间，为空表示永不过期"`
+	LastUsedAt *time.Time `gorm:"column:lastUsedAt;comment:最近使用时间"`
+	CreateAt   time.Time  `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间"`
+	UpdateAt   time.Time  `gorm:"column:updateAt;not null;default:CURRENT_TIMESTAMP;comment:更新时间"`
+}
+
+func (apiKeyV6) TableName() string { return "api_key" }
+
+// createAPIKeyTable 创建 API 密钥表
+var createAPIKeyTable = Migration{
+	Version: 6,
+	Name:    "create_api_key_table",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&apiKeyV6{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&apiKeyV6{})
+	},
+}
@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// passwordResetV7 为版本 7 时的密码重置令牌表结构快照
+type passwordResetV7 struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    string     `gorm:"column:userID;type:varchar(36);not null;index:idx_password_reset_userID;comment:用户唯一 ID"`
+	TokenHash string     `gorm:"column:tokenHash;type:varchar(64);not null;uniqueIndex:idx_password_reset_tokenHash;comment:令牌哈希"`
+	ExpiresAt time.Time  `gorm:"column:expiresAt;not null;comment:过期时间"`
+	UsedAt    *time.Time `gorm:"column:usedAt;comment:使用时间，为空表示未使用"`
+	CreateAt  time.Time  `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间"`
+}
+
+func (passwordResetV7) TableName() string { return "password_reset" }
+
+// createPasswordResetTable 创建密码重置令牌表
+var createPasswordResetTable = Migration{
+	Version: 7,
+	Name:    "create_password_reset_table",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&passwordResetV7{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&passwordResetV7{})
+	},
+}
@@ -0,0 +1,24 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// userV8 为版本 8 时新增的邮箱验证状态字段，已有用户默认为未验证
+type userV8 struct {
+	EmailVerified bool `gorm:"column:emailVerified;not null;default:false;comment:邮箱是否已验证"`
+}
+
+func (userV8) TableName() string { return "user" }
+
+// addUserEmailVerified 为用户表增加邮箱验证状态字段
+var addUserEmailVerified = Migration{
+	Version: 8,
+	Name:    "add_user_email_verified",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().AddColumn(&userV8{}, "EmailVerified")
+	},
+	Down: func(tx *gorm.DB) error {
+		return dropColumn(tx, &userV8{}, "emailVerified")
+	},
+}
@@ -0,0 +1,33 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userMFAV9 为版本 9 时的两步验证表结构快照
+type userMFAV9 struct {
+	ID            uint      `gorm:"primaryKey"`
+	UserID        string    `gorm:"column:userID;type:varchar(36);not null;uniqueIndex:idx_user_mfa_userID;comment:用户唯一 ID"`
+	Secret        string    `gorm:"column:secret;type:varchar(255);not null;comment:加密后的 TOTP 密钥"`
+	Enabled       bool      `gorm:"column:enabled;not null;default:false;comment:是否已启用，注册后验证通过才启用"`
+	LastStep      int64     `gorm:"column:lastStep;not null;default:0;comment:最近一次使用的验证码时间步"`
+	RecoveryCodes string    `gorm:"column:recoveryCodes;type:varchar(1024);not null;comment:未使用的恢复码哈希，逗号分隔"`
+	CreateAt      time.Time `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间"`
+	UpdateAt      time.Time `gorm:"column:updateAt;not null;default:CURRENT_TIMESTAMP;comment:更新时间"`
+}
+
+func (userMFAV9) TableName() string { return "user_mfa" }
+
+// createUserMFATable 创建两步验证表
+var createUserMFATable = Migration{
+	Version: 9,
+	Name:    "create_user_mfa_table",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&userMFAV9{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&userMFAV9{})
+	},
+}
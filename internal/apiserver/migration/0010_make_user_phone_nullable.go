@@ -0,0 +1,58 @@
+package migration
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// userV10 为版本 10 时的手机号字段，通过第三方账号自动创建的用户没有手机号，
+// 唯一索引允许多个 NULL，已设置的手机号仍然保持唯一
+type userV10 struct {
+	UserID   string  `gorm:"column:userID;type:varchar(36);not null;uniqueIndex:idx_user_userID;comment:用户唯一 ID"`
+	Username string  `gorm:"column:username;type:varchar(36);not null;uniqueIndex:idx_user_username;comment:用户名"`
+	Phone    *string `gorm:"column:phone;type:varchar(36);uniqueIndex:idx_user_phone;comment:手机"`
+}
+
+func (userV10) TableName() string { return "user" }
+
+// makeUserPhoneNullable 允许用户手机号为空
+var makeUserPhoneNullable = Migration{
+	Version: 10,
+	Name:    "make_user_phone_nullable",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().AlterColumn(&userV10{}, "Phone"); err != nil {
+			return err
+		}
+		return restoreUserIndexes(tx)
+	},
+	Down: func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&userV10{}).Where("phone IS NULL").Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("存在 %d 个未设置手机号的用户，无法回滚", count)
+		}
+		if err := tx.Migrator().AlterColumn(&userV1{}, "Phone"); err != nil {
+			return err
+		}
+		return restoreUserIndexes(tx)
+	},
+}
+
+// restoreUserIndexes SQLite 修改字段时会重建表并丢失索引，修改后重新创建用户表的索引
+func restoreUserIndexes(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "sqlite" {
+		return nil
+	}
+	for _, name := range []string{"idx_user_userID", "idx_user_username", "idx_user_phone"} {
+		if tx.Migrator().HasIndex(&userV10{}, name) {
+			continue
+		}
+		if err := tx.Migrator().CreateIndex(&userV10{}, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
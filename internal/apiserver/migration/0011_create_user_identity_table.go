@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userIdentityV11 为版本 11 时的第三方账号关联表结构快照
+type userIdentityV11 struct {
+	ID       uint      `gorm:"primaryKey"`
+	UserID   string    `gorm:"column:userID;type:varchar(36);not null;index:idx_user_identity_userID;comment:用户唯一 ID"`
+	Provider string    `gorm:"column:provider;type:varchar(64);not null;uniqueIndex:idx_user_identity_subject,priority:1;comment:身份提供方名称"`
+	Subject  string    `gorm:"column:subject;type:varchar(255);not null;uniqueIndex:idx_user_identity_subject,priority:2;comment:身份提供方中的用户 ID"`
+	Email    string    `gorm:"column:email;type:varchar(255);not null;default:'';comment:关联时身份提供方返回的邮箱"`
+	CreateAt time.Time `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间"`
+}
+
+func (userIdentityV11) TableName() string { return "user_identity" }
+
+// createUserIdentityTable 创建第三方账号关联表
+var createUserIdentityTable = Migration{
+	Version: 11,
+	Name:    "create_user_identity_table",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&userIdentityV11{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&userIdentityV11{})
+	},
+}
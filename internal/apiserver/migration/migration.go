@@ -0,0 +1,240 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 迁移方向
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// Migration 表示一个版本化的数据库迁移步骤
+type Migration struct {
+	// Version 迁移版本号，必须唯一且递增
+	Version uint
+	// Name 迁移名称
+	Name string
+	// Up 执行迁移
+	Up func(tx *gorm.DB) error
+	// Down 回滚迁移
+	Down func(tx *gorm.DB) error
+}
+
+// SchemaMigration 记录已执行的迁移版本
+type SchemaMigration struct {
+	Version   uint      `gorm:"column:version;primaryKey;autoIncrement:false;comment:迁移版本号"`
+	Name      string    `gorm:"column:name;type:varchar(255);not null;comment:迁移名称"`
+	AppliedAt time.Time `gorm:"column:appliedAt;not null;comment:执行时间"`
+}
+
+// TableName 表名
+func (SchemaMigration) TableName() string { return "schema_migrations" }
+
+// Status 表示迁移的执行状态
+type Status struct {
+	Version   uint
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Change 表示一次迁移变更
+type Change struct {
+	Version   uint
+	Name      string
+	Direction string
+}
+
+// Migrator 负责按照版本顺序执行和回滚迁移
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator 使用已注册的迁移步骤创建 Migrator 实例
+func NewMigrator(db *gorm.DB) *Migrator {
+	return NewMigratorWithMigrations(db, migrations)
+}
+
+// NewMigratorWithMigrations 使用指定的迁移步骤创建 Migrator 实例
+func NewMigratorWithMigrations(db *gorm.DB, ms []Migration) *Migrator {
+	sorted := make([]Migration, len(ms))
+	copy(sorted, ms)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Latest 返回最新的迁移版本号
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up 执行所有未执行的迁移
+func (m *Migrator) Up(ctx context.Context) ([]Change, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down 回滚最近一次执行的迁移
+func (m *Migrator) Down(ctx context.Context) ([]Change, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			if err := m.down(ctx, m.migrations[i]); err != nil {
+				return nil, err
+			}
+			return []Change{change(m.migrations[i], DirectionDown)}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// To 将数据库迁移到指定版本：执行不大于该版本的未执行迁移，回滚大于该版本的已执行迁移
+func (m *Migrator) To(ctx context.Context, version uint) ([]Change, error) {
+	if version != 0 && !m.exists(version) {
+		return nil, fmt.Errorf("迁移版本 %d 不存在", version)
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	// 先按照版本倒序回滚
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mg := m.migrations[i]
+		if _, ok := applied[mg.Version]; !ok || mg.Version <= version {
+			continue
+		}
+		if err := m.down(ctx, mg); err != nil {
+			return changes, err
+		}
+		changes = append(changes, change(mg, DirectionDown))
+	}
+	// 再按照版本顺序执行
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; ok || mg.Version > version {
+			continue
+		}
+		if err := m.up(ctx, mg); err != nil {
+			return changes, err
+		}
+		changes = append(changes, change(mg, DirectionUp))
+	}
+
+	return changes, nil
+}
+
+// Status 返回所有迁移的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		st := Status{Version: mg.Version, Name: mg.Name}
+		if record, ok := applied[mg.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = record.AppliedAt
+		}
+		list = append(list, st)
+	}
+
+	return list, nil
+}
+
+// up 在事务中执行迁移并记录版本
+func (m *Migrator) up(ctx context.Context, mg Migration) error {
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := mg.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("执行迁移 %d_%s 失败: %v", mg.Version, mg.Name, err)
+	}
+	return nil
+}
+
+// down 在事务中回滚迁移并删除版本记录
+func (m *Migrator) down(ctx context.Context, mg Migration) error {
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := mg.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{Version: mg.Version}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("回滚迁移 %d_%s 失败: %v", mg.Version, mg.Name, err)
+	}
+	return nil
+}
+
+// applied 查询已执行的迁移版本，迁移记录表不存在时自动创建
+func (m *Migrator) applied(ctx context.Context) (map[uint]SchemaMigration, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		if err := db.Migrator().CreateTable(&SchemaMigration{}); err != nil {
+			return nil, fmt.Errorf("创建迁移记录表失败: %v", err)
+		}
+	}
+
+	var records []SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %v", err)
+	}
+
+	applied := make(map[uint]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// exists 判断指定版本的迁移是否存在
+func (m *Migrator) exists(version uint) bool {
+	for _, mg := range m.migrations {
+		if mg.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func change(mg Migration, direction string) Change {
+	return Change{Version: mg.Version, Name: mg.Name, Direction: direction}
+}
+
+// dropColumn 删除字段。gorm 在 SQLite 下删除字段需要重建表并会丢失索引，
+// 因此 SQLite 直接使用 ALTER TABLE DROP COLUMN (3.35+)
+func dropColumn(tx *gorm.DB, model interface{}, column string) error {
+	if tx.Dialector.Name() != "sqlite" {
+		return tx.Migrator().DropColumn(model, column)
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	return tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: stmt.Schema.Table}, clause.Column{Name: column}).Error
+}
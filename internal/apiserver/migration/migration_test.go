@@ -0,0 +1,161 @@
+package migration
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/db/dbtest"
+)
+
+// models 执行全部迁移后数据库结构需要与之一致的模型
+var models = []interface{}{
+	&model.User{},
+	&model.Post{},
+	&model.APIKey{},
+	&model.PasswordReset{},
+	&model.UserMFA{},
+	&model.UserIdentity{},
+}
+
+// checkSchema 检查 models 中每个模型的表和字段都已经创建
+func checkSchema(t *testing.T, gormDB *gorm.DB) {
+	t.Helper()
+
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: gormDB}
+		if err := stmt.Parse(m); err != nil {
+			t.Fatalf("解析模型 %T: %v", m, err)
+		}
+		if !gormDB.Migrator().HasTable(stmt.Schema.Table) {
+			t.Errorf("表 %s 不存在", stmt.Schema.Table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !gormDB.Migrator().HasColumn(stmt.Schema.Table, field.DBName) {
+				t.Errorf("字段 %s.%s 不存在", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+}
+
+// checkStatus 检查版本不超过 version 的迁移都已执行，其余的都未执行
+func checkStatus(t *testing.T, m *Migrator, version uint) {
+	t.Helper()
+
+	status, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, st := range status {
+		if want := st.Version <= version; st.Applied != want {
+			t.Errorf("To(%d): 迁移 %d 已执行 = %v，期望 %v", version, st.Version, st.Applied, want)
+		}
+	}
+}
+
+func TestMigratorUp(t *testing.T) {
+	gormDB := dbtest.NewSQLite(t)
+	m := NewMigrator(gormDB)
+	changes, err := m.Up(context.Background())
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if uint(len(changes)) != m.Latest() {
+		t.Errorf("Up 执行了 %d 个迁移，期望 %d", len(changes), m.Latest())
+	}
+	checkSchema(t, gormDB)
+	checkStatus(t, m, m.Latest())
+
+	// 已经是最新版本时不再执行任何迁移
+	if changes, err := m.Up(context.Background()); err != nil || len(changes) != 0 {
+		t.Errorf("重复执行 Up 返回 %v, %v，期望没有变更", changes, err)
+	}
+}
+
+func TestMigratorTo(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.NewSQLite(t)
+	m := NewMigrator(gormDB)
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	// 逐个版本回滚，每个迁移的 Down 都必须能在上一个迁移之后执行
+	for version := int(m.Latest()) - 1; version >= 0; version-- {
+		changes, err := m.To(ctx, uint(version))
+		if err != nil {
+			t.Fatalf("To(%d): %v", version, err)
+		}
+		if len(changes) != 1 || changes[0].Direction != DirectionDown {
+			t.Fatalf("To(%d) 返回 %v，期望回滚一个迁移", version, changes)
+		}
+		checkStatus(t, m, uint(version))
+	}
+
+	for _, m := range models {
+		if gormDB.Migrator().HasTable(m) {
+			t.Errorf("全部回滚后表 %T 仍然存在", m)
+		}
+	}
+
+	// 全部回滚后可以重新执行
+	if _, err := m.To(ctx, m.Latest()); err != nil {
+		t.Fatalf("To(%d): %v", m.Latest(), err)
+	}
+	checkSchema(t, gormDB)
+	checkStatus(t, m, m.Latest())
+}
+
+func TestMigratorDownKeepsData(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.NewSQLite(t)
+	m := NewMigrator(gormDB)
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	err := gormDB.Exec("INSERT INTO user (userID, username, password, nickName, email, phone) VALUES (?, ?, ?, ?, ?, ?)",
+		"user-1", "user1", "hash", "nick", "user1@example.com", "13800000001").Error
+	if err != nil {
+		t.Fatalf("插入用户: %v", err)
+	}
+
+	// 回滚 11 和 10，10 在 SQLite 下会重建用户表
+	for i := 0; i < 2; i++ {
+		changes, err := m.Down(ctx)
+		if err != nil {
+			t.Fatalf("Down: %v", err)
+		}
+		if len(changes) != 1 || changes[0].Direction != DirectionDown {
+			t.Fatalf("Down 返回 %v，期望回滚一个迁移", changes)
+		}
+	}
+
+	var count int64
+	if err := gormDB.Table("user").Where("username = ?", "user1").Count(&count).Error; err != nil {
+		t.Fatalf("查询用户数: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("回滚后用户数 = %d，期望 1", count)
+	}
+	// 重建表后唯一索引仍然有效
+	err = gormDB.Exec("INSERT INTO user (userID, username, password, nickName, email, phone) VALUES (?, ?, ?, ?, ?, ?)",
+		"user-2", "user1", "hash", "nick", "user2@example.com", "13800000002").Error
+	if err == nil {
+		t.Error("插入重复的用户名应违反唯一索引")
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("回滚后重新执行迁移: %v", err)
+	}
+}
+
+func TestMigratorToUnknownVersion(t *testing.T) {
+	m := NewMigrator(dbtest.NewSQLite(t))
+	if _, err := m.To(context.Background(), m.Latest()+1); err == nil {
+		t.Error("迁移到不存在的版本应返回错误")
+	}
+}
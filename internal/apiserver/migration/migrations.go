@@ -0,0 +1,17 @@
+package migration
+
+// migrations 按版本顺序注册的全部迁移步骤。
+// 模型发生变化时必须追加新的迁移步骤，已发布的迁移步骤不允许再修改。
+var migrations = []Migration{
+	createUserTable,
+	createPostTable,
+	widenPostContent,
+	widenUserPassword,
+	addUserRole,
+	createAPIKeyTable,
+	createPasswordResetTable,
+	addUserEmailVerified,
+	createUserMFATable,
+	makeUserPhoneNullable,
+	createUserIdentityTable,
+}
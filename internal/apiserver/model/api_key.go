@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// APIKey 用户 API 密钥模型，密钥明文只在创建时返回一次，数据库中仅保存哈希
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	KeyID      string     `gorm:"column:keyID;type:varchar(32);not null;uniqueIndex:idx_api_key_keyID;comment:密钥公开 ID" json:"keyID"`
+	UserID     string     `gorm:"column:userID;type:varchar(36);not null;index:idx_api_key_userID;comment:用户唯一 ID" json:"userID"`
+	Name       string     `gorm:"column:name;type:varchar(64);not null;comment:密钥名称" json:"name"`
+	Scopes     string     `gorm:"column:scopes;type:varchar(255);not null;comment:授权范围，逗号分隔" json:"scopes"`
+	SecretHash string     `gorm:"column:secretHash;type:varchar(64);not null;comment:密钥哈希" json:"-"`
+	ExpiresAt  *time.Time `gorm:"column:expiresAt;comment:过期时间，为空表示永不过期" json:"expiresAt"`
+	LastUsedAt *time.Time `gorm:"column:lastUsedAt;comment:最近使用时间" json:"lastUsedAt"`
+	CreateAt   time.Time  `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间" json:"createAt"`
+	UpdateAt   time.Time  `gorm:"column:updateAt;not null;default:CURRENT_TIMESTAMP;comment:更新时间" json:"updateAt"`
+}
+
+// TableName 表名
+func (APIKey) TableName() string { return "api_key" }
+
+// 创建 API 密钥请求结构
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,min=1,max=64"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=posts:read posts:write users:admin"`
+	// ExpiresInDays 有效天数，为 0 表示永不过期
+	ExpiresInDays int `json:"expiresInDays" binding:"omitempty,min=1,max=365"`
+}
+
+// API 密钥响应结构
+type APIKeyInfo struct {
+	ID         uint       `json:"id"`
+	KeyID      string     `json:"keyID"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreateAt   time.Time  `json:"createAt"`
+}
+
+// 创建 API 密钥响应结构，Key 为密钥明文，只返回一次
+type CreateAPIKeyResponse struct {
+	Key string `json:"key"`
+	APIKeyInfo
+}
+
+// 查询 API 密钥列表响应结构
+type ListAPIKeyResponse struct {
+	TotalCount int64        `json:"totalCount"`
+	Keys       []APIKeyInfo `json:"keys"`
+}
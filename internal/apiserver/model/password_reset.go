@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// PasswordReset 密码重置令牌模型，数据库中仅保存令牌哈希
+type PasswordReset struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    string     `gorm:"column:userID;type:varchar(36);not null;index:idx_password_reset_userID;comment:用户唯一 ID" json:"userID"`
+	TokenHash string     `gorm:"column:tokenHash;type:varchar(64);not null;uniqueIndex:idx_password_reset_tokenHash;comment:令牌哈希" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expiresAt;not null;comment:过期时间" json:"expiresAt"`
+	UsedAt    *time.Time `gorm:"column:usedAt;comment:使用时间，为空表示未使用" json:"usedAt"`
+	CreateAt  time.Time  `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间" json:"createAt"`
+}
+
+// TableName 表名
+func (PasswordReset) TableName() string { return "password_reset" }
+
+// 申请重置密码请求结构
+type PasswordResetRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// 确认重置密码请求结构
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,password"`
+}
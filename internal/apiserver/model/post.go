@@ -5,27 +5,26 @@ import "time"
 // Post 博客模型
 type Post struct {
 	ID       uint      `gorm:"primarykey" json:"id"`
-	UserID   string    `gorm:"column:userID;type:varchar(36);not null;uniqueIndex:user.userID;comment:用户唯一 ID" json:"userID"`
-	PostID   string    `gorm:"column:postID;type:varchar(36);not null;uniqueIndex:post.postID;comment:帖子唯一 ID" json:"postID"`
-	Content  string    `gorm:"column:content;type:varchar(255);not null;comment:内容" json:"content"`
+	UserID   string    `gorm:"column:userID;type:varchar(36);not null;index:idx_post_userID;comment:用户唯一 ID" json:"userID"`
+	PostID   string    `gorm:"column:postID;type:varchar(36);not null;uniqueIndex:idx_post_postID;comment:帖子唯一 ID" json:"postID"`
+	Content  string    `gorm:"column:content;type:text;not null;comment:内容" json:"content"`
 	Title    string    `gorm:"column:title;type:varchar(255);not null;comment:标题" json:"title"`
-	CreateAt time.Time `gorm:"column:createAt;type:datetime;not null;default:CURRENT_TIMESTAMP;comment:创建时间" json:"createAt"`
-	UpdateAt time.Time `gorm:"column:updateAt;type:datetime;not null;default:CURRENT_TIMESTAMP;comment:更新时间" json:"updateAt"`
+	CreateAt time.Time `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间" json:"createAt"`
+	UpdateAt time.Time `gorm:"column:updateAt;not null;default:CURRENT_TIMESTAMP;comment:更新时间" json:"updateAt"`
 }
 
 // TableName 表名
 func (Post) TableName() string { return "post" }
 
-// 创建帖子请求结构
+// 创建帖子请求结构，作者为当前登录用户
 type CreatePostRequest struct {
-	UserID  string `json:"userID" binding:"required"`
 	Content string `json:"content" binding:"required"`
 	Title   string `json:"title" binding:"required"`
 }
 
 // 修改帖子请求结构
 type UpdatePostRequest struct {
-	ID      uint   `json:"id" binding:"required"`
+	ID      uint   `json:"-"` // 由路径参数指定
 	Content string `json:"content" binding:"required"`
 	Title   string `json:"title" binding:"required"`
 }
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// 会话响应结构，会话 ID 为登录时创建的令牌家族 ID
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	// Current 是否为当前请求使用的会话
+	Current bool `json:"current"`
+}
+
+// 查询会话列表响应结构
+type ListSessionResponse struct {
+	TotalCount int64         `json:"totalCount"`
+	Sessions   []SessionInfo `json:"sessions"`
+}
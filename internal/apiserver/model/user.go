@@ -4,15 +4,17 @@ import "time"
 
 // User 用户模型
 type User struct {
-	ID       uint      `gorm:"primaryKey" json:"id"`
-	UserID   string    `gorm:"column:userID;type:varchar(36);not null;uniqueIndex:user.userID;comment:用户唯一 ID" json:"userID"`
-	Username string    `gorm:"column:username;type:varchar(36);not null;uniqueIndex:user.username;comment:用户名" json:"username"`
-	Password string    `gorm:"column:password;type:varchar(36);not null;comment:密码" json:"-"`
-	NickName string    `gorm:"column:nickName;type:varchar(36);not null;comment:昵称" json:"nickName"`
-	Email    string    `gorm:"column:email;type:varchar(36);not null;comment:邮箱" json:"email"`
-	Phone    string    `gorm:"column:phone;type:varchar(36);not null;uniqueIndex:user.phone;comment:手机" json:"phone"`
-	CreateAt time.Time `gorm:"column:createAt;type:datetime;not null;default:CURRENT_TIMESTAMP;comment:创建时间" json:"createAt"`
-	UpdateAt time.Time `gorm:"column:updateAt;type:datetime;not null;default:CURRENT_TIMESTAMP;comment:更新时间" json:"updateAt"`
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        string    `gorm:"column:userID;type:varchar(36);not null;uniqueIndex:idx_user_userID;comment:用户唯一 ID" json:"userID"`
+	Username      string    `gorm:"column:username;type:varchar(36);not null;uniqueIndex:idx_user_username;comment:用户名" json:"username"`
+	Password      string    `gorm:"column:password;type:varchar(255);not null;comment:密码哈希" json:"-"`
+	NickName      string    `gorm:"column:nickName;type:varchar(36);not null;comment:昵称" json:"nickName"`
+	Email         string    `gorm:"column:email;type:varchar(36);not null;comment:邮箱" json:"email"`
+	Phone         *string   `gorm:"column:phone;type:varchar(36);uniqueIndex:idx_user_phone;comment:手机" json:"phone"`
+	Role          string    `gorm:"column:role;type:varchar(16);not null;default:author;comment:角色" json:"role"`
+	EmailVerified bool      `gorm:"column:emailVerified;not null;default:false;comment:邮箱是否已验证" json:"emailVerified"`
+	CreateAt      time.Time `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间" json:"createAt"`
+	UpdateAt      time.Time `gorm:"column:updateAt;not null;default:CURRENT_TIMESTAMP;comment:更新时间" json:"updateAt"`
 }
 
 // TableName 表名
@@ -36,11 +38,18 @@ type UpdateUser struct {
 
 // 用户响应结构体
 type UserInfo struct {
-	UserID   string `json:"userID"`
-	Username string `json:"username"`
-	Nickname string `json:"nickname"`
-	Email    string `json:"email"`
-	Phone    string `json:"phone"`
+	UserID        string `json:"userID"`
+	Username      string `json:"username"`
+	Nickname      string `json:"nickname"`
+	Email         string `json:"email"`
+	Phone         string `json:"phone"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"emailVerified"`
+}
+
+// 修改用户角色请求结构
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin editor author reader"`
 }
 
 //  用户登录请求结构
@@ -50,14 +59,37 @@ type UserLoginRequest struct {
 	Password string `json:"password" binding:"required,min=6,max=30"`
 }
 
+// 用户登录响应结构，启用两步验证时只返回挑战令牌，提交验证码后才返回访问令牌
 type UserLoginResponse struct {
-	Token string   `json:"token"`
-	User  UserInfo `json:"user"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+	User             UserInfo  `json:"user"`
+
+	MFARequired        bool       `json:"mfaRequired,omitempty"`
+	ChallengeToken     string     `json:"challengeToken,omitempty"`
+	ChallengeExpiresAt *time.Time `json:"challengeExpiresAt,omitempty"`
+	// MFAEnrollRequired 当前角色必须启用两步验证，启用前只能访问两步验证相关接口
+	MFAEnrollRequired bool `json:"mfaEnrollRequired,omitempty"`
+}
+
+// 刷新令牌请求结构
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// 刷新令牌响应结构
+type RefreshTokenResponse struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
 }
 
 type ChangePasswordRequest struct {
 	OldPassword string `json:"oldPassword" binding:"required,min=6,max=30"`
-	NewPassword string `json:"newPassword" binding:"required,min=6,max=30"`
+	NewPassword string `json:"newPassword" binding:"required,password"`
 }
 
 // 查询用户列表请求结构体
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// UserIdentity 用户关联的第三方账号，同一身份提供方中的账号只能关联一个用户
+type UserIdentity struct {
+	ID       uint      `gorm:"primaryKey" json:"id"`
+	UserID   string    `gorm:"column:userID;type:varchar(36);not null;index:idx_user_identity_userID;comment:用户唯一 ID" json:"userID"`
+	Provider string    `gorm:"column:provider;type:varchar(64);not null;uniqueIndex:idx_user_identity_subject,priority:1;comment:身份提供方名称" json:"provider"`
+	Subject  string    `gorm:"column:subject;type:varchar(255);not null;uniqueIndex:idx_user_identity_subject,priority:2;comment:身份提供方中的用户 ID" json:"subject"`
+	Email    string    `gorm:"column:email;type:varchar(255);not null;default:'';comment:关联时身份提供方返回的邮箱" json:"email"`
+	CreateAt time.Time `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间" json:"createAt"`
+}
+
+// TableName 表名
+func (UserIdentity) TableName() string { return "user_identity" }
+
+// 第三方账号响应结构
+type UserIdentityInfo struct {
+	Provider string    `json:"provider"`
+	Email    string    `json:"email"`
+	CreateAt time.Time `json:"createAt"`
+}
+
+// 查询第三方账号列表响应结构
+type ListUserIdentityResponse struct {
+	TotalCount int64              `json:"totalCount"`
+	Identities []UserIdentityInfo `json:"identities"`
+}
+
+// 第三方登录授权地址响应结构，关联账号时由前端跳转到该地址
+type OAuthAuthorizeResponse struct {
+	AuthURL string `json:"authURL"`
+	// State 由接口层写入 Cookie，回调时校验请求来自发起授权的浏览器
+	State string `json:"-"`
+}
+
+// 第三方登录回调请求结构
+type OAuthCallbackRequest struct {
+	Code  string `form:"code" binding:"required"`
+	State string `form:"state" binding:"required"`
+}
+
+// 第三方登录回调响应结构，登录时返回令牌，关联账号时只返回关联结果
+type OAuthCallbackResponse struct {
+	*UserLoginResponse
+	Provider string `json:"provider"`
+	Linked   bool   `json:"linked,omitempty"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// UserMFA 用户两步验证配置，TOTP 密钥加密保存，恢复码仅保存哈希
+type UserMFA struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        string    `gorm:"column:userID;type:varchar(36);not null;uniqueIndex:idx_user_mfa_userID;comment:用户唯一 ID" json:"userID"`
+	Secret        string    `gorm:"column:secret;type:varchar(255);not null;comment:加密后的 TOTP 密钥" json:"-"`
+	Enabled       bool      `gorm:"column:enabled;not null;default:false;comment:是否已启用，注册后验证通过才启用" json:"enabled"`
+	LastStep      int64     `gorm:"column:lastStep;not null;default:0;comment:最近一次使用的验证码时间步" json:"-"`
+	RecoveryCodes string    `gorm:"column:recoveryCodes;type:varchar(1024);not null;comment:未使用的恢复码哈希，逗号分隔" json:"-"`
+	CreateAt      time.Time `gorm:"column:createAt;not null;default:CURRENT_TIMESTAMP;comment:创建时间" json:"createAt"`
+	UpdateAt      time.Time `gorm:"column:updateAt;not null;default:CURRENT_TIMESTAMP;comment:更新时间" json:"updateAt"`
+}
+
+// TableName 表名
+func (UserMFA) TableName() string { return "user_mfa" }
+
+// 注册两步验证响应结构
+type EnrollMFAResponse struct {
+	// Secret Base32 编码的 TOTP 密钥，无法扫描二维码时手动输入
+	Secret string `json:"secret"`
+	// URL otpauth:// 格式的密钥地址
+	URL string `json:"url"`
+	// QRCode Base64 编码的二维码 PNG 图片
+	QRCode string `json:"qrCode"`
+}
+
+// 两步验证码请求结构，code 和 recoveryCode 二选一
+type MFACodeRequest struct {
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// 启用两步验证响应结构，恢复码只返回一次，同时返回通过两步验证的新令牌
+type EnableMFAResponse struct {
+	RecoveryCodes    []string  `json:"recoveryCodes"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+}
+
+// 两步验证登录请求结构
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	MFACodeRequest
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"gorm.io/gorm"
+)
+
+type APIKeyStore interface {
+	// Create 创建 API 密钥
+	Create(ctx context.Context, key *model.APIKey) error
+	// GetByKeyID 根据密钥公开 ID 获取 API 密钥
+	GetByKeyID(ctx context.Context, keyID string) (*model.APIKey, error)
+	// ListByUserID 获取用户的全部 API 密钥
+	ListByUserID(ctx context.Context, userID string) ([]*model.APIKey, error)
+	// Delete 删除用户的 API 密钥
+	Delete(ctx context.Context, userID string, id uint) error
+	// DeleteByUserID 删除用户的全部 API 密钥
+	DeleteByUserID(ctx context.Context, userID string) error
+	// UpdateLastUsed 更新 API 密钥的最近使用时间
+	UpdateLastUsed(ctx context.Context, id uint, lastUsedAt time.Time) error
+}
+
+// apiKeys 实现 APIKeyStore 接口
+type apiKeys struct {
+	ds *dataStore
+}
+
+// newAPIKeys 创建 apiKeys 实例
+func newAPIKeys(ds *dataStore) *apiKeys {
+	return &apiKeys{ds: ds}
+}
+
+// Create 创建 API 密钥
+func (a *apiKeys) Create(ctx context.Context, key *model.APIKey) error {
+	now := time.Now()
+	if key.CreateAt.IsZero() {
+		key.CreateAt = now
+	}
+	if key.UpdateAt.IsZero() {
+		key.UpdateAt = now
+	}
+
+	if err := a.ds.DB(ctx).Create(key).Error; err != nil {
+		return apiKeyError(err)
+	}
+	return nil
+}
+
+// GetByKeyID 根据密钥公开 ID 获取 API 密钥
+func (a *apiKeys) GetByKeyID(ctx context.Context, keyID string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := a.ds.DB(ctx).Where(map[string]interface{}{"keyID": keyID}).First(&key).Error; err != nil {
+		return nil, apiKeyError(err)
+	}
+
+	return &key, nil
+}
+
+// ListByUserID 获取用户的全部 API 密钥
+func (a *apiKeys) ListByUserID(ctx context.Context, userID string) ([]*model.APIKey, error) {
+	var list []*model.APIKey
+	if err := a.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).Order("id DESC").Find(&list).Error; err != nil {
+		return nil, dbError(err)
+	}
+
+	return list, nil
+}
+
+// Delete 删除用户的 API 密钥
+func (a *apiKeys) Delete(ctx context.Context, userID string, id uint) error {
+	result := a.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).Delete(&model.APIKey{}, id)
+	if result.Error != nil {
+		return apiKeyError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errno.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// DeleteByUserID 删除用户的全部 API 密钥
+func (a *apiKeys) DeleteByUserID(ctx context.Context, userID string) error {
+	return dbError(a.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).Delete(&model.APIKey{}).Error)
+}
+
+// UpdateLastUsed 更新 API 密钥的最近使用时间
+func (a *apiKeys) UpdateLastUsed(ctx context.Context, id uint, lastUsedAt time.Time) error {
+	return dbError(a.ds.DB(ctx).Model(&model.APIKey{ID: id}).UpdateColumn("lastUsedAt", lastUsedAt).Error)
+}
+
+// apiKeyError 将 gorm 错误转换为 API 密钥相关的业务错误码
+func apiKeyError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errno.ErrAPIKeyNotFound
+	default:
+		return dbError(err)
+	}
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// apiKeys 实现 store.APIKeyStore 接口
+type apiKeys struct {
+	ds *dataStore
+}
+
+var _ store.APIKeyStore = (*apiKeys)(nil)
+
+// Create 创建 API 密钥
+func (a *apiKeys) Create(ctx context.Context, key *model.APIKey) error {
+	defer a.ds.lock(ctx)()
+
+	for _, existing := range a.ds.apiKeys {
+		if existing.KeyID == key.KeyID {
+			return errors.New("API 密钥 ID 重复")
+		}
+	}
+
+	now := time.Now()
+	if key.CreateAt.IsZero() {
+		key.CreateAt = now
+	}
+	if key.UpdateAt.IsZero() {
+		key.UpdateAt = now
+	}
+	a.ds.apiKeyID++
+	key.ID = a.ds.apiKeyID
+
+	saved := *key
+	a.ds.apiKeys[key.ID] = &saved
+	return nil
+}
+
+// GetByKeyID 根据密钥公开 ID 获取 API 密钥
+func (a *apiKeys) GetByKeyID(ctx context.Context, keyID string) (*model.APIKey, error) {
+	a.ds.mu.RLock()
+	defer a.ds.mu.RUnlock()
+
+	for _, key := range a.ds.apiKeys {
+		if key.KeyID == keyID {
+			found := *key
+			return &found, nil
+		}
+	}
+
+	return nil, errno.ErrAPIKeyNotFound
+}
+
+// ListByUserID 获取用户的全部 API 密钥
+func (a *apiKeys) ListByUserID(ctx context.Context, userID string) ([]*model.APIKey, error) {
+	a.ds.mu.RLock()
+	defer a.ds.mu.RUnlock()
+
+	list := make([]*model.APIKey, 0)
+	for _, key := range a.ds.apiKeys {
+		if key.UserID == userID {
+			found := *key
+			list = append(list, &found)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+
+	return list, nil
+}
+
+// Delete 删除用户的 API 密钥
+func (a *apiKeys) Delete(ctx context.Context, userID string, id uint) error {
+	defer a.ds.lock(ctx)()
+
+	key, ok := a.ds.apiKeys[id]
+	if !ok || key.UserID != userID {
+		return errno.ErrAPIKeyNotFound
+	}
+
+	delete(a.ds.apiKeys, id)
+	return nil
+}
+
+// DeleteByUserID 删除用户的全部 API 密钥
+func (a *apiKeys) DeleteByUserID(ctx context.Context, userID string) error {
+	defer a.ds.lock(ctx)()
+
+	for id, key := range a.ds.apiKeys {
+		if key.UserID == userID {
+			delete(a.ds.apiKeys, id)
+		}
+	}
+	return nil
+}
+
+// UpdateLastUsed 更新 API 密钥的最近使用时间
+func (a *apiKeys) UpdateLastUsed(ctx context.Context, id uint, lastUsedAt time.Time) error {
+	defer a.ds.lock(ctx)()
+
+	key, ok := a.ds.apiKeys[id]
+	if !ok {
+		return nil
+	}
+
+	// 记录在修改时整体替换，保证事务快照不受影响
+	updated := *key
+	updated.LastUsedAt = &lastUsedAt
+	a.ds.apiKeys[id] = &updated
+	return nil
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// passwordResets 实现 store.PasswordResetStore 接口
+type passwordResets struct {
+	ds *dataStore
+}
+
+var _ store.PasswordResetStore = (*passwordResets)(nil)
+
+// Create 创建密码重置令牌
+func (p *passwordResets) Create(ctx context.Context, reset *model.PasswordReset) error {
+	defer p.ds.lock(ctx)()
+
+	for _, existing := range p.ds.passwordResets {
+		if existing.TokenHash == reset.TokenHash {
+			return errors.New("密码重置令牌重复")
+		}
+	}
+
+	if reset.CreateAt.IsZero() {
+		reset.CreateAt = time.Now()
+	}
+	p.ds.passwordResetID++
+	reset.ID = p.ds.passwordResetID
+
+	saved := *reset
+	p.ds.passwordResets[reset.ID] = &saved
+	return nil
+}
+
+// GetByTokenHash 根据令牌哈希获取密码重置令牌
+func (p *passwordResets) GetByTokenHash(ctx context.Context, tokenHash string) (*model.PasswordReset, error) {
+	p.ds.mu.RLock()
+	defer p.ds.mu.RUnlock()
+
+	for _, reset := range p.ds.passwordResets {
+		if reset.TokenHash == tokenHash {
+			found := *reset
+			return &found, nil
+		}
+	}
+
+	return nil, errno.ErrResetTokenInvalid
+}
+
+// MarkUsed 将未使用的密码重置令牌标记为已使用
+func (p *passwordResets) MarkUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	defer p.ds.lock(ctx)()
+
+	reset, ok := p.ds.passwordResets[id]
+	if !ok || reset.UsedAt != nil {
+		return errno.ErrResetTokenInvalid
+	}
+
+	// 记录在修改时整体替换，保证事务快照不受影响
+	updated := *reset
+	updated.UsedAt = &usedAt
+	p.ds.passwordResets[id] = &updated
+	return nil
+}
+
+// DeleteByUserID 删除用户的全部密码重置令牌
+func (p *passwordResets) DeleteByUserID(ctx context.Context, userID string) error {
+	defer p.ds.lock(ctx)()
+
+	for id, reset := range p.ds.passwordResets {
+		if reset.UserID == userID {
+			delete(p.ds.passwordResets, id)
+		}
+	}
+	return nil
+}
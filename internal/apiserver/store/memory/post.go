@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// posts 实现 store.PostStore 接口
+type posts struct {
+	ds *dataStore
+}
+
+var _ store.PostStore = (*posts)(nil)
+
+// Create 创建帖子
+func (p *posts) Create(ctx context.Context, post *model.Post) error {
+	defer p.ds.lock(ctx)()
+
+	if p.conflict(post) {
+		return errno.ErrPostAlreadyExist
+	}
+
+	now := time.Now()
+	if post.CreateAt.IsZero() {
+		post.CreateAt = now
+	}
+	if post.UpdateAt.IsZero() {
+		post.UpdateAt = now
+	}
+	p.ds.postID++
+	post.ID = p.ds.postID
+
+	saved := *post
+	p.ds.posts[post.ID] = &saved
+	return nil
+}
+
+// GetByID 根据 ID 获取帖子
+func (p *posts) GetByID(ctx context.Context, id uint) (*model.Post, error) {
+	p.ds.mu.RLock()
+	defer p.ds.mu.RUnlock()
+
+	post, ok := p.ds.posts[id]
+	if !ok {
+		return nil, errno.ErrPostNotFound
+	}
+
+	found := *post
+	return &found, nil
+}
+
+// Update 更新帖子
+func (p *posts) Update(ctx context.Context, post *model.Post) error {
+	defer p.ds.lock(ctx)()
+
+	old, ok := p.ds.posts[post.ID]
+	if !ok {
+		return errno.ErrPostNotFound
+	}
+	if p.conflict(post) {
+		return errno.ErrPostAlreadyExist
+	}
+
+	post.CreateAt = old.CreateAt
+	post.UpdateAt = time.Now()
+
+	saved := *post
+	p.ds.posts[post.ID] = &saved
+	return nil
+}
+
+// Delete 删除帖子
+func (p *posts) Delete(ctx context.Context, id uint) error {
+	defer p.ds.lock(ctx)()
+
+	if _, ok := p.ds.posts[id]; !ok {
+		return errno.ErrPostNotFound
+	}
+
+	delete(p.ds.posts, id)
+	return nil
+}
+
+// List 获取帖子列表
+func (p *posts) List(ctx context.Context, page, pageSize int) ([]*model.Post, int64, error) {
+	return p.list(func(*model.Post) bool { return true }, page, pageSize)
+}
+
+// GetByUserID 根据用户 ID 获取帖子列表
+func (p *posts) GetByUserID(ctx context.Context, userID string, page, pageSize int) ([]*model.Post, int64, error) {
+	return p.list(func(post *model.Post) bool { return post.UserID == userID }, page, pageSize)
+}
+
+// GetByPostID 根据帖子 ID 获取帖子
+func (p *posts) GetByPostID(ctx context.Context, postID string) (*model.Post, error) {
+	p.ds.mu.RLock()
+	defer p.ds.mu.RUnlock()
+
+	for _, post := range p.ds.posts {
+		if post.PostID == postID {
+			found := *post
+			return &found, nil
+		}
+	}
+
+	return nil, errno.ErrPostNotFound
+}
+
+// DeleteByUserID 删除指定用户的全部帖子
+func (p *posts) DeleteByUserID(ctx context.Context, userID string) error {
+	defer p.ds.lock(ctx)()
+
+	for id, post := range p.ds.posts {
+		if post.UserID == userID {
+			delete(p.ds.posts, id)
+		}
+	}
+	return nil
+}
+
+// list 筛选满足条件的帖子并分页，同时返回满足条件的帖子总数
+func (p *posts) list(match func(*model.Post) bool, page, pageSize int) ([]*model.Post, int64, error) {
+	p.ds.mu.RLock()
+	defer p.ds.mu.RUnlock()
+
+	all := make([]*model.Post, 0, len(p.ds.posts))
+	for _, post := range p.ds.posts {
+		if match(post) {
+			found := *post
+			all = append(all, &found)
+		}
+	}
+
+	list := paginate(all, func(post *model.Post) uint { return post.ID }, page, pageSize)
+	return list, int64(len(all)), nil
+}
+
+// conflict 判断 postID 是否已被其他帖子占用，调用方需持有锁
+func (p *posts) conflict(post *model.Post) bool {
+	for id, existing := range p.ds.posts {
+		if id != post.ID && existing.PostID == post.PostID {
+			return true
+		}
+	}
+
+	return false
+}
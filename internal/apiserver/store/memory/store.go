@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+)
+
+// transactionKey 用于在 context 中标记当前处于哪个存储的事务中
+type transactionKey struct{}
+
+// dataStore 基于内存实现 store.IStore 接口，适用于单元测试和演示模式
+type dataStore struct {
+	// txMu 保证同一时间只有一个事务，事务外的写操作同样需要获取该锁，
+	// 避免事务回滚时覆盖事务外的修改
+	txMu sync.Mutex
+	// mu 保护下面所有的数据
+	mu sync.RWMutex
+
+	users  map[uint]*model.User
+	userID uint
+
+	posts  map[uint]*model.Post
+	postID uint
+
+	apiKeys  map[uint]*model.APIKey
+	apiKeyID uint
+
+	passwordResets  map[uint]*model.PasswordReset
+	passwordResetID uint
+
+	userMFAs  map[uint]*model.UserMFA
+	userMFAID uint
+
+	userIdentities map[uint]*model.UserIdentity
+	userIdentityID uint
+}
+
+// dataStore 实现 IStore 接口
+var _ store.IStore = (*dataStore)(nil)
+
+// NewStore 创建内存存储层工厂，每次调用都会返回一个全新的空存储
+func NewStore() store.IStore {
+	return &dataStore{
+		users:   make(map[uint]*model.User),
+		posts:   make(map[uint]*model.Post),
+		apiKeys: make(map[uint]*model.APIKey),
+
+		passwordResets: make(map[uint]*model.PasswordReset),
+		userMFAs:       make(map[uint]*model.UserMFA),
+		userIdentities: make(map[uint]*model.UserIdentity),
+	}
+}
+
+// User() UserStore
+func (ds *dataStore) User() store.UserStore {
+	return &users{ds: ds}
+}
+
+// Post() PostStore
+func (ds *dataStore) Post() store.PostStore {
+	return &posts{ds: ds}
+}
+
+// APIKey() APIKeyStore
+func (ds *dataStore) APIKey() store.APIKeyStore {
+	return &apiKeys{ds: ds}
+}
+
+// PasswordReset() PasswordResetStore
+func (ds *dataStore) PasswordReset() store.PasswordResetStore {
+	return &passwordResets{ds: ds}
+}
+
+// UserMFA() UserMFAStore
+func (ds *dataStore) UserMFA() store.UserMFAStore {
+	return &userMFAs{ds: ds}
+}
+
+// UserIdentity() UserIdentityStore
+func (ds *dataStore) UserIdentity() store.UserIdentityStore {
+	return &userIdentities{ds: ds}
+}
+
+// TX 在事务中执行 fn。事务之间串行执行，fn 返回错误或发生 panic 时恢复到事务开始前的数据，
+// 嵌套调用时只回滚内层的修改。fn 中必须使用传入的 ctx 调用存储方法，否则会发生死锁
+func (ds *dataStore) TX(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if !ds.inTX(ctx) {
+		ds.txMu.Lock()
+		defer ds.txMu.Unlock()
+		ctx = context.WithValue(ctx, transactionKey{}, ds)
+	}
+
+	snap := ds.snapshot()
+	defer func() {
+		if p := recover(); p != nil {
+			ds.restore(snap)
+			panic(p)
+		}
+		if err != nil {
+			ds.restore(snap)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// Close 内存存储无需释放资源
+func (ds *dataStore) Close() error {
+	return nil
+}
+
+// inTX 判断 ctx 是否处于当前存储的事务中
+func (ds *dataStore) inTX(ctx context.Context) bool {
+	owner, _ := ctx.Value(transactionKey{}).(*dataStore)
+	return owner == ds
+}
+
+// lock 获取写锁，事务外的写操作需要等待正在执行的事务结束
+func (ds *dataStore) lock(ctx context.Context) func() {
+	if ds.inTX(ctx) {
+		ds.mu.Lock()
+		return ds.mu.Unlock
+	}
+
+	ds.txMu.Lock()
+	ds.mu.Lock()
+	return func() {
+		ds.mu.Unlock()
+		ds.txMu.Unlock()
+	}
+}
+
+// snapshot 保存当前数据的快照。存储中的记录在修改时总是整体替换，因此浅拷贝即可
+type snapshot struct {
+	users  map[uint]*model.User
+	userID uint
+	posts  map[uint]*model.Post
+	postID uint
+
+	apiKeys  map[uint]*model.APIKey
+	apiKeyID uint
+
+	passwordResets  map[uint]*model.PasswordReset
+	passwordResetID uint
+
+	userMFAs  map[uint]*model.UserMFA
+	userMFAID uint
+
+	userIdentities map[uint]*model.UserIdentity
+	userIdentityID uint
+}
+
+func (ds *dataStore) snapshot() *snapshot {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	snap := &snapshot{
+		users:  make(map[uint]*model.User, len(ds.users)),
+		userID: ds.userID,
+		posts:  make(map[uint]*model.Post, len(ds.posts)),
+		postID: ds.postID,
+
+		apiKeys:  make(map[uint]*model.APIKey, len(ds.apiKeys)),
+		apiKeyID: ds.apiKeyID,
+
+		passwordResets:  make(map[uint]*model.PasswordReset, len(ds.passwordResets)),
+		passwordResetID: ds.passwordResetID,
+
+		userMFAs:  make(map[uint]*model.UserMFA, len(ds.userMFAs)),
+		userMFAID: ds.userMFAID,
+
+		userIdentities: make(map[uint]*model.UserIdentity, len(ds.userIdentities)),
+		userIdentityID: ds.userIdentityID,
+	}
+	for id, user := range ds.users {
+		snap.users[id] = user
+	}
+	for id, post := range ds.posts {
+		snap.posts[id] = post
+	}
+	for id, key := range ds.apiKeys {
+		snap.apiKeys[id] = key
+	}
+	for id, reset := range ds.passwordResets {
+		snap.passwordResets[id] = reset
+	}
+	for id, mfa := range ds.userMFAs {
+		snap.userMFAs[id] = mfa
+	}
+	for id, identity := range ds.userIdentities {
+		snap.userIdentities[id] = identity
+	}
+	return snap
+}
+
+// restore 恢复到快照时的数据
+func (ds *dataStore) restore(snap *snapshot) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	ds.users, ds.userID = snap.users, snap.userID
+	ds.posts, ds.postID = snap.posts, snap.postID
+	ds.apiKeys, ds.apiKeyID = snap.apiKeys, snap.apiKeyID
+	ds.passwordResets, ds.passwordResetID = snap.passwordResets, snap.passwordResetID
+	ds.userMFAs, ds.userMFAID = snap.userMFAs, snap.userMFAID
+	ds.userIdentities, ds.userIdentityID = snap.userIdentities, snap.userIdentityID
+}
+
+// paginate 按照 ID 倒序排序后返回指定页的数据，分页语义与 GORM 存储保持一致
+func paginate[T any](items []*T, id func(*T) uint, page, pageSize int) []*T {
+	sort.Slice(items, func(i, j int) bool { return id(items[i]) > id(items[j]) })
+
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * pageSize
+	if pageSize <= 0 || start >= len(items) {
+		return []*T{}
+	}
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[start:end]
+}
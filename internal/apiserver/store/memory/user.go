@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// users 实现 store.UserStore 接口
+type users struct {
+	ds *dataStore
+}
+
+var _ store.UserStore = (*users)(nil)
+
+// Create 创建用户
+func (u *users) Create(ctx context.Context, user *model.User) error {
+	defer u.ds.lock(ctx)()
+
+	if u.conflict(user) {
+		return errno.ErrUserAlreadyExist
+	}
+
+	now := time.Now()
+	if user.CreateAt.IsZero() {
+		user.CreateAt = now
+	}
+	if user.UpdateAt.IsZero() {
+		user.UpdateAt = now
+	}
+	u.ds.userID++
+	user.ID = u.ds.userID
+
+	saved := *user
+	u.ds.users[user.ID] = &saved
+	return nil
+}
+
+// GetByID 根据 ID 获取用户
+func (u *users) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	u.ds.mu.RLock()
+	defer u.ds.mu.RUnlock()
+
+	user, ok := u.ds.users[id]
+	if !ok {
+		return nil, errno.ErrUserNotFound
+	}
+
+	found := *user
+	return &found, nil
+}
+
+// GetByUsername 根据用户名获取用户
+func (u *users) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	u.ds.mu.RLock()
+	defer u.ds.mu.RUnlock()
+
+	for _, user := range u.ds.users {
+		if user.Username == username {
+			found := *user
+			return &found, nil
+		}
+	}
+
+	return nil, errno.ErrUserNotFound
+}
+
+// GetByUserID 根据用户唯一 ID 获取用户
+func (u *users) GetByUserID(ctx context.Context, userID string) (*model.User, error) {
+	u.ds.mu.RLock()
+	defer u.ds.mu.RUnlock()
+
+	for _, user := range u.ds.users {
+		if user.UserID == userID {
+			found := *user
+			return &found, nil
+		}
+	}
+
+	return nil, errno.ErrUserNotFound
+}
+
+// GetByEmail 根据邮箱获取用户
+func (u *users) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	u.ds.mu.RLock()
+	defer u.ds.mu.RUnlock()
+
+	var found *model.User
+	for _, user := range u.ds.users {
+		if user.Email == email && (found == nil || user.ID < found.ID) {
+			found = user
+		}
+	}
+	if found == nil {
+		return nil, errno.ErrUserNotFound
+	}
+
+	user := *found
+	return &user, nil
+}
+
+// Update 更新用户
+func (u *users) Update(ctx context.Context, user *model.User) error {
+	defer u.ds.lock(ctx)()
+
+	old, ok := u.ds.users[user.ID]
+	if !ok {
+		return errno.ErrUserNotFound
+	}
+	if u.conflict(user) {
+		return errno.ErrUserAlreadyExist
+	}
+
+	user.CreateAt = old.CreateAt
+	user.UpdateAt = time.Now()
+
+	saved := *user
+	u.ds.users[user.ID] = &saved
+	return nil
+}
+
+// Delete 删除用户
+func (u *users) Delete(ctx context.Context, id uint) error {
+	defer u.ds.lock(ctx)()
+
+	if _, ok := u.ds.users[id]; !ok {
+		return errno.ErrUserNotFound
+	}
+
+	delete(u.ds.users, id)
+	return nil
+}
+
+// List 获取用户列表
+func (u *users) List(ctx context.Context, page, pageSize int) ([]*model.User, int64, error) {
+	u.ds.mu.RLock()
+	defer u.ds.mu.RUnlock()
+
+	all := make([]*model.User, 0, len(u.ds.users))
+	for _, user := range u.ds.users {
+		found := *user
+		all = append(all, &found)
+	}
+
+	list := paginate(all, func(user *model.User) uint { return user.ID }, page, pageSize)
+	return list, int64(len(all)), nil
+}
+
+// conflict 判断 userID、用户名或手机号是否已被其他用户占用，调用方需持有锁
+func (u *users) conflict(user *model.User) bool {
+	for id, existing := range u.ds.users {
+		if id == user.ID {
+			continue
+		}
+		if existing.UserID == user.UserID || existing.Username == user.Username {
+			return true
+		}
+		// 与数据库唯一索引一致，未设置的手机号不会冲突
+		if existing.Phone != nil && user.Phone != nil && *existing.Phone == *user.Phone {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// userIdentities 实现 store.UserIdentityStore 接口
+type userIdentities struct {
+	ds *dataStore
+}
+
+var _ store.UserIdentityStore = (*userIdentities)(nil)
+
+// Create 关联第三方账号
+func (i *userIdentities) Create(ctx context.Context, identity *model.UserIdentity) error {
+	defer i.ds.lock(ctx)()
+
+	for _, existing := range i.ds.userIdentities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return errno.ErrIdentityAlreadyLinked
+		}
+	}
+
+	if identity.CreateAt.IsZero() {
+		identity.CreateAt = time.Now()
+	}
+	i.ds.userIdentityID++
+	identity.ID = i.ds.userIdentityID
+
+	saved := *identity
+	i.ds.userIdentities[identity.ID] = &saved
+	return nil
+}
+
+// Get 获取关联记录
+func (i *userIdentities) Get(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	i.ds.mu.RLock()
+	defer i.ds.mu.RUnlock()
+
+	for _, identity := range i.ds.userIdentities {
+		if identity.Provider == provider && identity.Subject == subject {
+			found := *identity
+			return &found, nil
+		}
+	}
+
+	return nil, errno.ErrIdentityNotLinked
+}
+
+// ListByUserID 获取用户关联的全部第三方账号
+func (i *userIdentities) ListByUserID(ctx context.Context, userID string) ([]*model.UserIdentity, error) {
+	i.ds.mu.RLock()
+	defer i.ds.mu.RUnlock()
+
+	var identities []*model.UserIdentity
+	for _, identity := range i.ds.userIdentities {
+		if identity.UserID == userID {
+			found := *identity
+			identities = append(identities, &found)
+		}
+	}
+	// 与 GORM 存储一致，按关联顺序返回
+	sort.Slice(identities, func(a, b int) bool { return identities[a].ID < identities[b].ID })
+	return identities, nil
+}
+
+// Delete 解除关联
+func (i *userIdentities) Delete(ctx context.Context, userID, provider string) error {
+	defer i.ds.lock(ctx)()
+
+	for id, identity := range i.ds.userIdentities {
+		if identity.UserID == userID && identity.Provider == provider {
+			delete(i.ds.userIdentities, id)
+			return nil
+		}
+	}
+	return errno.ErrIdentityNotLinked
+}
+
+// DeleteByUserID 删除用户关联的全部第三方账号
+func (i *userIdentities) DeleteByUserID(ctx context.Context, userID string) error {
+	defer i.ds.lock(ctx)()
+
+	for id, identity := range i.ds.userIdentities {
+		if identity.UserID == userID {
+			delete(i.ds.userIdentities, id)
+		}
+	}
+	return nil
+}
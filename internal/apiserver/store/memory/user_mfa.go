@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// userMFAs 实现 store.UserMFAStore 接口
+type userMFAs struct {
+	ds *dataStore
+}
+
+var _ store.UserMFAStore = (*userMFAs)(nil)
+
+// Create 创建两步验证配置
+func (m *userMFAs) Create(ctx context.Context, mfa *model.UserMFA) error {
+	defer m.ds.lock(ctx)()
+
+	for _, existing := range m.ds.userMFAs {
+		if existing.UserID == mfa.UserID {
+			return errors.New("两步验证配置已存在")
+		}
+	}
+
+	now := time.Now()
+	if mfa.CreateAt.IsZero() {
+		mfa.CreateAt = now
+	}
+	if mfa.UpdateAt.IsZero() {
+		mfa.UpdateAt = now
+	}
+	m.ds.userMFAID++
+	mfa.ID = m.ds.userMFAID
+
+	saved := *mfa
+	m.ds.userMFAs[mfa.ID] = &saved
+	return nil
+}
+
+// Get 获取用户的两步验证配置
+func (m *userMFAs) Get(ctx context.Context, userID string) (*model.UserMFA, error) {
+	m.ds.mu.RLock()
+	defer m.ds.mu.RUnlock()
+
+	for _, mfa := range m.ds.userMFAs {
+		if mfa.UserID == userID {
+			found := *mfa
+			return &found, nil
+		}
+	}
+
+	return nil, errno.ErrMFANotEnabled
+}
+
+// Update 更新两步验证配置
+func (m *userMFAs) Update(ctx context.Context, mfa *model.UserMFA) error {
+	defer m.ds.lock(ctx)()
+
+	existing, ok := m.ds.userMFAs[mfa.ID]
+	if !ok {
+		return errno.ErrMFANotEnabled
+	}
+
+	mfa.CreateAt = existing.CreateAt
+	mfa.UpdateAt = time.Now()
+	saved := *mfa
+	m.ds.userMFAs[mfa.ID] = &saved
+	return nil
+}
+
+// UseStep 记录已使用的验证码时间步
+func (m *userMFAs) UseStep(ctx context.Context, id uint, step int64) error {
+	defer m.ds.lock(ctx)()
+
+	mfa, ok := m.ds.userMFAs[id]
+	if !ok || mfa.LastStep >= step {
+		return errno.ErrMFACodeInvalid
+	}
+
+	// 记录在修改时整体替换，保证事务快照不受影响
+	updated := *mfa
+	updated.LastStep = step
+	m.ds.userMFAs[id] = &updated
+	return nil
+}
+
+// UseRecoveryCode 将未使用的恢复码从 old 替换为 new
+func (m *userMFAs) UseRecoveryCode(ctx context.Context, id uint, old, new string) error {
+	defer m.ds.lock(ctx)()
+
+	mfa, ok := m.ds.userMFAs[id]
+	if !ok || mfa.RecoveryCodes != old {
+		return errno.ErrMFACodeInvalid
+	}
+
+	updated := *mfa
+	updated.RecoveryCodes = new
+	m.ds.userMFAs[id] = &updated
+	return nil
+}
+
+// Delete 删除用户的两步验证配置
+func (m *userMFAs) Delete(ctx context.Context, userID string) error {
+	defer m.ds.lock(ctx)()
+
+	for id, mfa := range m.ds.userMFAs {
+		if mfa.UserID == userID {
+			delete(m.ds.userMFAs, id)
+		}
+	}
+	return nil
+}
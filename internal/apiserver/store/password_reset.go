@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"gorm.io/gorm"
+)
+
+type PasswordResetStore interface {
+	// Create 创建密码重置令牌
+	Create(ctx context.Context, reset *model.PasswordReset) error
+	// GetByTokenHash 根据令牌哈希获取密码重置令牌
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.PasswordReset, error)
+	// MarkUsed 将未使用的密码重置令牌标记为已使用，令牌已被使用时返回 errno.ErrResetTokenInvalid
+	MarkUsed(ctx context.Context, id uint, usedAt time.Time) error
+	// DeleteByUserID 删除用户的全部密码重置令牌
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// passwordResets 实现 PasswordResetStore 接口
+type passwordResets struct {
+	ds *dataStore
+}
+
+// newPasswordResets 创建 passwordResets 实例
+func newPasswordResets(ds *dataStore) *passwordResets {
+	return &passwordResets{ds: ds}
+}
+
+// Create 创建密码重置令牌
+func (p *passwordResets) Create(ctx context.Context, reset *model.PasswordReset) error {
+	if reset.CreateAt.IsZero() {
+		reset.CreateAt = time.Now()
+	}
+
+	return dbError(p.ds.DB(ctx).Create(reset).Error)
+}
+
+// GetByTokenHash 根据令牌哈希获取密码重置令牌
+func (p *passwordResets) GetByTokenHash(ctx context.Context, tokenHash string) (*model.PasswordReset, error) {
+	var reset model.PasswordReset
+	if err := p.ds.DB(ctx).Where(map[string]interface{}{"tokenHash": tokenHash}).First(&reset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errno.ErrResetTokenInvalid
+		}
+		return nil, dbError(err)
+	}
+
+	return &reset, nil
+}
+
+// MarkUsed 将未使用的密码重置令牌标记为已使用，通过条件更新保证令牌只能使用一次
+func (p *passwordResets) MarkUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	result := p.ds.DB(ctx).Model(&model.PasswordReset{}).
+		Where(map[string]interface{}{"id": id, "usedAt": nil}).
+		UpdateColumn("usedAt", usedAt)
+	if result.Error != nil {
+		return dbError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errno.ErrResetTokenInvalid
+	}
+
+	return nil
+}
+
+// DeleteByUserID 删除用户的全部密码重置令牌
+func (p *passwordResets) DeleteByUserID(ctx context.Context, userID string) error {
+	return dbError(p.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).Delete(&model.PasswordReset{}).Error)
+}
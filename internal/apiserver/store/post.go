@@ -2,8 +2,11 @@ package store
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
 	"gorm.io/gorm"
 )
 
@@ -16,55 +19,137 @@ type PostStore interface {
 	Update(ctx context.Context, post *model.Post) error
 	// Delete 删除帖子
 	Delete(ctx context.Context, id uint) error
-	// List 获取帖子列表
-	List(ctx context.Context, page, pageSize int) ([]*model.Post, error)
-	// GetByUserID 根据用户 ID 获取帖子列表
-	GetByUserID(ctx context.Context, userID string, page, pageSize int) ([]*model.Post, error)
+	// List 获取帖子列表，同时返回帖子总数
+	List(ctx context.Context, page, pageSize int) ([]*model.Post, int64, error)
+	// GetByUserID 根据用户 ID 获取帖子列表，同时返回该用户的帖子总数
+	GetByUserID(ctx context.Context, userID string, page, pageSize int) ([]*model.Post, int64, error)
 	// GetByPostID 根据帖子 ID 获取帖子
 	GetByPostID(ctx context.Context, postID string) (*model.Post, error)
+	// DeleteByUserID 删除指定用户的全部帖子
+	DeleteByUserID(ctx context.Context, userID string) error
 }
 
 // postStore 实现Factory 的全部接口
 type posts struct {
-	db *gorm.DB
+	ds *dataStore
 }
 
-// newPostStore 创建 postStore 实例
-func NewPosts(db *gorm.DB) PostStore {
-	return &posts{db: db}
+// newPosts 创建 posts 实例
+func newPosts(ds *dataStore) *posts {
+	return &posts{ds: ds}
 }
 
 // Create 创建帖子
 func (p *posts) Create(ctx context.Context, post *model.Post) error {
+	now := time.Now()
+	if post.CreateAt.IsZero() {
+		post.CreateAt = now
+	}
+	if post.UpdateAt.IsZero() {
+		post.UpdateAt = now
+	}
+
+	if err := p.ds.DB(ctx).Create(post).Error; err != nil {
+		return postError(err)
+	}
 	return nil
 }
 
 // GetByID 根据 ID 获取帖子
 func (p *posts) GetByID(ctx context.Context, id uint) (*model.Post, error) {
-	return nil, nil
+	var post model.Post
+	if err := p.ds.DB(ctx).First(&post, id).Error; err != nil {
+		return nil, postError(err)
+	}
+
+	return &post, nil
 }
 
 // Update 更新帖子
 func (p *posts) Update(ctx context.Context, post *model.Post) error {
+	post.UpdateAt = time.Now()
+
+	// Select("*") 保证零值字段同样会被更新
+	result := p.ds.DB(ctx).Model(post).Select("*").Omit("id", "createAt").Updates(post)
+	if result.Error != nil {
+		return postError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// MySQL 在数据未发生变化时返回 0，需要再次确认记录是否存在
+		if _, err := p.GetByID(ctx, post.ID); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // Delete 删除帖子
 func (p *posts) Delete(ctx context.Context, id uint) error {
+	result := p.ds.DB(ctx).Delete(&model.Post{}, id)
+	if result.Error != nil {
+		return postError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errno.ErrPostNotFound
+	}
+
 	return nil
 }
 
 // List 获取帖子列表
-func (p *posts) List(ctx context.Context, page, pageSize int) ([]*model.Post, error) {
-	return nil, nil
+func (p *posts) List(ctx context.Context, page, pageSize int) ([]*model.Post, int64, error) {
+	return p.list(p.ds.DB(ctx).Model(&model.Post{}), page, pageSize)
 }
 
 // GetByUserID 根据用户 ID 获取帖子列表
-func (p *posts) GetByUserID(ctx context.Context, userID string, page, pageSize int) ([]*model.Post, error) {
-	return nil, nil
+func (p *posts) GetByUserID(ctx context.Context, userID string, page, pageSize int) ([]*model.Post, int64, error) {
+	db := p.ds.DB(ctx).Model(&model.Post{}).Where(map[string]interface{}{"userID": userID})
+	return p.list(db, page, pageSize)
 }
 
 // GetByPostID 根据帖子 ID 获取帖子
 func (p *posts) GetByPostID(ctx context.Context, postID string) (*model.Post, error) {
-	return nil, nil
+	var post model.Post
+	if err := p.ds.DB(ctx).Where(map[string]interface{}{"postID": postID}).First(&post).Error; err != nil {
+		return nil, postError(err)
+	}
+
+	return &post, nil
+}
+
+// DeleteByUserID 删除指定用户的全部帖子
+func (p *posts) DeleteByUserID(ctx context.Context, userID string) error {
+	return dbError(p.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).Delete(&model.Post{}).Error)
+}
+
+// list 按照 ID 倒序分页查询帖子，并返回满足条件的帖子总数
+func (p *posts) list(db *gorm.DB, page, pageSize int) ([]*model.Post, int64, error) {
+	var (
+		total int64
+		list  []*model.Post
+	)
+
+	// 新建会话，保证 Count 与 Find 使用相互独立的查询条件
+	db = db.Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, dbError(err)
+	}
+	if err := db.Order("id DESC").Offset(offset(page, pageSize)).Limit(pageSize).Find(&list).Error; err != nil {
+		return nil, 0, dbError(err)
+	}
+
+	return list, total, nil
+}
+
+// postError 将 gorm 错误转换为帖子相关的业务错误码
+func postError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errno.ErrPostNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errno.ErrPostAlreadyExist
+	default:
+		return dbError(err)
+	}
 }
@@ -1,16 +1,14 @@
 package store
 
 import (
-	"sync"
+	"context"
 
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
 	"gorm.io/gorm"
 )
 
 var (
-
-	// 确保store 是实例化一次
-	once sync.Once
-	// 全局变量
+	// S 全局变量，保存最近一次创建的存储层工厂
 	S *dataStore
 )
 
@@ -21,12 +19,27 @@ type IStore interface {
 
 	Post() PostStore
 
+	APIKey() APIKeyStore
+
+	PasswordReset() PasswordResetStore
+
+	UserMFA() UserMFAStore
+
+	UserIdentity() UserIdentityStore
+
+	// TX 在同一个事务中执行 fn，fn 中使用传入的 ctx 调用的存储方法都会加入该事务。
+	// fn 返回错误或发生 panic 时回滚事务，嵌套调用时使用保存点只回滚内层的修改
+	TX(ctx context.Context, fn func(ctx context.Context) error) error
+
 	Close() error
 }
 
+// transactionKey 用于在 context 中保存事务对象
+type transactionKey struct{}
+
 // dataStore 实现 IStore 接口
 type dataStore struct {
-	db *gorm.DB
+	core *gorm.DB
 }
 
 // dataStore 实现 IStore 接口
@@ -34,29 +47,78 @@ var _ IStore = (*dataStore)(nil)
 
 // NewStore 创建存储层工厂
 func NewStore(db *gorm.DB) IStore {
-	// 确保store 是实例化一次
-	once.Do(func() {
-		S = &dataStore{db}
-	})
+	S = &dataStore{core: db}
 	return S
 }
 
+// DB 返回 ctx 中的事务对象，不在事务中时返回普通的数据库对象
+func (ds *dataStore) DB(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return ds.core.WithContext(ctx)
+}
+
+// TX 在事务中执行 fn
+func (ds *dataStore) TX(ctx context.Context, fn func(ctx context.Context) error) error {
+	// 已经处于事务中时，gorm 会为嵌套事务创建保存点
+	return ds.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, transactionKey{}, tx))
+	})
+}
+
 // User() UserStore
 func (ds *dataStore) User() UserStore {
-	return NewUsers(ds.db)
+	return newUsers(ds)
 }
 
 // Post() PostStore
 
 func (ds *dataStore) Post() PostStore {
-	return NewPosts(ds.db)
+	return newPosts(ds)
+}
+
+// APIKey() APIKeyStore
+func (ds *dataStore) APIKey() APIKeyStore {
+	return newAPIKeys(ds)
+}
+
+// PasswordReset() PasswordResetStore
+func (ds *dataStore) PasswordReset() PasswordResetStore {
+	return newPasswordResets(ds)
+}
+
+// UserMFA() UserMFAStore
+func (ds *dataStore) UserMFA() UserMFAStore {
+	return newUserMFAs(ds)
+}
+
+// UserIdentity() UserIdentityStore
+func (ds *dataStore) UserIdentity() UserIdentityStore {
+	return newUserIdentities(ds)
 }
 
 func (ds *dataStore) Close() error {
-	sqlDB, err := ds.db.DB()
+	sqlDB, err := ds.core.DB()
 	if err != nil {
 		return err
 	}
 
 	return sqlDB.Close()
 }
+
+// dbError 将数据库错误包装为 errno.ErrDatabase，在错误产生处记录调用栈，err 为 nil 时返回 nil
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errno.ErrDatabase.Wrap(err)
+}
+
+// offset 根据页码和每页条数计算查询偏移量
+func offset(page, pageSize int) int {
+	if page < 1 {
+		page = 1
+	}
+	return (page - 1) * pageSize
+}
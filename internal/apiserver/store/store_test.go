@@ -0,0 +1,411 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/migration"
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/apiserver/store/memory"
+	"github.com/lichenglife/easyblog/internal/pkg/db/dbtest"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// newGORMStore 创建使用 SQLite 内存数据库并执行了全部迁移的存储层
+func newGORMStore(t *testing.T) store.IStore {
+	t.Helper()
+
+	gormDB := dbtest.NewSQLite(t)
+	if _, err := migration.NewMigrator(gormDB).Up(context.Background()); err != nil {
+		t.Fatalf("执行迁移: %v", err)
+	}
+	return store.NewStore(gormDB)
+}
+
+// parityTest 在每种存储层实现上执行 run，期望返回相同的错误
+type parityTest struct {
+	name string
+	run  func(ctx context.Context, s store.IStore) error
+	want error
+}
+
+// runParity 为每个用例分别创建全新的内存存储和 GORM 存储并比较执行结果
+func runParity(t *testing.T, tests []parityTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		stores := map[string]store.IStore{
+			"memory": memory.NewStore(),
+			"gorm":   newGORMStore(t),
+		}
+		for name, s := range stores {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				if err := tt.run(context.Background(), s); !errors.Is(err, tt.want) {
+					t.Errorf("err = %v，期望 %v", err, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func newUser(n int) *model.User {
+	phone := fmt.Sprintf("1380000%04d", n)
+	return &model.User{
+		UserID:   fmt.Sprintf("user-%d", n),
+		Username: fmt.Sprintf("user%d", n),
+		Password: "hash",
+		NickName: fmt.Sprintf("nick%d", n),
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		Phone:    &phone,
+	}
+}
+
+func TestUserStoreParity(t *testing.T) {
+	runParity(t, []parityTest{
+		{
+			name: "get missing user",
+			run: func(ctx context.Context, s store.IStore) error {
+				_, err := s.User().GetByUsername(ctx, "nobody")
+				return err
+			},
+			want: errno.ErrUserNotFound,
+		},
+		{
+			name: "duplicate username",
+			run: func(ctx context.Context, s store.IStore) error {
+				if err := s.User().Create(ctx, newUser(1)); err != nil {
+					return err
+				}
+				user := newUser(2)
+				user.Username = "user1"
+				return s.User().Create(ctx, user)
+			},
+			want: errno.ErrUserAlreadyExist,
+		},
+		{
+			name: "users without phone do not conflict",
+			run: func(ctx context.Context, s store.IStore) error {
+				for i := 1; i <= 2; i++ {
+					user := newUser(i)
+					user.Phone = nil
+					if err := s.User().Create(ctx, user); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			name: "update missing user",
+			run: func(ctx context.Context, s store.IStore) error {
+				user := newUser(1)
+				user.ID = 42
+				return s.User().Update(ctx, user)
+			},
+			want: errno.ErrUserNotFound,
+		},
+		{
+			name: "update unchanged user",
+			run: func(ctx context.Context, s store.IStore) error {
+				user := newUser(1)
+				if err := s.User().Create(ctx, user); err != nil {
+					return err
+				}
+				return s.User().Update(ctx, user)
+			},
+		},
+		{
+			name: "delete missing user",
+			run: func(ctx context.Context, s store.IStore) error {
+				return s.User().Delete(ctx, 42)
+			},
+			want: errno.ErrUserNotFound,
+		},
+		{
+			name: "get by email returns earliest user",
+			run: func(ctx context.Context, s store.IStore) error {
+				for i := 1; i <= 2; i++ {
+					user := newUser(i)
+					user.Email = "shared@example.com"
+					if err := s.User().Create(ctx, user); err != nil {
+						return err
+					}
+				}
+				user, err := s.User().GetByEmail(ctx, "shared@example.com")
+				if err != nil {
+					return err
+				}
+				if user.Username != "user1" {
+					return fmt.Errorf("GetByEmail 返回 %s，期望 user1", user.Username)
+				}
+				return nil
+			},
+		},
+		{
+			name: "list newest first",
+			run: func(ctx context.Context, s store.IStore) error {
+				for i := 1; i <= 3; i++ {
+					if err := s.User().Create(ctx, newUser(i)); err != nil {
+						return err
+					}
+				}
+				list, total, err := s.User().List(ctx, 2, 2)
+				if err != nil {
+					return err
+				}
+				if total != 3 || len(list) != 1 || list[0].Username != "user1" {
+					return fmt.Errorf("List(2, 2) 返回 %d 个用户，总数 %d", len(list), total)
+				}
+				return nil
+			},
+		},
+	})
+}
+
+func TestPostStoreParity(t *testing.T) {
+	runParity(t, []parityTest{
+		{
+			name: "get missing post",
+			run: func(ctx context.Context, s store.IStore) error {
+				_, err := s.Post().GetByPostID(ctx, "missing")
+				return err
+			},
+			want: errno.ErrPostNotFound,
+		},
+		{
+			name: "duplicate post id",
+			run: func(ctx context.Context, s store.IStore) error {
+				for i := 0; i < 2; i++ {
+					if err := s.Post().Create(ctx, &model.Post{UserID: "user-1", PostID: "post-1", Title: "t", Content: "c"}); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			want: errno.ErrPostAlreadyExist,
+		},
+		{
+			name: "list by user",
+			run: func(ctx context.Context, s store.IStore) error {
+				for i, userID := range []string{"user-1", "user-2", "user-1"} {
+					post := &model.Post{UserID: userID, PostID: fmt.Sprintf("post-%d", i), Title: "t", Content: "c"}
+					if err := s.Post().Create(ctx, post); err != nil {
+						return err
+					}
+				}
+				list, total, err := s.Post().GetByUserID(ctx, "user-1", 1, 10)
+				if err != nil {
+					return err
+				}
+				if total != 2 || len(list) != 2 || list[0].PostID != "post-2" {
+					return fmt.Errorf("GetByUserID 返回 %d 个帖子，总数 %d", len(list), total)
+				}
+				return nil
+			},
+		},
+		{
+			name: "delete missing post",
+			run: func(ctx context.Context, s store.IStore) error {
+				return s.Post().Delete(ctx, 42)
+			},
+			want: errno.ErrPostNotFound,
+		},
+		{
+			name: "delete by user",
+			run: func(ctx context.Context, s store.IStore) error {
+				if err := s.Post().Create(ctx, &model.Post{UserID: "user-1", PostID: "post-1", Title: "t", Content: "c"}); err != nil {
+					return err
+				}
+				if err := s.Post().DeleteByUserID(ctx, "user-1"); err != nil {
+					return err
+				}
+				_, err := s.Post().GetByPostID(ctx, "post-1")
+				return err
+			},
+			want: errno.ErrPostNotFound,
+		},
+	})
+}
+
+func TestTXParity(t *testing.T) {
+	errRollback := errors.New("rollback")
+
+	runParity(t, []parityTest{
+		{
+			name: "nested rollback only undoes inner changes",
+			run: func(ctx context.Context, s store.IStore) error {
+				err := s.TX(ctx, func(ctx context.Context) error {
+					if err := s.User().Create(ctx, newUser(1)); err != nil {
+						return err
+					}
+					// 内层事务回滚只撤销内层的修改
+					_ = s.TX(ctx, func(ctx context.Context) error {
+						if err := s.User().Create(ctx, newUser(2)); err != nil {
+							return err
+						}
+						return errRollback
+					})
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if _, err := s.User().GetByUsername(ctx, "user1"); err != nil {
+					return fmt.Errorf("外层事务创建的用户: %w", err)
+				}
+				_, err = s.User().GetByUsername(ctx, "user2")
+				return err
+			},
+			want: errno.ErrUserNotFound,
+		},
+		{
+			name: "rollback returns fn error",
+			run: func(ctx context.Context, s store.IStore) error {
+				err := s.TX(ctx, func(ctx context.Context) error {
+					if err := s.User().Create(ctx, newUser(1)); err != nil {
+						return err
+					}
+					return errRollback
+				})
+				if !errors.Is(err, errRollback) {
+					return fmt.Errorf("TX 返回 %v，期望 %v", err, errRollback)
+				}
+				_, err = s.User().GetByUsername(ctx, "user1")
+				return err
+			},
+			want: errno.ErrUserNotFound,
+		},
+	})
+}
+
+func TestAPIKeyStoreParity(t *testing.T) {
+	runParity(t, []parityTest{
+		{
+			name: "get missing api key",
+			run: func(ctx context.Context, s store.IStore) error {
+				_, err := s.APIKey().GetByKeyID(ctx, "missing")
+				return err
+			},
+			want: errno.ErrAPIKeyNotFound,
+		},
+		{
+			name: "delete other user's api key",
+			run: func(ctx context.Context, s store.IStore) error {
+				key := &model.APIKey{KeyID: "k1", UserID: "user-1", Name: "ci", Scopes: "posts:read", SecretHash: "hash"}
+				if err := s.APIKey().Create(ctx, key); err != nil {
+					return err
+				}
+				return s.APIKey().Delete(ctx, "user-2", key.ID)
+			},
+			want: errno.ErrAPIKeyNotFound,
+		},
+		{
+			name: "update last used",
+			run: func(ctx context.Context, s store.IStore) error {
+				key := &model.APIKey{KeyID: "k1", UserID: "user-1", Name: "ci", Scopes: "posts:read", SecretHash: "hash"}
+				if err := s.APIKey().Create(ctx, key); err != nil {
+					return err
+				}
+				now := time.Now()
+				if err := s.APIKey().UpdateLastUsed(ctx, key.ID, now); err != nil {
+					return err
+				}
+				got, err := s.APIKey().GetByKeyID(ctx, "k1")
+				if err != nil {
+					return err
+				}
+				if got.LastUsedAt == nil || !got.LastUsedAt.Equal(now) {
+					return fmt.Errorf("LastUsedAt = %v，期望 %v", got.LastUsedAt, now)
+				}
+				return nil
+			},
+		},
+	})
+}
+
+func TestPasswordResetStoreParity(t *testing.T) {
+	runParity(t, []parityTest{
+		{
+			name: "password reset token used twice",
+			run: func(ctx context.Context, s store.IStore) error {
+				reset := &model.PasswordReset{UserID: "user-1", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+				if err := s.PasswordReset().Create(ctx, reset); err != nil {
+					return err
+				}
+				if err := s.PasswordReset().MarkUsed(ctx, reset.ID, time.Now()); err != nil {
+					return err
+				}
+				return s.PasswordReset().MarkUsed(ctx, reset.ID, time.Now())
+			},
+			want: errno.ErrResetTokenInvalid,
+		},
+		{
+			name: "missing password reset token",
+			run: func(ctx context.Context, s store.IStore) error {
+				_, err := s.PasswordReset().GetByTokenHash(ctx, "missing")
+				return err
+			},
+			want: errno.ErrResetTokenInvalid,
+		},
+		{
+			name: "delete password reset tokens by user",
+			run: func(ctx context.Context, s store.IStore) error {
+				reset := &model.PasswordReset{UserID: "user-1", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+				if err := s.PasswordReset().Create(ctx, reset); err != nil {
+					return err
+				}
+				if err := s.PasswordReset().DeleteByUserID(ctx, "user-1"); err != nil {
+					return err
+				}
+				_, err := s.PasswordReset().GetByTokenHash(ctx, "hash")
+				return err
+			},
+			want: errno.ErrResetTokenInvalid,
+		},
+	})
+}
+
+func TestUserMFAStoreParity(t *testing.T) {
+	runParity(t, []parityTest{
+		{
+			name: "totp step not after last step",
+			run: func(ctx context.Context, s store.IStore) error {
+				mfa := &model.UserMFA{UserID: "user-1", Secret: "secret", Enabled: true}
+				if err := s.UserMFA().Create(ctx, mfa); err != nil {
+					return err
+				}
+				if err := s.UserMFA().UseStep(ctx, mfa.ID, 10); err != nil {
+					return err
+				}
+				return s.UserMFA().UseStep(ctx, mfa.ID, 10)
+			},
+			want: errno.ErrMFACodeInvalid,
+		},
+		{
+			name: "recovery code list changed concurrently",
+			run: func(ctx context.Context, s store.IStore) error {
+				mfa := &model.UserMFA{UserID: "user-1", Secret: "secret", Enabled: true, RecoveryCodes: "a,b"}
+				if err := s.UserMFA().Create(ctx, mfa); err != nil {
+					return err
+				}
+				if err := s.UserMFA().UseRecoveryCode(ctx, mfa.ID, "a,b", "b"); err != nil {
+					return err
+				}
+				return s.UserMFA().UseRecoveryCode(ctx, mfa.ID, "a,b", "a")
+			},
+			want: errno.ErrMFACodeInvalid,
+		},
+		{
+			name: "mfa not enabled",
+			run: func(ctx context.Context, s store.IStore) error {
+				_, err := s.UserMFA().Get(ctx, "user-1")
+				return err
+			},
+			want: errno.ErrMFANotEnabled,
+		},
+	})
+}
@@ -2,8 +2,11 @@ package store
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
 	"gorm.io/gorm"
 )
 
@@ -14,56 +17,143 @@ type UserStore interface {
 	GetByID(ctx context.Context, id uint) (*model.User, error)
 	// GetByUsername 根据用户名获取用户
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	// GetByUserID 根据用户唯一 ID 获取用户
+	GetByUserID(ctx context.Context, userID string) (*model.User, error)
+	// GetByEmail 根据邮箱获取用户，多个用户使用相同邮箱时返回最早注册的用户
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	// Update 更新用户
 	Update(ctx context.Context, user *model.User) error
 	// Delete 删除用户
 	Delete(ctx context.Context, id uint) error
-	// List 获取用户列表
-	List(ctx context.Context, page, pageSize int) ([]*model.User, error)
+	// List 获取用户列表，同时返回用户总数
+	List(ctx context.Context, page, pageSize int) ([]*model.User, int64, error)
 }
 
 // users 实现 UserStore 接口
 type users struct {
-	db *gorm.DB
+	ds *dataStore
 }
 
-// NewUsers 创建 users 实例
-func NewUsers(db *gorm.DB) UserStore {
-	return &users{db: db}
+// newUsers 创建 users 实例
+func newUsers(ds *dataStore) *users {
+	return &users{ds: ds}
 }
 
 // Create 创建用户
 func (u *users) Create(ctx context.Context, user *model.User) error {
-
+	now := time.Now()
+	if user.CreateAt.IsZero() {
+		user.CreateAt = now
+	}
+	if user.UpdateAt.IsZero() {
+		user.UpdateAt = now
+	}
+
+	if err := u.ds.DB(ctx).Create(user).Error; err != nil {
+		return userError(err)
+	}
 	return nil
 }
 
 // GetByID 根据 ID 获取用户
 func (u *users) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	var user model.User
+	if err := u.ds.DB(ctx).First(&user, id).Error; err != nil {
+		return nil, userError(err)
+	}
 
-	return nil, nil
+	return &user, nil
 }
 
 // GetByUsername 根据用户名获取用户
 func (u *users) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	if err := u.ds.DB(ctx).Where(map[string]interface{}{"username": username}).First(&user).Error; err != nil {
+		return nil, userError(err)
+	}
+
+	return &user, nil
+}
+
+// GetByUserID 根据用户唯一 ID 获取用户
+func (u *users) GetByUserID(ctx context.Context, userID string) (*model.User, error) {
+	var user model.User
+	if err := u.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).First(&user).Error; err != nil {
+		return nil, userError(err)
+	}
 
-	return nil, nil
+	return &user, nil
+}
+
+// GetByEmail 根据邮箱获取用户
+func (u *users) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	if err := u.ds.DB(ctx).Where(map[string]interface{}{"email": email}).Order("id").First(&user).Error; err != nil {
+		return nil, userError(err)
+	}
+
+	return &user, nil
 }
 
 // Update 更新用户
 func (u *users) Update(ctx context.Context, user *model.User) error {
+	user.UpdateAt = time.Now()
+
+	// Select("*") 保证零值字段同样会被更新
+	result := u.ds.DB(ctx).Model(user).Select("*").Omit("id", "createAt").Updates(user)
+	if result.Error != nil {
+		return userError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// MySQL 在数据未发生变化时返回 0，需要再次确认记录是否存在
+		if _, err := u.GetByID(ctx, user.ID); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
 // Delete 删除用户
 func (u *users) Delete(ctx context.Context, id uint) error {
+	result := u.ds.DB(ctx).Delete(&model.User{}, id)
+	if result.Error != nil {
+		return userError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errno.ErrUserNotFound
+	}
 
 	return nil
 }
 
 // List 获取用户列表
-func (u *users) List(ctx context.Context, page, pageSize int) ([]*model.User, error) {
+func (u *users) List(ctx context.Context, page, pageSize int) ([]*model.User, int64, error) {
+	var (
+		total int64
+		list  []*model.User
+	)
+
+	// 新建会话，保证 Count 与 Find 使用相互独立的查询条件
+	db := u.ds.DB(ctx).Model(&model.User{}).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, dbError(err)
+	}
+	if err := db.Order("id DESC").Offset(offset(page, pageSize)).Limit(pageSize).Find(&list).Error; err != nil {
+		return nil, 0, dbError(err)
+	}
+
+	return list, total, nil
+}
 
-	return nil, nil
+// userError 将 gorm 错误转换为用户相关的业务错误码
+func userError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errno.ErrUserNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errno.ErrUserAlreadyExist
+	default:
+		return dbError(err)
+	}
 }
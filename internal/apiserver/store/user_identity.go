@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"gorm.io/gorm"
+)
+
+type UserIdentityStore interface {
+	// Create 关联第三方账号，账号已关联其他用户时返回 errno.ErrIdentityAlreadyLinked
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	// Get 根据身份提供方和其中的用户 ID 获取关联记录，不存在时返回 errno.ErrIdentityNotLinked
+	Get(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	// ListByUserID 获取用户关联的全部第三方账号
+	ListByUserID(ctx context.Context, userID string) ([]*model.UserIdentity, error)
+	// Delete 解除用户与身份提供方的关联，未关联时返回 errno.ErrIdentityNotLinked
+	Delete(ctx context.Context, userID, provider string) error
+	// DeleteByUserID 删除用户关联的全部第三方账号
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// userIdentities 实现 UserIdentityStore 接口
+type userIdentities struct {
+	ds *dataStore
+}
+
+// newUserIdentities 创建 userIdentities 实例
+func newUserIdentities(ds *dataStore) *userIdentities {
+	return &userIdentities{ds: ds}
+}
+
+// Create 关联第三方账号
+func (i *userIdentities) Create(ctx context.Context, identity *model.UserIdentity) error {
+	if identity.CreateAt.IsZero() {
+		identity.CreateAt = time.Now()
+	}
+
+	if err := i.ds.DB(ctx).Create(identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errno.ErrIdentityAlreadyLinked
+		}
+		return dbError(err)
+	}
+	return nil
+}
+
+// Get 获取关联记录
+func (i *userIdentities) Get(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := i.ds.DB(ctx).Where(map[string]interface{}{"provider": provider, "subject": subject}).First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errno.ErrIdentityNotLinked
+		}
+		return nil, dbError(err)
+	}
+
+	return &identity, nil
+}
+
+// ListByUserID 获取用户关联的全部第三方账号
+func (i *userIdentities) ListByUserID(ctx context.Context, userID string) ([]*model.UserIdentity, error) {
+	var identities []*model.UserIdentity
+	err := i.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).Order("id").Find(&identities).Error
+	return identities, dbError(err)
+}
+
+// Delete 解除关联
+func (i *userIdentities) Delete(ctx context.Context, userID, provider string) error {
+	result := i.ds.DB(ctx).Where(map[string]interface{}{"userID": userID, "provider": provider}).Delete(&model.UserIdentity{})
+	if result.Error != nil {
+		return dbError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errno.ErrIdentityNotLinked
+	}
+	return nil
+}
+
+// DeleteByUserID 删除用户关联的全部第三方账号
+func (i *userIdentities) DeleteByUserID(ctx context.Context, userID string) error {
+	return dbError(i.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).Delete(&model.UserIdentity{}).Error)
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/apiserver/model"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserMFAStore interface {
+	// Create 创建两步验证配置
+	Create(ctx context.Context, mfa *model.UserMFA) error
+	// Get 获取用户的两步验证配置，不存在时返回 errno.ErrMFANotEnabled
+	Get(ctx context.Context, userID string) (*model.UserMFA, error)
+	// Update 更新两步验证配置
+	Update(ctx context.Context, mfa *model.UserMFA) error
+	// UseStep 记录已使用的验证码时间步，时间步不大于已记录的值时返回 errno.ErrMFACodeInvalid
+	UseStep(ctx context.Context, id uint, step int64) error
+	// UseRecoveryCode 将未使用的恢复码从 old 替换为 new，恢复码已被并发修改时返回 errno.ErrMFACodeInvalid
+	UseRecoveryCode(ctx context.Context, id uint, old, new string) error
+	// Delete 删除用户的两步验证配置
+	Delete(ctx context.Context, userID string) error
+}
+
+// userMFAs 实现 UserMFAStore 接口
+type userMFAs struct {
+	ds *dataStore
+}
+
+// newUserMFAs 创建 userMFAs 实例
+func newUserMFAs(ds *dataStore) *userMFAs {
+	return &userMFAs{ds: ds}
+}
+
+// Create 创建两步验证配置
+func (m *userMFAs) Create(ctx context.Context, mfa *model.UserMFA) error {
+	now := time.Now()
+	if mfa.CreateAt.IsZero() {
+		mfa.CreateAt = now
+	}
+	if mfa.UpdateAt.IsZero() {
+		mfa.UpdateAt = now
+	}
+
+	return dbError(m.ds.DB(ctx).Create(mfa).Error)
+}
+
+// Get 获取用户的两步验证配置
+func (m *userMFAs) Get(ctx context.Context, userID string) (*model.UserMFA, error) {
+	var mfa model.UserMFA
+	if err := m.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errno.ErrMFANotEnabled
+		}
+		return nil, dbError(err)
+	}
+
+	return &mfa, nil
+}
+
+// Update 更新两步验证配置
+func (m *userMFAs) Update(ctx context.Context, mfa *model.UserMFA) error {
+	mfa.UpdateAt = time.Now()
+
+	// Select("*") 保证零值字段同样会被更新
+	return dbError(m.ds.DB(ctx).Model(mfa).Select("*").Omit("id", "createAt").Updates(mfa).Error)
+}
+
+// UseStep 记录已使用的验证码时间步，通过条件更新保证同一验证码只能使用一次
+func (m *userMFAs) UseStep(ctx context.Context, id uint, step int64) error {
+	result := m.ds.DB(ctx).Model(&model.UserMFA{}).
+		Where(map[string]interface{}{"id": id}).
+		Where(clause.Lt{Column: "lastStep", Value: step}).
+		UpdateColumn("lastStep", step)
+	if result.Error != nil {
+		return dbError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errno.ErrMFACodeInvalid
+	}
+
+	return nil
+}
+
+// UseRecoveryCode 通过条件更新保证同一恢复码只能使用一次
+func (m *userMFAs) UseRecoveryCode(ctx context.Context, id uint, old, new string) error {
+	result := m.ds.DB(ctx).Model(&model.UserMFA{}).
+		Where(map[string]interface{}{"id": id, "recoveryCodes": old}).
+		UpdateColumn("recoveryCodes", new)
+	if result.Error != nil {
+		return dbError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errno.ErrMFACodeInvalid
+	}
+
+	return nil
+}
+
+// Delete 删除用户的两步验证配置
+func (m *userMFAs) Delete(ctx context.Context, userID string) error {
+	return dbError(m.ds.DB(ctx).Where(map[string]interface{}{"userID": userID}).Delete(&model.UserMFA{}).Error)
+}
@@ -1,14 +1,35 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
+	"github.com/lichenglife/easyblog/internal/apiserver/migration"
 	"github.com/lichenglife/easyblog/internal/apiserver/store"
+	"github.com/lichenglife/easyblog/internal/apiserver/store/memory"
+	"github.com/lichenglife/easyblog/internal/pkg/auth"
+	"github.com/lichenglife/easyblog/internal/pkg/authz"
 	"github.com/lichenglife/easyblog/internal/pkg/cache"
 	"github.com/lichenglife/easyblog/internal/pkg/db"
+	"github.com/lichenglife/easyblog/internal/pkg/lockout"
 	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/lichenglife/easyblog/internal/pkg/mfa"
+	"github.com/lichenglife/easyblog/internal/pkg/notify"
+	"github.com/lichenglife/easyblog/internal/pkg/oauth"
+	"github.com/lichenglife/easyblog/internal/pkg/ratelimit"
+	"github.com/lichenglife/easyblog/internal/pkg/token"
 
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 存储层实现类型
+const (
+	// StoreDB 使用数据库存储
+	StoreDB = "db"
+	// StoreMemory 使用内存存储，无需依赖数据库
+	StoreMemory = "memory"
 )
 
 // App 初始化选项
@@ -16,6 +37,10 @@ type AppOptions struct {
 	EnableDB     bool
 	EnableCache  bool
 	EnableServer bool
+	// Store 存储层实现类型 (db, memory)
+	Store string
+	// AutoMigrate 启动时自动执行数据库迁移
+	AutoMigrate bool
 }
 
 // 构造方法， 返回默认配置
@@ -25,6 +50,7 @@ func DefaultAppOptions() *AppOptions {
 		EnableDB:     true,
 		EnableCache:  false,
 		EnableServer: true,
+		Store:        StoreDB,
 	}
 }
 
@@ -37,6 +63,22 @@ type IApp interface {
 	GetStoreFactory() store.IStore
 
 	// 服务接口
+	// GetPasswordHasher 获取密码哈希组件
+	GetPasswordHasher() auth.PasswordHasher
+	// GetTokenManager 获取令牌管理组件
+	GetTokenManager() *token.Manager
+	// GetEnforcer 获取访问控制组件
+	GetEnforcer() *authz.Enforcer
+	// GetNotifier 获取通知组件
+	GetNotifier() notify.Notifier
+	// GetMFAManager 获取两步验证组件
+	GetMFAManager() *mfa.Manager
+	// GetLockoutGuard 获取登录防护组件
+	GetLockoutGuard() *lockout.Guard
+	// GetOAuthManager 获取第三方登录组件
+	GetOAuthManager() *oauth.Manager
+	// GetRateLimiter 获取限流器
+	GetRateLimiter() ratelimit.Limiter
 
 	// 关闭应用
 	Close() error
@@ -59,7 +101,16 @@ type App struct {
 	//  存储
 	store store.IStore
 	//  认证服务
-
+	hasher   auth.PasswordHasher
+	tokens   *token.Manager
+	enforcer *authz.Enforcer
+	mfa      *mfa.Manager
+	lockout  *lockout.Guard
+	oauth    *oauth.Manager
+	//  通知服务
+	notifier notify.Notifier
+	//  限流器
+	limiter ratelimit.Limiter
 }
 
 // 创建App实例
@@ -84,6 +135,11 @@ func NewAppWithOptions(config *viper.Viper, option *AppOptions) (IApp, error) {
 		if err != nil {
 			return nil, fmt.Errorf("初始化数据库失败%v", err)
 		}
+		if option.AutoMigrate {
+			if err := app.migrate(); err != nil {
+				return nil, fmt.Errorf("执行数据库迁移失败%v", err)
+			}
+		}
 	}
 	if option.EnableCache {
 		err = app.initCache()
@@ -91,10 +147,19 @@ func NewAppWithOptions(config *viper.Viper, option *AppOptions) (IApp, error) {
 			return nil, fmt.Errorf("初始化缓存失败%v", err)
 		}
 	}
-	err = app.initStoreFactory()
+	err = app.initStoreFactory(option.Store)
 	if err != nil {
 		return nil, fmt.Errorf("初始化存储工厂失败%v", err)
 	}
+	err = app.initAuth(option.Store)
+	if err != nil {
+		return nil, fmt.Errorf("初始化认证服务失败%v", err)
+	}
+	err = app.initNotifier()
+	if err != nil {
+		return nil, fmt.Errorf("初始化通知服务失败%v", err)
+	}
+	app.initRateLimiter()
 
 	return app, nil
 }
@@ -120,6 +185,15 @@ func (app *App) initDB() error {
 	return nil
 }
 
+// migrate 执行全部未执行的数据库迁移
+func (app *App) migrate() error {
+	changes, err := migration.NewMigrator(app.Db.DB).Up(context.Background())
+	for _, c := range changes {
+		app.logger.Info("执行数据库迁移", zap.Uint("version", c.Version), zap.String("name", c.Name))
+	}
+	return err
+}
+
 func (app *App) initCache() error {
 	cache, err := cache.NewCache(app.config)
 	if err != nil {
@@ -129,10 +203,103 @@ func (app *App) initCache() error {
 	return nil
 }
 
-func (app *App) initStoreFactory() error {
-	app.store = store.NewStore(app.Db.DB)
+// initStoreFactory 根据存储类型初始化存储工厂
+func (app *App) initStoreFactory(storeType string) error {
+	switch storeType {
+	case StoreMemory:
+		app.store = memory.NewStore()
+	case StoreDB, "":
+		if app.Db == nil {
+			return fmt.Errorf("数据库存储需要启用数据库")
+		}
+		app.store = store.NewStore(app.Db.DB)
+	default:
+		return fmt.Errorf("不支持的存储类型: %s", storeType)
+	}
 	return nil
 }
+
+// initAuth 初始化认证相关组件，storeType 为存储层实现类型
+func (app *App) initAuth(storeType string) error {
+	hasher, err := auth.NewPasswordHasher(app.config)
+	if err != nil {
+		return err
+	}
+	app.hasher = hasher
+
+	// 令牌黑名单、刷新令牌家族、登录失败次数和第三方登录的授权请求优先保存在 Redis 中，未启用缓存时退化为进程内存储，
+	// 此时注销和锁定状态无法在多个实例间共享
+	var tokenStore cache.Store
+	if app.cache != nil {
+		tokenStore = cache.NewRedisStore(app.cache)
+	} else {
+		app.logger.Warn("未启用 Redis，令牌注销状态保存在进程内存中")
+		tokenStore = cache.NewMemoryStore()
+	}
+
+	tokens, err := token.NewManager(app.config, tokenStore)
+	if err != nil {
+		return err
+	}
+	app.tokens = tokens
+
+	guard, err := lockout.NewGuard(lockout.NewOptions(app.config), tokenStore, app.logger)
+	if err != nil {
+		return err
+	}
+	app.lockout = guard
+
+	oauthManager, err := oauth.NewManager(app.config, tokenStore)
+	if err != nil {
+		return err
+	}
+	app.oauth = oauthManager
+
+	if role := app.config.GetString("authz.defaultRole"); role != "" && !authz.IsRole(role) {
+		return fmt.Errorf("不支持的默认角色: %s", role)
+	}
+	enforcer, err := authz.NewEnforcer(app.config)
+	if err != nil {
+		return err
+	}
+	app.enforcer = enforcer
+
+	// 内存存储的数据在重启后丢失，未设置加密密钥时使用临时生成的密钥；持久化存储必须设置密钥，
+	// 否则重启后已启用两步验证的用户将无法登录
+	mfaManager, err := mfa.NewManager(app.config)
+	if errors.Is(err, mfa.ErrEncryptionKeyNotSet) && storeType == StoreMemory {
+		app.logger.Warn("未设置两步验证加密密钥，内存存储模式下使用临时生成的密钥，仅适用于本地开发")
+		mfaManager, err = mfa.NewEphemeralManager(app.config)
+	}
+	if err != nil {
+		return err
+	}
+	app.mfa = mfaManager
+	return nil
+}
+
+// initNotifier 初始化通知组件
+func (app *App) initNotifier() error {
+	notifier, err := notify.NewNotifier(app.config, app.logger)
+	if err != nil {
+		return err
+	}
+	if driver := app.config.GetString("notify.driver"); driver == notify.DriverLog || driver == "" {
+		app.logger.Warn("通知内容将写入日志，仅适用于本地开发")
+	}
+	app.notifier = notifier
+	return nil
+}
+
+// initRateLimiter 初始化限流器，启用 Redis 时多个实例共享限流配额
+func (app *App) initRateLimiter() {
+	if app.cache != nil {
+		app.limiter = ratelimit.NewRedisLimiter(app.cache)
+		return
+	}
+	app.limiter = ratelimit.NewLocalLimiter()
+}
+
 func (app *App) Close() error {
 
 	if app.Db != nil {
@@ -170,3 +337,35 @@ func (app *App) GetStoreFactory() store.IStore {
 }
 
 // 服务接口
+
+func (app *App) GetPasswordHasher() auth.PasswordHasher {
+	return app.hasher
+}
+
+func (app *App) GetTokenManager() *token.Manager {
+	return app.tokens
+}
+
+func (app *App) GetEnforcer() *authz.Enforcer {
+	return app.enforcer
+}
+
+func (app *App) GetNotifier() notify.Notifier {
+	return app.notifier
+}
+
+func (app *App) GetMFAManager() *mfa.Manager {
+	return app.mfa
+}
+
+func (app *App) GetLockoutGuard() *lockout.Guard {
+	return app.lockout
+}
+
+func (app *App) GetOAuthManager() *oauth.Manager {
+	return app.oauth
+}
+
+func (app *App) GetRateLimiter() ratelimit.Limiter {
+	return app.limiter
+}
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 支持的密码哈希算法
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// PasswordHasher 定义了密码哈希组件接口，哈希结果中包含算法及参数，
+// 因此修改配置后仍然可以校验旧的哈希
+type PasswordHasher interface {
+	// Hash 使用当前配置的算法和参数计算密码哈希
+	Hash(password string) (string, error)
+	// Compare 校验密码，不匹配或哈希格式无法识别时返回 errno.ErrPasswordIncorrect
+	Compare(hash, password string) error
+	// NeedsRehash 判断哈希使用的算法或参数是否与当前配置不一致
+	NeedsRehash(hash string) bool
+	// CompareDummy 使用预先计算的哈希校验密码并忽略结果，用于用户不存在时保持与 Compare 相同的耗时
+	CompareDummy(password string)
+}
+
+// Argon2Params argon2id 算法参数
+type Argon2Params struct {
+	// Time 迭代次数
+	Time uint32
+	// Memory 内存大小(KiB)
+	Memory uint32
+	// Threads 并行度
+	Threads uint8
+	// KeyLength 哈希长度(字节)
+	KeyLength uint32
+	// SaltLength 盐长度(字节)
+	SaltLength uint32
+}
+
+// PasswordOptions 密码哈希配置
+type PasswordOptions struct {
+	// Algorithm 新密码使用的哈希算法
+	Algorithm string
+	// BcryptCost bcrypt 计算成本
+	BcryptCost int
+	// Argon2 argon2id 算法参数
+	Argon2 Argon2Params
+}
+
+// NewPasswordOptions 返回默认的密码哈希配置
+func NewPasswordOptions() *PasswordOptions {
+	return &PasswordOptions{
+		Algorithm:  AlgorithmArgon2id,
+		BcryptCost: 12,
+		Argon2: Argon2Params{
+			Time:       3,
+			Memory:     64 * 1024,
+			Threads:    2,
+			KeyLength:  32,
+			SaltLength: 16,
+		},
+	}
+}
+
+// passwordHasher 实现 PasswordHasher 接口
+type passwordHasher struct {
+	opts *PasswordOptions
+	// dummyHash 随机密码的哈希，供 CompareDummy 使用
+	dummyHash string
+}
+
+// NewPasswordHasher 根据配置创建密码哈希组件，未配置的参数使用默认值
+func NewPasswordHasher(config *viper.Viper) (PasswordHasher, error) {
+	opts := NewPasswordOptions()
+	if v := config.GetString("auth.password.algorithm"); v != "" {
+		opts.Algorithm = v
+	}
+	if v := config.GetInt("auth.password.bcryptCost"); v != 0 {
+		opts.BcryptCost = v
+	}
+	if v := config.GetUint32("auth.password.argon2.time"); v != 0 {
+		opts.Argon2.Time = v
+	}
+	if v := config.GetUint32("auth.password.argon2.memory"); v != 0 {
+		opts.Argon2.Memory = v
+	}
+	if v := config.GetUint("auth.password.argon2.threads"); v != 0 {
+		opts.Argon2.Threads = uint8(v)
+	}
+
+	return NewPasswordHasherWithOptions(opts)
+}
+
+// NewPasswordHasherWithOptions 使用指定配置创建密码哈希组件
+func NewPasswordHasherWithOptions(opts *PasswordOptions) (PasswordHasher, error) {
+	switch opts.Algorithm {
+	case AlgorithmArgon2id:
+		if opts.Argon2.Time == 0 || opts.Argon2.Memory == 0 || opts.Argon2.Threads == 0 {
+			return nil, fmt.Errorf("无效的 argon2id 参数")
+		}
+	case AlgorithmBcrypt:
+		if opts.BcryptCost < bcrypt.MinCost || opts.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("无效的 bcrypt 计算成本: %d", opts.BcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的密码哈希算法: %s", opts.Algorithm)
+	}
+
+	h := &passwordHasher{opts: opts}
+	dummy := make([]byte, 16)
+	if _, err := rand.Read(dummy); err != nil {
+		return nil, fmt.Errorf("生成随机密码失败: %v", err)
+	}
+	hash, err := h.Hash(base64.RawStdEncoding.EncodeToString(dummy))
+	if err != nil {
+		return nil, err
+	}
+	h.dummyHash = hash
+
+	return h, nil
+}
+
+// Hash implements PasswordHasher.
+func (h *passwordHasher) Hash(password string) (string, error) {
+	if h.opts.Algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.opts.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("计算密码哈希失败: %v", err)
+		}
+		return string(hash), nil
+	}
+
+	p := h.opts.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("生成密码盐失败: %v", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Compare implements PasswordHasher.
+func (h *passwordHasher) Compare(hash, password string) error {
+	switch {
+	case isArgon2id(hash):
+		p, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return errno.ErrPasswordIncorrect
+		}
+		actual := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return errno.ErrPasswordIncorrect
+		}
+		return nil
+	case isBcrypt(hash):
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+			return errno.ErrPasswordIncorrect
+		}
+		return nil
+	default:
+		return errno.ErrPasswordIncorrect
+	}
+}
+
+// NeedsRehash implements PasswordHasher.
+func (h *passwordHasher) NeedsRehash(hash string) bool {
+	switch {
+	case isArgon2id(hash):
+		if h.opts.Algorithm != AlgorithmArgon2id {
+			return true
+		}
+		p, _, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return true
+		}
+		want := h.opts.Argon2
+		return p.Time != want.Time || p.Memory != want.Memory || p.Threads != want.Threads || uint32(len(key)) != want.KeyLength
+	case isBcrypt(hash):
+		if h.opts.Algorithm != AlgorithmBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.opts.BcryptCost
+	default:
+		return true
+	}
+}
+
+// CompareDummy implements PasswordHasher.
+func (h *passwordHasher) CompareDummy(password string) {
+	_ = h.Compare(h.dummyHash, password)
+}
+
+func isArgon2id(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// decodeArgon2id 解析 $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key> 格式的哈希
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+
+	return p, salt, key, nil
+}
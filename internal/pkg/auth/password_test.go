@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// newTestOptions 返回计算量较小的哈希配置，避免测试耗时过长
+func newTestOptions(algorithm string) *PasswordOptions {
+	return &PasswordOptions{
+		Algorithm:  algorithm,
+		BcryptCost: bcrypt.MinCost,
+		Argon2: Argon2Params{
+			Time:       1,
+			Memory:     1024,
+			Threads:    1,
+			KeyLength:  32,
+			SaltLength: 16,
+		},
+	}
+}
+
+func newTestHasher(t *testing.T, opts *PasswordOptions) PasswordHasher {
+	t.Helper()
+	h, err := NewPasswordHasherWithOptions(opts)
+	if err != nil {
+		t.Fatalf("NewPasswordHasherWithOptions: %v", err)
+	}
+	return h
+}
+
+func TestHashCompare(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmArgon2id, AlgorithmBcrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			h := newTestHasher(t, newTestOptions(algorithm))
+			hash, err := h.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if err := h.Compare(hash, "correct horse"); err != nil {
+				t.Errorf("正确的密码: Compare = %v，期望 nil", err)
+			}
+			if err := h.Compare(hash, "wrong horse"); !errors.Is(err, errno.ErrPasswordIncorrect) {
+				t.Errorf("错误的密码: Compare = %v，期望 %v", err, errno.ErrPasswordIncorrect)
+			}
+			if h.NeedsRehash(hash) {
+				t.Error("使用当前配置计算的哈希不需要重新哈希")
+			}
+
+			// 每次哈希使用不同的盐
+			again, err := h.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if again == hash {
+				t.Error("相同密码的两次哈希结果不应相同")
+			}
+		})
+	}
+}
+
+func TestCompareAcrossAlgorithms(t *testing.T) {
+	argon := newTestHasher(t, newTestOptions(AlgorithmArgon2id))
+	bcryptHasher := newTestHasher(t, newTestOptions(AlgorithmBcrypt))
+
+	tests := []struct {
+		name        string
+		hashWith    PasswordHasher
+		compareWith PasswordHasher
+	}{
+		{name: "argon2id 哈希切换到 bcrypt", hashWith: argon, compareWith: bcryptHasher},
+		{name: "bcrypt 哈希切换到 argon2id", hashWith: bcryptHasher, compareWith: argon},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hashWith.Hash("secret")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			// 修改算法后仍然可以校验旧的哈希，但需要重新哈希
+			if err := tt.compareWith.Compare(hash, "secret"); err != nil {
+				t.Errorf("Compare = %v，期望 nil", err)
+			}
+			if !tt.compareWith.NeedsRehash(hash) {
+				t.Error("算法变化后应需要重新哈希")
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argonHash, err := newTestHasher(t, newTestOptions(AlgorithmArgon2id)).Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	bcryptHash, err := newTestHasher(t, newTestOptions(AlgorithmBcrypt)).Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		hash   string
+		change func(opts *PasswordOptions)
+		want   bool
+	}{
+		{name: "argon2id 参数未变化", hash: argonHash, change: func(*PasswordOptions) {}, want: false},
+		{name: "argon2id 迭代次数变化", hash: argonHash, change: func(o *PasswordOptions) { o.Argon2.Time = 2 }, want: true},
+		{name: "argon2id 内存大小变化", hash: argonHash, change: func(o *PasswordOptions) { o.Argon2.Memory = 2048 }, want: true},
+		{name: "argon2id 并行度变化", hash: argonHash, change: func(o *PasswordOptions) { o.Argon2.Threads = 2 }, want: true},
+		{name: "argon2id 哈希长度变化", hash: argonHash, change: func(o *PasswordOptions) { o.Argon2.KeyLength = 64 }, want: true},
+		{name: "argon2id 盐长度变化", hash: argonHash, change: func(o *PasswordOptions) { o.Argon2.SaltLength = 32 }, want: false},
+		{name: "bcrypt 计算成本未变化", hash: bcryptHash, change: func(o *PasswordOptions) { o.Algorithm = AlgorithmBcrypt }, want: false},
+		{name: "bcrypt 计算成本变化", hash: bcryptHash, change: func(o *PasswordOptions) { o.Algorithm, o.BcryptCost = AlgorithmBcrypt, bcrypt.MinCost+1 }, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := newTestOptions(AlgorithmArgon2id)
+			tt.change(opts)
+			if got := newTestHasher(t, opts).NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareMalformedHash(t *testing.T) {
+	h := newTestHasher(t, newTestOptions(AlgorithmArgon2id))
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "argon2id 字段数量错误", hash: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA"},
+		{name: "argon2id 版本不支持", hash: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"},
+		{name: "argon2id 参数格式错误", hash: "$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"},
+		{name: "argon2id 盐不是 Base64", hash: "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"},
+		{name: "argon2id 哈希不是 Base64", hash: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$!!!"},
+		{name: "bcrypt 哈希被截断", hash: "$2a$04$abcdefghijklmnopqrstuv"},
+		{name: "未知的哈希格式", hash: "$pbkdf2-sha256$29000$c2FsdA$a2V5"},
+		{name: "明文", hash: "Passw0rd!"},
+		{name: "空字符串", hash: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 即使输入的密码与存储的值完全相同也不能通过校验
+			if err := h.Compare(tt.hash, tt.hash); !errors.Is(err, errno.ErrPasswordIncorrect) {
+				t.Errorf("Compare = %v，期望 %v", err, errno.ErrPasswordIncorrect)
+			}
+			if !h.NeedsRehash(tt.hash) {
+				t.Error("无法解析的哈希应需要重新哈希")
+			}
+		})
+	}
+}
+
+func TestNewPasswordHasherWithOptionsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(opts *PasswordOptions)
+	}{
+		{name: "不支持的算法", change: func(o *PasswordOptions) { o.Algorithm = "md5" }},
+		{name: "argon2id 迭代次数为 0", change: func(o *PasswordOptions) { o.Argon2.Time = 0 }},
+		{name: "bcrypt 计算成本过低", change: func(o *PasswordOptions) { o.Algorithm, o.BcryptCost = AlgorithmBcrypt, bcrypt.MinCost-1 }},
+		{name: "bcrypt 计算成本过高", change: func(o *PasswordOptions) { o.Algorithm, o.BcryptCost = AlgorithmBcrypt, bcrypt.MaxCost+1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := newTestOptions(AlgorithmArgon2id)
+			tt.change(opts)
+			if _, err := NewPasswordHasherWithOptions(opts); err == nil {
+				t.Error("NewPasswordHasherWithOptions 应返回错误")
+			}
+		})
+	}
+}
+
+func TestCompareDummy(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmArgon2id, AlgorithmBcrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			h := newTestHasher(t, newTestOptions(algorithm)).(*passwordHasher)
+			// 预先计算的哈希使用当前配置，与校验真实用户密码的耗时一致
+			if h.NeedsRehash(h.dummyHash) {
+				t.Errorf("dummyHash %q 与当前配置不一致", h.dummyHash)
+			}
+			h.CompareDummy("correct horse")
+		})
+	}
+}
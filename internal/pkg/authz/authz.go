@@ -0,0 +1,246 @@
+package authz
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 用户角色
+const (
+	// RoleAdmin 管理员，拥有全部权限
+	RoleAdmin = "admin"
+	// RoleEditor 编辑，可以管理全部帖子
+	RoleEditor = "editor"
+	// RoleAuthor 作者，可以发布和管理自己的帖子
+	RoleAuthor = "author"
+	// RoleReader 读者，只能浏览
+	RoleReader = "reader"
+)
+
+// API 密钥授权范围
+const (
+	// ScopePostsRead 读取帖子
+	ScopePostsRead = "posts:read"
+	// ScopePostsWrite 发布、修改和删除帖子
+	ScopePostsWrite = "posts:write"
+	// ScopeUsersAdmin 管理用户
+	ScopeUsersAdmin = "users:admin"
+)
+
+// 规则效果
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// wildcard 匹配全部角色、方法或路由
+const wildcard = "*"
+
+// defaultPolicy 内置访问控制策略
+//
+//go:embed policy.yaml
+var defaultPolicy []byte
+
+// Roles 返回全部角色
+func Roles() []string {
+	return []string{RoleAdmin, RoleEditor, RoleAuthor, RoleReader}
+}
+
+// IsRole 判断是否为合法角色
+func IsRole(role string) bool {
+	return slices.Contains(Roles(), role)
+}
+
+// Scopes 返回全部 API 密钥授权范围
+func Scopes() []string {
+	return []string{ScopePostsRead, ScopePostsWrite, ScopeUsersAdmin}
+}
+
+// IsScope 判断是否为合法的 API 密钥授权范围
+func IsScope(scope string) bool {
+	return slices.Contains(Scopes(), scope)
+}
+
+// IsPrivileged 判断角色能否管理其他用户的内容
+func IsPrivileged(role string) bool {
+	return role == RoleAdmin || role == RoleEditor
+}
+
+// Rule 访问控制规则
+type Rule struct {
+	// Roles 规则适用的角色
+	Roles []string `mapstructure:"roles"`
+	// Methods 规则适用的 HTTP 方法
+	Methods []string `mapstructure:"methods"`
+	// Paths 规则适用的路由
+	Paths []string `mapstructure:"paths"`
+	// Effect 规则效果 (allow, deny)，默认为 allow
+	Effect string `mapstructure:"effect"`
+}
+
+// ScopeRule API 密钥授权范围规则
+type ScopeRule struct {
+	// Scope 授权范围
+	Scope string `mapstructure:"scope"`
+	// Methods 授权范围覆盖的 HTTP 方法
+	Methods []string `mapstructure:"methods"`
+	// Paths 授权范围覆盖的路由
+	Paths []string `mapstructure:"paths"`
+}
+
+// MFARule 两步验证策略
+type MFARule struct {
+	// Roles 必须启用两步验证的角色
+	Roles []string `mapstructure:"roles"`
+	// ExemptPaths 未通过两步验证时仍然可以访问的路由，用于启用两步验证
+	ExemptPaths []string `mapstructure:"exemptPaths"`
+}
+
+// Policy 访问控制策略
+type Policy struct {
+	Rules  []Rule      `mapstructure:"rules"`
+	Scopes []ScopeRule `mapstructure:"scopes"`
+	MFA    MFARule     `mapstructure:"mfa"`
+}
+
+// Enforcer 根据访问控制策略判断角色能否访问指定路由
+type Enforcer struct {
+	rules  []Rule
+	scopes []ScopeRule
+	mfa    MFARule
+}
+
+// NewEnforcer 根据 authz.policyFile 配置加载策略文件，未配置时使用内置策略
+func NewEnforcer(config *viper.Viper) (*Enforcer, error) {
+	v := viper.New()
+	if file := config.GetString("authz.policyFile"); file != "" {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("读取访问控制策略失败: %v", err)
+		}
+	} else {
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(bytes.NewReader(defaultPolicy)); err != nil {
+			return nil, fmt.Errorf("读取内置访问控制策略失败: %v", err)
+		}
+	}
+
+	var policy Policy
+	if err := v.Unmarshal(&policy); err != nil {
+		return nil, fmt.Errorf("解析访问控制策略失败: %v", err)
+	}
+	return NewEnforcerWithPolicy(&policy)
+}
+
+// NewEnforcerWithPolicy 使用指定的策略创建 Enforcer 实例
+func NewEnforcerWithPolicy(policy *Policy) (*Enforcer, error) {
+	rules := make([]Rule, 0, len(policy.Rules))
+	for i, r := range policy.Rules {
+		if r.Effect == "" {
+			r.Effect = EffectAllow
+		}
+		if r.Effect != EffectAllow && r.Effect != EffectDeny {
+			return nil, fmt.Errorf("第 %d 条访问控制规则的 effect 无效: %s", i+1, r.Effect)
+		}
+		if len(r.Roles) == 0 || len(r.Methods) == 0 || len(r.Paths) == 0 {
+			return nil, fmt.Errorf("第 %d 条访问控制规则缺少 roles、methods 或 paths", i+1)
+		}
+		for _, role := range r.Roles {
+			if role != wildcard && !IsRole(role) {
+				return nil, fmt.Errorf("第 %d 条访问控制规则的角色无效: %s", i+1, role)
+			}
+		}
+		for j, m := range r.Methods {
+			r.Methods[j] = strings.ToUpper(m)
+		}
+		rules = append(rules, r)
+	}
+
+	scopes := make([]ScopeRule, 0, len(policy.Scopes))
+	for i, r := range policy.Scopes {
+		if !IsScope(r.Scope) {
+			return nil, fmt.Errorf("第 %d 条授权范围规则的 scope 无效: %s", i+1, r.Scope)
+		}
+		if len(r.Methods) == 0 || len(r.Paths) == 0 {
+			return nil, fmt.Errorf("第 %d 条授权范围规则缺少 methods 或 paths", i+1)
+		}
+		scopes = append(scopes, r)
+	}
+
+	for _, role := range policy.MFA.Roles {
+		if role != wildcard && !IsRole(role) {
+			return nil, fmt.Errorf("两步验证策略的角色无效: %s", role)
+		}
+	}
+
+	return &Enforcer{rules: rules, scopes: scopes, mfa: policy.MFA}, nil
+}
+
+// Enforce 判断角色能否使用 method 访问 path，path 为 gin 路由模板，如 /v1/user/:id。
+// 任一 deny 规则命中即拒绝，否则至少需要一条 allow 规则命中
+func (e *Enforcer) Enforce(role, method, path string) bool {
+	allowed := false
+	for _, r := range e.rules {
+		if !r.match(role, method, path) {
+			continue
+		}
+		if r.Effect == EffectDeny {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+// EnforceScopes 判断拥有 scopes 授权范围的 API 密钥能否使用 method 访问 path
+func (e *Enforcer) EnforceScopes(scopes []string, method, path string) bool {
+	for _, r := range e.scopes {
+		if !slices.Contains(scopes, r.Scope) {
+			continue
+		}
+		if matchAny(r.Methods, method, strings.EqualFold) && matchAny(r.Paths, path, matchPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// MFARequired 判断角色是否必须启用两步验证
+func (e *Enforcer) MFARequired(role string) bool {
+	return matchAny(e.mfa.Roles, role, strings.EqualFold)
+}
+
+// MFAExempt 判断未通过两步验证的调用方能否访问 path
+func (e *Enforcer) MFAExempt(path string) bool {
+	return matchAny(e.mfa.ExemptPaths, path, matchPath)
+}
+
+// match 判断规则是否适用于请求
+func (r *Rule) match(role, method, path string) bool {
+	return matchAny(r.Roles, role, strings.EqualFold) &&
+		matchAny(r.Methods, method, strings.EqualFold) &&
+		matchAny(r.Paths, path, matchPath)
+}
+
+// matchAny 判断 value 是否匹配 patterns 中的任意一项
+func matchAny(patterns []string, value string, match func(pattern, value string) bool) bool {
+	for _, p := range patterns {
+		if p == wildcard || match(p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath 判断路由是否匹配，以 "/*" 结尾的模式匹配该前缀下的全部路由
+func matchPath(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	return pattern == path
+}
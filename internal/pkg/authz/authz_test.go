@@ -0,0 +1,193 @@
+package authz
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	e, err := NewEnforcer(viper.New())
+	if err != nil {
+		t.Fatalf("NewEnforcer: %v", err)
+	}
+
+	tests := []struct {
+		role, method, path string
+		want               bool
+	}{
+		{role: RoleAdmin, method: "GET", path: "/v1/user", want: true},
+		{role: RoleAdmin, method: "PUT", path: "/v1/user/:id/role", want: true},
+		{role: RoleAdmin, method: "DELETE", path: "/v1/user/:id", want: true},
+		{role: RoleEditor, method: "GET", path: "/v1/user", want: false},
+		{role: RoleReader, method: "GET", path: "/v1/user", want: false},
+		{role: RoleReader, method: "GET", path: "/v1/user/info", want: true},
+		{role: RoleReader, method: "get", path: "/v1/user/info", want: true},
+		{role: RoleReader, method: "POST", path: "/v1/user/logout", want: true},
+		{role: RoleReader, method: "PUT", path: "/v1/user/:id/password", want: true},
+		{role: RoleAuthor, method: "PUT", path: "/v1/user/:id/role", want: false},
+		{role: RoleAuthor, method: "DELETE", path: "/v1/user/:id", want: false},
+		{role: RoleAuthor, method: "POST", path: "/v1/post", want: true},
+		{role: RoleEditor, method: "DELETE", path: "/v1/post/:id", want: true},
+		{role: RoleReader, method: "POST", path: "/v1/post", want: false},
+		{role: RoleReader, method: "PUT", path: "/v1/post/:id", want: false},
+		{role: "guest", method: "POST", path: "/v1/post", want: false},
+	}
+	for _, tt := range tests {
+		if got := e.Enforce(tt.role, tt.method, tt.path); got != tt.want {
+			t.Errorf("Enforce(%s, %s, %s) = %v，期望 %v", tt.role, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEnforceRules(t *testing.T) {
+	e, err := NewEnforcerWithPolicy(&Policy{Rules: []Rule{
+		{Roles: []string{"*"}, Methods: []string{"*"}, Paths: []string{"/v1/admin/*"}},
+		{Roles: []string{RoleReader}, Methods: []string{"delete"}, Paths: []string{"/v1/admin/*"}, Effect: EffectDeny},
+	}})
+	if err != nil {
+		t.Fatalf("NewEnforcerWithPolicy: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		role, method, path string
+		want               bool
+	}{
+		{name: "前缀本身", role: RoleReader, method: "GET", path: "/v1/admin", want: true},
+		{name: "前缀下的路由", role: RoleReader, method: "GET", path: "/v1/admin/users/:id", want: true},
+		{name: "同名前缀的其他路由", role: RoleReader, method: "GET", path: "/v1/administrator", want: false},
+		{name: "deny 规则优先", role: RoleReader, method: "DELETE", path: "/v1/admin/users/:id", want: false},
+		{name: "deny 规则只作用于指定角色", role: RoleAuthor, method: "DELETE", path: "/v1/admin/users/:id", want: true},
+		{name: "没有规则命中", role: RoleAdmin, method: "GET", path: "/v1/post", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.Enforce(tt.role, tt.method, tt.path); got != tt.want {
+				t.Errorf("Enforce(%s, %s, %s) = %v，期望 %v", tt.role, tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEnforcerWithPolicyInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "无效的 effect", rule: Rule{Roles: []string{RoleAdmin}, Methods: []string{"*"}, Paths: []string{"*"}, Effect: "maybe"}},
+		{name: "缺少 roles", rule: Rule{Methods: []string{"*"}, Paths: []string{"*"}}},
+		{name: "缺少 paths", rule: Rule{Roles: []string{RoleAdmin}, Methods: []string{"*"}}},
+		{name: "无效的角色", rule: Rule{Roles: []string{"root"}, Methods: []string{"*"}, Paths: []string{"*"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEnforcerWithPolicy(&Policy{Rules: []Rule{tt.rule}}); err == nil {
+				t.Error("NewEnforcerWithPolicy 应返回错误")
+			}
+		})
+	}
+}
+
+func TestNewEnforcerPolicyFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	policy := "rules:\n  - roles: [reader]\n    methods: [GET]\n    paths: [/v1/user]\n"
+	if err := os.WriteFile(file, []byte(policy), 0o600); err != nil {
+		t.Fatalf("写入策略文件: %v", err)
+	}
+
+	config := viper.New()
+	config.Set("authz.policyFile", file)
+	e, err := NewEnforcer(config)
+	if err != nil {
+		t.Fatalf("NewEnforcer: %v", err)
+	}
+	// 使用策略文件时不再加载内置策略
+	if !e.Enforce(RoleReader, "GET", "/v1/user") || e.Enforce(RoleAdmin, "GET", "/v1/user") {
+		t.Error("应使用策略文件中的规则")
+	}
+
+	config.Set("authz.policyFile", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := NewEnforcer(config); err == nil {
+		t.Error("策略文件不存在时应返回错误")
+	}
+}
+
+func TestEnforceScopes(t *testing.T) {
+	e, err := NewEnforcer(viper.New())
+	if err != nil {
+		t.Fatalf("NewEnforcer: %v", err)
+	}
+
+	tests := []struct {
+		scopes       []string
+		method, path string
+		want         bool
+	}{
+		{scopes: []string{ScopePostsRead}, method: "GET", path: "/v1/post/:id", want: true},
+		{scopes: []string{ScopePostsRead}, method: "POST", path: "/v1/post", want: false},
+		{scopes: []string{ScopePostsRead, ScopePostsWrite}, method: "POST", path: "/v1/post", want: true},
+		{scopes: []string{ScopePostsWrite}, method: "DELETE", path: "/v1/post/:id", want: true},
+		{scopes: []string{ScopePostsWrite}, method: "GET", path: "/v1/user", want: false},
+		{scopes: []string{ScopeUsersAdmin}, method: "DELETE", path: "/v1/user/:id", want: true},
+		{scopes: []string{ScopeUsersAdmin}, method: "GET", path: "/v1/post", want: false},
+		{scopes: nil, method: "GET", path: "/v1/post", want: false},
+	}
+	for _, tt := range tests {
+		if got := e.EnforceScopes(tt.scopes, tt.method, tt.path); got != tt.want {
+			t.Errorf("EnforceScopes(%v, %s, %s) = %v，期望 %v", tt.scopes, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNewEnforcerWithPolicyInvalidScope(t *testing.T) {
+	tests := []struct {
+		name string
+		rule ScopeRule
+	}{
+		{name: "无效的授权范围", rule: ScopeRule{Scope: "posts:admin", Methods: []string{"GET"}, Paths: []string{"/v1/post"}}},
+		{name: "缺少 methods", rule: ScopeRule{Scope: ScopePostsRead, Paths: []string{"/v1/post"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEnforcerWithPolicy(&Policy{Scopes: []ScopeRule{tt.rule}}); err == nil {
+				t.Error("NewEnforcerWithPolicy 应返回错误")
+			}
+		})
+	}
+}
+
+func TestMFAPolicy(t *testing.T) {
+	e, err := NewEnforcer(viper.New())
+	if err != nil {
+		t.Fatalf("NewEnforcer: %v", err)
+	}
+
+	for _, role := range Roles() {
+		if got, want := e.MFARequired(role), role == RoleAdmin; got != want {
+			t.Errorf("MFARequired(%s) = %v，期望 %v", role, got, want)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/v1/user/info", want: true},
+		{path: "/v1/user/logout", want: true},
+		{path: "/v1/user/mfa/enroll", want: true},
+		{path: "/v1/user", want: false},
+		{path: "/v1/user/:id/role", want: false},
+	}
+	for _, tt := range tests {
+		if got := e.MFAExempt(tt.path); got != tt.want {
+			t.Errorf("MFAExempt(%s) = %v，期望 %v", tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := NewEnforcerWithPolicy(&Policy{MFA: MFARule{Roles: []string{"root"}}}); err == nil {
+		t.Error("两步验证策略的角色无效时应返回错误")
+	}
+}
@@ -17,18 +17,18 @@ type Cache struct {
 func NewCache(config *viper.Viper) (*Cache, error) {
 	// 创建redis  client 客户端实例
 	client := redis.NewClient(&redis.Options{
-		Addr:         fmt.Sprintf("%s:%d", config.GetString("redis.add"), config.GetInt("redis.port")),
+		Addr:         fmt.Sprintf("%s:%d", config.GetString("redis.host"), config.GetInt("redis.port")),
 		Password:     config.GetString("redis.password"),
 		DB:           config.GetInt("redis.db"),
 		PoolSize:     config.GetInt("redis.poolSize"),
 		MinIdleConns: config.GetInt("redis.minIdleConns"),
-		MaxIdleConns: config.GetInt("redis.maxIdelConns"),
+		MaxIdleConns: config.GetInt("redis.maxIdleConns"),
 	})
 	//  测试连接
 	// 创建带有超时机制的context
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := client.Ping(ctx); err != nil {
+	if err := client.Ping(ctx).Err(); err != nil {
 
 		return nil, fmt.Errorf("连接Redis失败 %v", err)
 	}
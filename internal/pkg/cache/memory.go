@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryItem 内存缓存中的键值及其过期时间
+type memoryItem struct {
+	value string
+	// members 集合类型键值的成员
+	members  map[string]struct{}
+	expireAt time.Time
+}
+
+// expired 判断键值是否已过期
+func (i *memoryItem) expired(now time.Time) bool {
+	return !i.expireAt.IsZero() && !now.Before(i.expireAt)
+}
+
+// memoryStore 基于内存实现 Store 接口，适用于单实例部署和未启用 Redis 的场景
+type memoryStore struct {
+	mu    sync.Mutex
+	items map[string]*memoryItem
+	// writes 写入计数，每写入 sweepInterval 次清理一次过期键值
+	writes int
+}
+
+// sweepInterval 清理过期键值的写入间隔
+const sweepInterval = 1024
+
+// NewMemoryStore 创建基于内存的缓存存储
+func NewMemoryStore() Store {
+	return &memoryStore{items: make(map[string]*memoryItem)}
+}
+
+// Set 设置键值
+func (s *memoryStore) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set(key, value, ttl)
+	return nil
+}
+
+// Get 获取键值
+func (s *memoryStore) Get(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.get(key)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return item.value, nil
+}
+
+// Exists 判断键是否存在
+func (s *memoryStore) Exists(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.get(key)
+	return ok, nil
+}
+
+// Del 删除键
+func (s *memoryStore) Del(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.items, key)
+	}
+	return nil
+}
+
+// CompareAndSwap 比较并替换键值
+func (s *memoryStore) CompareAndSwap(_ context.Context, key, old, new string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.get(key)
+	if !ok || item.value != old {
+		return false, nil
+	}
+	s.set(key, new, ttl)
+	return true, nil
+}
+
+// Incr 递增计数
+func (s *memoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.get(key)
+	if !ok {
+		s.set(key, "1", ttl)
+		return 1, nil
+	}
+	n, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("键 %s 的值不是整数", key)
+	}
+	// 保留原有的过期时间
+	item.value = strconv.FormatInt(n+1, 10)
+	return n + 1, nil
+}
+
+// SAdd 向集合中添加成员
+func (s *memoryStore) SAdd(_ context.Context, key, member string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := map[string]struct{}{member: {}}
+	if item, ok := s.get(key); ok {
+		for m := range item.members {
+			members[m] = struct{}{}
+		}
+	}
+	s.set(key, "", ttl)
+	s.items[key].members = members
+	return nil
+}
+
+// SMembers 返回集合的全部成员
+func (s *memoryStore) SMembers(_ context.Context, key string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.get(key)
+	if !ok {
+		return []string{}, nil
+	}
+	members := make([]string, 0, len(item.members))
+	for m := range item.members {
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// SRem 从集合中删除成员
+func (s *memoryStore) SRem(_ context.Context, key string, members ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.get(key)
+	if !ok {
+		return nil
+	}
+	for _, m := range members {
+		delete(item.members, m)
+	}
+	// 与 Redis 一致，集合为空时删除键
+	if len(item.members) == 0 {
+		delete(s.items, key)
+	}
+	return nil
+}
+
+// get 获取未过期的键值，过期的键值会被顺带清理
+func (s *memoryStore) get(key string) (*memoryItem, bool) {
+	item, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	if item.expired(time.Now()) {
+		delete(s.items, key)
+		return nil, false
+	}
+	return item, true
+}
+
+// set 设置键值，调用方需持有锁
+func (s *memoryStore) set(key, value string, ttl time.Duration) {
+	item := &memoryItem{value: value}
+	if ttl > 0 {
+		item.expireAt = time.Now().Add(ttl)
+	}
+	s.items[key] = item
+
+	s.writes++
+	if s.writes%sweepInterval == 0 {
+		s.sweep()
+	}
+}
+
+// sweep 清理全部过期键值，调用方需持有锁
+func (s *memoryStore) sweep() {
+	now := time.Now()
+	for key, item := range s.items {
+		if item.expired(now) {
+			delete(s.items, key)
+		}
+	}
+}
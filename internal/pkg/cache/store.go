@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotFound 表示缓存中不存在指定的键
+var ErrNotFound = errors.New("缓存不存在")
+
+// Store 定义了键值缓存的通用操作，Redis 和内存实现均满足该接口。
+// ttl 小于等于 0 表示永不过期
+type Store interface {
+	// Set 设置键值
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// Get 获取键值，键不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) (string, error)
+	// Exists 判断键是否存在
+	Exists(ctx context.Context, key string) (bool, error)
+	// Del 删除键
+	Del(ctx context.Context, keys ...string) error
+	// CompareAndSwap 当键的当前值等于 old 时原子地替换为 new，返回是否替换成功
+	CompareAndSwap(ctx context.Context, key, old, new string, ttl time.Duration) (bool, error)
+	// Incr 将键的整数值加一并返回新值，键不存在时从 0 开始计数并设置过期时间，已存在的键不会延长过期时间
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// SAdd 向集合中添加成员，并将集合的过期时间重置为 ttl
+	SAdd(ctx context.Context, key, member string, ttl time.Duration) error
+	// SMembers 返回集合的全部成员，集合不存在时返回空列表
+	SMembers(ctx context.Context, key string) ([]string, error)
+	// SRem 从集合中删除成员
+	SRem(ctx context.Context, key string, members ...string) error
+}
+
+// compareAndSwapScript 原子地比较并替换键值，ARGV[3] 为过期时间(毫秒)，0 表示不过期
+var compareAndSwapScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// incrScript 原子地递增计数并在首次创建时设置过期时间，ARGV[1] 为过期时间(毫秒)，0 表示不过期
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// redisStore 基于 Redis 实现 Store 接口
+type redisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore 创建基于 Redis 的缓存存储
+func NewRedisStore(c *Cache) Store {
+	return &redisStore{client: c.Client}
+}
+
+// Set 设置键值
+func (s *redisStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, expiration(ttl)).Err()
+}
+
+// Get 获取键值
+func (s *redisStore) Get(ctx context.Context, key string) (string, error) {
+	value, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+// Exists 判断键是否存在
+func (s *redisStore) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := s.client.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+// Del 删除键
+func (s *redisStore) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+// CompareAndSwap 比较并替换键值
+func (s *redisStore) CompareAndSwap(ctx context.Context, key, old, new string, ttl time.Duration) (bool, error) {
+	n, err := compareAndSwapScript.Run(ctx, s.client, []string{key}, old, new, expiration(ttl).Milliseconds()).Int()
+	return n == 1, err
+}
+
+// Incr 递增计数
+func (s *redisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.client, []string{key}, expiration(ttl).Milliseconds()).Int64()
+}
+
+// SAdd 向集合中添加成员
+func (s *redisStore) SAdd(ctx context.Context, key, member string, ttl time.Duration) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, member)
+		if ttl > 0 {
+			pipe.PExpire(ctx, key, ttl)
+		} else {
+			pipe.Persist(ctx, key)
+		}
+		return nil
+	})
+	return err
+}
+
+// SMembers 返回集合的全部成员
+func (s *redisStore) SMembers(ctx context.Context, key string) ([]string, error) {
+	return s.client.SMembers(ctx, key).Result()
+}
+
+// SRem 从集合中删除成员
+func (s *redisStore) SRem(ctx context.Context, key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		args = append(args, member)
+	}
+	return s.client.SRem(ctx, key, args...).Err()
+}
+
+// expiration 将 ttl 转换为 Redis 过期时间，小于等于 0 时表示不过期
+func expiration(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return 0
+	}
+	return ttl
+}
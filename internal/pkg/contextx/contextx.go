@@ -0,0 +1,113 @@
+package contextx
+
+import (
+	"context"
+	"time"
+)
+
+// Principal 表示当前请求的调用方身份
+type Principal struct {
+	// UserID 用户唯一 ID
+	UserID string
+	// Username 用户名
+	Username string
+	// Role 用户角色
+	Role string
+	// TokenID 访问令牌 ID (jti)
+	TokenID string
+	// Family 访问令牌所属的令牌家族 ID
+	Family string
+	// ExpiresAt 访问令牌过期时间
+	ExpiresAt time.Time
+	// MFA 访问令牌是否通过两步验证登录获得
+	MFA bool
+	// APIKeyID 使用 API 密钥认证时为密钥公开 ID
+	APIKeyID string
+	// Scopes 使用 API 密钥认证时为密钥的授权范围
+	Scopes []string
+}
+
+// IsAPIKey 判断调用方是否使用 API 密钥认证
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != ""
+}
+
+// principalKey 用于在 context 中保存调用方身份
+type principalKey struct{}
+
+// WithPrincipal 将调用方身份保存到 context 中
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom 从 context 中获取调用方身份
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// UserID 从 context 中获取调用方的用户 ID，未认证时返回空字符串
+func UserID(ctx context.Context) string {
+	if p, ok := PrincipalFrom(ctx); ok {
+		return p.UserID
+	}
+	return ""
+}
+
+// Username 从 context 中获取调用方的用户名，未认证时返回空字符串
+func Username(ctx context.Context) string {
+	if p, ok := PrincipalFrom(ctx); ok {
+		return p.Username
+	}
+	return ""
+}
+
+// Role 从 context 中获取调用方的角色，未认证时返回空字符串
+func Role(ctx context.Context) string {
+	if p, ok := PrincipalFrom(ctx); ok {
+		return p.Role
+	}
+	return ""
+}
+
+// clientIPKey 用于在 context 中保存客户端 IP
+type clientIPKey struct{}
+
+// WithClientIP 将客户端 IP 保存到 context 中
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP 从 context 中获取客户端 IP，未设置时返回空字符串
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// userAgentKey 用于在 context 中保存客户端 User-Agent
+type userAgentKey struct{}
+
+// WithUserAgent 将客户端 User-Agent 保存到 context 中
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, userAgent)
+}
+
+// UserAgent 从 context 中获取客户端 User-Agent，未设置时返回空字符串
+func UserAgent(ctx context.Context) string {
+	userAgent, _ := ctx.Value(userAgentKey{}).(string)
+	return userAgent
+}
+
+// localeKey 用于在 context 中保存响应语言
+type localeKey struct{}
+
+// WithLocale 将响应语言保存到 context 中
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale 从 context 中获取响应语言，未设置时返回空字符串
+func Locale(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
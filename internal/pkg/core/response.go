@@ -1,18 +1,24 @@
 package core
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
 	"github.com/lichenglife/easyblog/internal/pkg/errno"
 )
 
 // Response 定义了API响应结构
 type Response struct {
-	Code    int         `json:"code"`    // 错误码
-	Message string      `json:"message"` // 错误信息
-	Data    interface{} `json:"data"`    // 响应数据
+	Code      int         `json:"code"`                // 错误码
+	Message   string      `json:"message"`             // 错误信息
+	RequestID string      `json:"requestID,omitempty"` // 请求ID，与 X-Request-ID 响应头相同
+	Data      interface{} `json:"data"`                // 响应数据
+	Details   interface{} `json:"details,omitempty"`   // 错误详情，如字段校验错误
 }
 
 // ListResponse 定义了列表类API的响应结构
@@ -22,24 +28,39 @@ type ListResponse[T any] struct {
 	Items      []T   `json:"items"`      // 数据项
 }
 
-// WriteResponse 写入HTTP响应
+// WriteResponse 写入HTTP响应，错误会记录到 gin.Context 中，由请求日志输出原因和调用栈
 func WriteResponse(c *gin.Context, err error, data interface{}) {
 	if err != nil {
 		// 解码错误信息
 		e := errno.Decode(err)
+		_ = c.Error(e)
+		// 需要稍后重试的错误通过 Retry-After 告知客户端等待的秒数
+		var retry interface{ RetryAfter() time.Duration }
+		if errors.As(err, &retry) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retry.RetryAfter().Seconds()))))
+		}
+		// 错误信息按请求语言返回，未确定语言时使用默认语言
+		locale := contextx.Locale(c.Request.Context())
+		if locale == "" {
+			locale = errno.DefaultLocale()
+		}
 		// 返回错误响应
 		c.JSON(e.HTTP(), Response{
-			Code:    e.Code(),
-			Message: e.Message(),
-			Data:    nil,
+			Code:      e.Code(),
+			Message:   e.Localize(locale),
+			RequestID: c.GetString("requestID"),
+			Data:      nil,
+			// 携带详情的错误，如参数校验失败的字段列表
+			Details: e.Details(),
 		})
 		c.Abort()
 	} else {
 		// 返回成功响应
 		c.JSON(http.StatusOK, Response{
-			Code:    errno.OK.Code(),
-			Message: errno.OK.Message(),
-			Data:    data,
+			Code:      errno.OK.Code(),
+			Message:   errno.OK.Message(),
+			RequestID: c.GetString("requestID"),
+			Data:      data,
 		})
 	}
 }
@@ -0,0 +1,201 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// regexPrefix 以该前缀开头的 allowedOrigins 项按正则表达式匹配
+const regexPrefix = "regex:"
+
+// Options 跨域参数
+type Options struct {
+	// AllowAll 是否允许任意来源，此时响应 Access-Control-Allow-Origin: *
+	AllowAll bool
+	// AllowedMethods 允许的请求方法
+	AllowedMethods []string
+	// AllowedHeaders 允许的请求头，包含 "*" 时允许预检请求中声明的全部请求头
+	AllowedHeaders []string
+	// ExposedHeaders 允许浏览器读取的响应头
+	ExposedHeaders []string
+	// AllowCredentials 是否允许携带 Cookie 等凭证
+	AllowCredentials bool
+	// MaxAge 预检结果的缓存时间(秒)，为 0 时不设置
+	MaxAge int
+
+	// origins 精确匹配的来源
+	origins map[string]struct{}
+	// wildcards 通配子域名的来源，保存 * 两侧的前缀和后缀
+	wildcards [][2]string
+	// patterns 正则匹配的来源
+	patterns []*regexp.Regexp
+}
+
+// NewOptions 根据 cors 配置创建跨域参数，allowedOrigins 为空时不处理跨域请求。
+// allowedOrigins 支持 "*"、精确来源(https://blog.example.com)、通配子域名(https://*.example.com)
+// 以及 regex: 开头的正则表达式，配置组合无效时返回错误
+func NewOptions(config *viper.Viper) (*Options, error) {
+	opts := &Options{
+		AllowedMethods:   upper(config.GetStringSlice("cors.allowedMethods")),
+		AllowedHeaders:   config.GetStringSlice("cors.allowedHeaders"),
+		ExposedHeaders:   config.GetStringSlice("cors.exposedHeaders"),
+		AllowCredentials: config.GetBool("cors.allowCredentials"),
+		MaxAge:           config.GetInt("cors.maxAge"),
+		origins:          make(map[string]struct{}),
+	}
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+	}
+	if opts.MaxAge < 0 {
+		return nil, fmt.Errorf("cors.maxAge 不能小于 0")
+	}
+
+	for _, origin := range config.GetStringSlice("cors.allowedOrigins") {
+		origin = strings.TrimSpace(origin)
+		switch {
+		case origin == "*":
+			opts.AllowAll = true
+		case strings.HasPrefix(origin, regexPrefix):
+			re, err := regexp.Compile(strings.TrimPrefix(origin, regexPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("cors.allowedOrigins 中的正则表达式 %q 无效: %v", origin, err)
+			}
+			opts.patterns = append(opts.patterns, re)
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(strings.ToLower(origin), "*")
+			if strings.Contains(suffix, "*") || !strings.HasSuffix(prefix, "://") || !strings.HasPrefix(suffix, ".") {
+				return nil, fmt.Errorf("cors.allowedOrigins 中的来源 %q 无效，通配符只能用于子域名，如 https://*.example.com", origin)
+			}
+			opts.wildcards = append(opts.wildcards, [2]string{prefix, suffix})
+		default:
+			if err := validateOrigin(origin); err != nil {
+				return nil, fmt.Errorf("cors.allowedOrigins 中的来源 %q 无效: %v", origin, err)
+			}
+			opts.origins[strings.ToLower(origin)] = struct{}{}
+		}
+	}
+
+	// 浏览器不接受 Access-Control-Allow-Origin: * 与 Access-Control-Allow-Credentials: true 同时出现
+	if opts.AllowAll && opts.AllowCredentials {
+		return nil, fmt.Errorf("cors.allowedOrigins 包含 \"*\" 时不能开启 cors.allowCredentials，请改为列出具体来源")
+	}
+	if opts.AllowAll && opts.hasOriginRules() {
+		return nil, fmt.Errorf("cors.allowedOrigins 包含 \"*\" 时不能再配置其他来源")
+	}
+	return opts, nil
+}
+
+// validateOrigin 校验来源格式，来源只包含协议、主机和端口
+func validateOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("缺少协议或主机")
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("不能包含路径、查询参数或片段")
+	}
+	return nil
+}
+
+// upper 将请求方法转换为大写
+func upper(methods []string) []string {
+	for i, m := range methods {
+		methods[i] = strings.ToUpper(strings.TrimSpace(m))
+	}
+	return methods
+}
+
+// hasOriginRules 是否配置了 "*" 以外的来源
+func (o *Options) hasOriginRules() bool {
+	return len(o.origins) > 0 || len(o.wildcards) > 0 || len(o.patterns) > 0
+}
+
+// Enabled 是否处理跨域请求
+func (o *Options) Enabled() bool {
+	return o != nil && (o.AllowAll || o.hasOriginRules())
+}
+
+// AllowOrigin 判断是否允许来自 origin 的跨域请求
+func (o *Options) AllowOrigin(origin string) bool {
+	if o.AllowAll {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if _, ok := o.origins[lower]; ok {
+		return true
+	}
+	for _, w := range o.wildcards {
+		// 通配符至少匹配一级子域名
+		if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	for _, re := range o.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowMethod 判断预检请求声明的方法是否允许
+func (o *Options) AllowMethod(method string) bool {
+	method = strings.ToUpper(method)
+	for _, m := range o.AllowedMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowHeaders 判断预检请求声明的请求头是否全部允许，requested 为 Access-Control-Request-Headers 的值
+func (o *Options) AllowHeaders(requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		allowed := false
+		for _, a := range o.AllowedHeaders {
+			if a == "*" || strings.EqualFold(a, h) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// PreflightHeaders 返回预检响应需要设置的 Access-Control-Allow-Methods、Access-Control-Allow-Headers 和 Access-Control-Max-Age
+func (o *Options) PreflightHeaders(requestedHeaders string) http.Header {
+	header := http.Header{}
+	header.Set("Access-Control-Allow-Methods", strings.Join(o.AllowedMethods, ", "))
+	allowedHeaders := strings.Join(o.AllowedHeaders, ", ")
+	for _, a := range o.AllowedHeaders {
+		if a == "*" {
+			// 携带凭证时浏览器不识别 "*"，原样返回预检请求声明的请求头
+			allowedHeaders = requestedHeaders
+			break
+		}
+	}
+	if allowedHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", allowedHeaders)
+	}
+	if o.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(o.MaxAge))
+	}
+	return header
+}
@@ -0,0 +1,114 @@
+package cors
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func newTestOptions(t *testing.T, origins []string, credentials bool) (*Options, error) {
+	t.Helper()
+	config := viper.New()
+	config.Set("cors.allowedOrigins", origins)
+	config.Set("cors.allowCredentials", credentials)
+	return NewOptions(config)
+}
+
+func TestNewOptionsInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+	}{
+		{name: "任意来源不能携带凭证", origins: []string{"*"}, credentials: true},
+		{name: "任意来源不能与其他来源同时配置", origins: []string{"*", "https://blog.example.com"}},
+		{name: "无效的正则表达式", origins: []string{"regex:^https://(a|b$"}},
+		{name: "通配符不在子域名位置", origins: []string{"https://blog.*.com"}},
+		{name: "多个通配符", origins: []string{"https://*.*.example.com"}},
+		{name: "缺少协议", origins: []string{"blog.example.com"}},
+		{name: "包含路径", origins: []string{"https://blog.example.com/admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTestOptions(t, tt.origins, tt.credentials); err == nil {
+				t.Errorf("NewOptions(%v) 应返回错误", tt.origins)
+			}
+		})
+	}
+}
+
+func TestAllowOrigin(t *testing.T) {
+	opts, err := newTestOptions(t, []string{
+		"https://blog.example.com",
+		"https://*.easyblog.com",
+		`regex:^http://127\.0\.0\.1:[0-9]+$`,
+	}, true)
+	if err != nil {
+		t.Fatalf("NewOptions: %v", err)
+	}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{origin: "https://blog.example.com", want: true},
+		{origin: "HTTPS://Blog.Example.com", want: true},
+		{origin: "http://blog.example.com", want: false},
+		{origin: "https://blog.example.com:8443", want: false},
+		{origin: "https://evil-blog.example.com", want: false},
+		{origin: "https://admin.easyblog.com", want: true},
+		{origin: "https://a.b.easyblog.com", want: true},
+		{origin: "https://easyblog.com", want: false},
+		{origin: "https://.easyblog.com", want: false},
+		{origin: "https://evileasyblog.com", want: false},
+		{origin: "https://admin.easyblog.com.evil.com", want: false},
+		{origin: "http://127.0.0.1:3000", want: true},
+		{origin: "http://127.0.0.1:3000.evil.com", want: false},
+		{origin: "null", want: false},
+	}
+	for _, tt := range tests {
+		if got := opts.AllowOrigin(tt.origin); got != tt.want {
+			t.Errorf("AllowOrigin(%q) = %v，期望 %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestAllowAll(t *testing.T) {
+	opts, err := newTestOptions(t, []string{"*"}, false)
+	if err != nil {
+		t.Fatalf("NewOptions: %v", err)
+	}
+	if !opts.Enabled() || !opts.AllowOrigin("https://any.example.org") {
+		t.Error("配置 * 时应允许任意来源")
+	}
+
+	disabled, err := newTestOptions(t, nil, false)
+	if err != nil {
+		t.Fatalf("NewOptions: %v", err)
+	}
+	if disabled.Enabled() {
+		t.Error("未配置来源时不应处理跨域请求")
+	}
+}
+
+func TestAllowHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowed   []string
+		requested string
+		want      bool
+	}{
+		{name: "全部允许", allowed: []string{"Authorization", "Content-Type"}, requested: "content-type, authorization", want: true},
+		{name: "包含未允许的请求头", allowed: []string{"Content-Type"}, requested: "Content-Type, X-Custom", want: false},
+		{name: "通配符", allowed: []string{"*"}, requested: "X-Custom", want: true},
+		{name: "未声明请求头", allowed: nil, requested: "", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &Options{AllowedHeaders: tt.allowed}
+			if got := opts.AllowHeaders(tt.requested); got != tt.want {
+				t.Errorf("AllowHeaders(%q) = %v，期望 %v", tt.requested, got, tt.want)
+			}
+		})
+	}
+}
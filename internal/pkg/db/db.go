@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -15,30 +17,33 @@ type DB struct {
 	*gorm.DB
 }
 
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// sqliteMemory 表示使用 SQLite 内存数据库
+const sqliteMemory = ":memory:"
+
 // NewDB 创建一个新的数据库实例
 func NewDB(config *viper.Viper) (*DB, error) {
-	// 构建DSN
-	username := config.GetString("db.username")
-	password := config.GetString("db.password")
-	host := config.GetString("db.host")
-	port := config.GetInt("db.port")
-	database := config.GetString("db.database")
-
-	// 打印调试信息，确认配置值
-	fmt.Printf("数据库连接配置: host=%s, port=%d, user=%s, database=%s\n",
-		host, port, username, database)
+	driver := config.GetString("db.driver")
+	if driver == "" {
+		driver = DriverMySQL
+	}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		username,
-		password,
-		host,
-		port,
-		database,
-	)
+	dialector, err := newDialector(driver, config)
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建数据库连接
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(getLogLevel(config.GetString("db.logLevel"))),
+		// 将驱动相关的错误（如唯一索引冲突）转换为 gorm 的通用错误
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("连接数据库失败: %v", err)
@@ -51,13 +56,61 @@ func NewDB(config *viper.Viper) (*DB, error) {
 	}
 
 	// 设置连接池
-	sqlDB.SetMaxIdleConns(config.GetInt("db.maxIdleConns"))
-	sqlDB.SetMaxOpenConns(config.GetInt("db.maxOpenConns"))
-	sqlDB.SetConnMaxLifetime(time.Duration(config.GetInt("db.connMaxLifetime")) * time.Second)
+	if driver == DriverSQLite {
+		// SQLite 同一时间只允许一个写连接，内存数据库在连接关闭后数据即丢失，
+		// 因此固定使用单个长期存活的连接
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+	} else {
+		sqlDB.SetMaxIdleConns(config.GetInt("db.maxIdleConns"))
+		sqlDB.SetMaxOpenConns(config.GetInt("db.maxOpenConns"))
+		sqlDB.SetConnMaxLifetime(time.Duration(config.GetInt("db.connMaxLifetime")) * time.Second)
+	}
 
 	return &DB{db}, nil
 }
 
+// newDialector 根据驱动类型构建对应的 DSN 与 gorm 方言
+func newDialector(driver string, config *viper.Viper) (gorm.Dialector, error) {
+	username := config.GetString("db.username")
+	password := config.GetString("db.password")
+	host := config.GetString("db.host")
+	port := config.GetInt("db.port")
+	database := config.GetString("db.database")
+
+	switch driver {
+	case DriverMySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			username,
+			password,
+			host,
+			port,
+			database,
+		)
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable TimeZone=Local",
+			host,
+			port,
+			username,
+			password,
+			database,
+		)
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		// database 为数据库文件路径，":memory:" 表示使用内存数据库
+		dsn := database
+		if dsn == "" || dsn == sqliteMemory {
+			dsn = "file::memory:"
+		}
+		dsn += "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+}
+
 // getLogLevel 获取日志级别
 func getLogLevel(level string) logger.LogLevel {
 	switch level {
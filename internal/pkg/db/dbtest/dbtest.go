@@ -0,0 +1,27 @@
+// Package dbtest 提供测试使用的 SQLite 内存数据库，无需依赖外部数据库即可测试存储层和迁移
+package dbtest
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+
+	"github.com/lichenglife/easyblog/internal/pkg/db"
+)
+
+// NewSQLite 创建一个全新的 SQLite 内存数据库，测试结束时自动关闭
+func NewSQLite(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	config := viper.New()
+	config.Set("db.driver", db.DriverSQLite)
+	config.Set("db.database", ":memory:")
+	config.Set("db.logLevel", "silent")
+	database, err := db.NewDB(config)
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return database.DB
+}
@@ -0,0 +1,162 @@
+package errno
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourceLocale 代码中错误信息使用的语言，New 和 WithMessage 的错误信息均为该语言
+const SourceLocale = "zh-CN"
+
+var (
+	mu sync.RWMutex
+	// catalog 各语言按错误码保存的错误信息
+	catalog = map[string]map[int]string{
+		SourceLocale: {},
+		"en-US":      enUS,
+	}
+	// defaultLocale 无法从请求中确定语言时使用的语言
+	defaultLocale = SourceLocale
+)
+
+// enUS 英文错误信息
+var enUS = map[int]string{
+	0:      "OK",
+	10001:  "Internal server error",
+	10002:  "Database error",
+	10003:  "Invalid parameters",
+	10004:  "Unauthorized",
+	10005:  "Forbidden",
+	10006:  "Resource not found",
+	10007:  "Too many requests",
+	10008:  "Invalid token",
+	10009:  "Token expired",
+	10010:  "Token revoked",
+	100010: "Invalid parameters",
+	99999:  "Unknown error",
+
+	20001: "User not found",
+	20002: "User already exists",
+	20003: "Incorrect password",
+	20004: "Invalid username format",
+	20005: "Invalid password format",
+	20006: "Invalid phone number format",
+	20007: "Invalid email format",
+	20008: "API key not found",
+	20009: "Reset link is invalid or has expired",
+	20010: "Verification link is invalid or has expired",
+	20011: "Email not verified",
+	20012: "Invalid verification code",
+	20013: "Two-factor authentication is required for your role",
+	20014: "Two-factor authentication is not enabled",
+	20015: "Session not found",
+	20016: "Identity provider not found",
+	20017: "Login request is invalid or has expired, please sign in again",
+	20018: "Third-party login failed",
+	20019: "Third-party account is not linked to any user",
+	20020: "Third-party account is already linked to another user",
+
+	30001: "Post not found",
+	30002: "You do not have access to this post",
+	30003: "Invalid post title",
+	30004: "Invalid post content",
+	30005: "Post already exists",
+}
+
+// register 保存错误码在指定语言下的错误信息
+func register(locale string, code int, message string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	locale = canonicalLocale(locale)
+	if catalog[locale] == nil {
+		catalog[locale] = make(map[int]string)
+	}
+	catalog[locale][code] = message
+}
+
+// lookup 返回错误码在指定语言下的错误信息
+func lookup(locale string, code int) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	message, ok := catalog[canonicalLocale(locale)][code]
+	return message, ok
+}
+
+// canonicalLocale 返回与 locale 忽略大小写相同的已有语言，不存在时原样返回，调用方需要持有锁
+func canonicalLocale(locale string) string {
+	for l := range catalog {
+		if strings.EqualFold(l, locale) {
+			return l
+		}
+	}
+	return locale
+}
+
+// Locales 返回支持的语言
+func Locales() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	locales := make([]string, 0, len(catalog))
+	for l := range catalog {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// DefaultLocale 返回默认语言
+func DefaultLocale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLocale
+}
+
+// SetDefaultLocale 设置默认语言，语言必须已经存在
+func SetDefaultLocale(locale string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	canonical := canonicalLocale(locale)
+	if _, ok := catalog[canonical]; !ok {
+		return fmt.Errorf("不支持的语言 %s", locale)
+	}
+	defaultLocale = canonical
+	return nil
+}
+
+// LoadLocales 从目录加载错误信息，每个 <语言>.yaml 文件对应一种语言，内容为错误码到错误信息的映射，
+// 如 ja-JP.yaml 中的 10006: リソースが存在しません。已有语言的错误信息会被文件中的同名错误码覆盖
+func LoadLocales(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return fmt.Errorf("查找语言文件失败: %v", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("读取语言文件 %s 失败: %v", file, err)
+		}
+		var messages map[int]string
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("解析语言文件 %s 失败: %v", file, err)
+		}
+		locale := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		for code, message := range messages {
+			register(locale, code, message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package errno
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setDefaultLocale 修改默认语言，测试结束后恢复
+func setDefaultLocale(t *testing.T, locale string) {
+	t.Helper()
+	previous := DefaultLocale()
+	if err := SetDefaultLocale(locale); err != nil {
+		t.Fatalf("SetDefaultLocale: %v", err)
+	}
+	t.Cleanup(func() { _ = SetDefaultLocale(previous) })
+}
+
+// errUntranslated 只有源语言错误信息的错误码
+var errUntranslated = New(99001, "只有中文的错误", http.StatusBadRequest)
+
+func TestLocalize(t *testing.T) {
+	custom := ErrUserNotFound.WithMessage("用户 alice 不存在")
+
+	tests := []struct {
+		name          string
+		err           Errno
+		defaultLocale string
+		locale        string
+		want          string
+	}{
+		{name: "源语言", err: ErrUserNotFound, locale: "zh-CN", want: "用户不存在"},
+		{name: "英文", err: ErrUserNotFound, locale: "en-US", want: "User not found"},
+		{name: "语言忽略大小写", err: ErrUserNotFound, locale: "EN-us", want: "User not found"},
+		{name: "不支持的语言使用默认语言", err: ErrUserNotFound, locale: "fr-FR", want: "用户不存在"},
+		{name: "默认语言为英文", err: ErrUserNotFound, defaultLocale: "en-US", locale: "fr-FR", want: "User not found"},
+		{name: "没有翻译时使用默认语言", err: errUntranslated, locale: "en-US", want: "只有中文的错误"},
+		{name: "自定义信息用于源语言", err: custom, locale: "zh-CN", want: "用户 alice 不存在"},
+		{name: "自定义信息不用于其他语言", err: custom, locale: "en-US", want: "User not found"},
+		{name: "不支持的语言回退到源语言时使用自定义信息", err: custom, locale: "fr-FR", want: "用户 alice 不存在"},
+		{name: "不支持的语言回退到其他语言时不使用自定义信息", err: custom, defaultLocale: "en-US", locale: "fr-FR", want: "User not found"},
+		{name: "自定义信息不用于默认语言", err: custom, defaultLocale: "en-US", locale: "en-US", want: "User not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.defaultLocale != "" {
+				setDefaultLocale(t, tt.defaultLocale)
+			}
+			if got := tt.err.Localize(tt.locale); got != tt.want {
+				t.Errorf("Localize(%s) = %q，期望 %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+	// 本地化不修改错误本身的信息
+	if got := custom.Message(); got != "用户 alice 不存在" {
+		t.Errorf("Message = %q，期望自定义信息", got)
+	}
+}
+
+func TestLoadLocales(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ja-JP.yaml": "20001: ユーザーが存在しません\n",
+		// 已有语言的错误信息被覆盖，其余保持不变
+		"en-US.yaml": "30001: Blog post not found\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("写入语言文件: %v", err)
+		}
+	}
+	t.Cleanup(func() { register("en-US", 30001, enUS[30001]) })
+
+	if err := LoadLocales(dir); err != nil {
+		t.Fatalf("LoadLocales: %v", err)
+	}
+	tests := []struct {
+		err    Errno
+		locale string
+		want   string
+	}{
+		{err: ErrUserNotFound, locale: "ja-JP", want: "ユーザーが存在しません"},
+		{err: ErrPostNotFound, locale: "ja-JP", want: "博客不存在"},
+		{err: ErrPostNotFound, locale: "en-US", want: "Blog post not found"},
+		{err: ErrUserNotFound, locale: "en-US", want: "User not found"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Localize(tt.locale); got != tt.want {
+			t.Errorf("%d Localize(%s) = %q，期望 %q", tt.err.Code(), tt.locale, got, tt.want)
+		}
+	}
+
+	bad := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bad, "de-DE.yaml"), []byte("not: [a map"), 0o600); err != nil {
+		t.Fatalf("写入语言文件: %v", err)
+	}
+	if err := LoadLocales(bad); err == nil {
+		t.Error("语言文件格式错误时应返回错误")
+	}
+}
+
+func TestSetDefaultLocale(t *testing.T) {
+	setDefaultLocale(t, "en-us")
+	if got := DefaultLocale(); got != "en-US" {
+		t.Errorf("DefaultLocale = %q，期望 en-US", got)
+	}
+	if err := SetDefaultLocale("xx-XX"); err == nil {
+		t.Error("设置不支持的语言应返回错误")
+	}
+}
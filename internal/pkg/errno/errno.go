@@ -4,13 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"runtime"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
 // Errno 定义了错误码接口
 type Errno interface {
-	// Error 返回错误信息
+	// Error 返回错误信息，包含原因
 	Error() string
 	// Code 返回错误码
 	Code() int
@@ -20,6 +22,24 @@ type Errno interface {
 	HTTP() int
 	// WithMessage 设置自定义错误消息
 	WithMessage(message string) Errno
+	// Localize 返回指定语言的错误信息
+	Localize(locale string) string
+	// Wrap 设置错误原因，原因不会返回给客户端，只用于日志和 errors.Is/As
+	Wrap(cause error) Errno
+	// Unwrap 返回错误原因
+	Unwrap() error
+	// Is 错误码相同时认为是同一错误，用于 errors.Is
+	Is(target error) bool
+	// WithMetadata 附加用于日志的元数据
+	WithMetadata(key string, value interface{}) Errno
+	// Metadata 返回元数据
+	Metadata() map[string]interface{}
+	// WithDetails 附加返回给客户端的错误详情
+	WithDetails(details interface{}) Errno
+	// Details 返回错误详情
+	Details() interface{}
+	// Stack 返回创建错误时的调用栈，预定义的错误码没有调用栈
+	Stack() string
 }
 
 // errno 实现了Errno接口
@@ -27,10 +47,23 @@ type errno struct {
 	code    int
 	message string
 	http    int
+	// custom 是否为 WithMessage 设置的自定义错误消息
+	custom bool
+	// cause 错误原因
+	cause error
+	// metadata 用于日志的元数据
+	metadata map[string]interface{}
+	// details 返回给客户端的错误详情
+	details interface{}
+	// stack 调用栈
+	stack []uintptr
 }
 
 // Error 返回错误信息
 func (e *errno) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("错误码: %d, 错误信息: %s, 原因: %v", e.code, e.message, e.cause)
+	}
 	return fmt.Sprintf("错误码: %d, 错误信息: %s", e.code, e.message)
 }
 
@@ -49,17 +82,117 @@ func (e *errno) HTTP() int {
 	return e.http
 }
 
+// clone 复制错误，第一次从预定义的错误码派生时记录调用栈
+func (e *errno) clone() *errno {
+	c := *e
+	if c.stack == nil {
+		c.stack = callers()
+	}
+	if e.metadata != nil {
+		c.metadata = make(map[string]interface{}, len(e.metadata))
+		for k, v := range e.metadata {
+			c.metadata[k] = v
+		}
+	}
+	return &c
+}
+
 // WithMessage 设置自定义错误消息
 func (e *errno) WithMessage(message string) Errno {
-	return &errno{
-		code:    e.code,
-		message: message,
-		http:    e.http,
+	c := e.clone()
+	c.message = message
+	c.custom = true
+	return c
+}
+
+// Localize 返回指定语言的错误信息。自定义错误消息只有源语言版本，
+// 其他语言使用错误码对应的通用信息，没有对应翻译时使用默认语言
+func (e *errno) Localize(locale string) string {
+	if e.custom && strings.EqualFold(locale, SourceLocale) {
+		return e.message
 	}
+	if message, ok := lookup(locale, e.code); ok {
+		return message
+	}
+	if defaultLocale := DefaultLocale(); !strings.EqualFold(locale, defaultLocale) {
+		return e.Localize(defaultLocale)
+	}
+	return e.message
+}
+
+// Wrap 设置错误原因
+func (e *errno) Wrap(cause error) Errno {
+	c := e.clone()
+	c.cause = cause
+	return c
+}
+
+// Unwrap 返回错误原因
+func (e *errno) Unwrap() error {
+	return e.cause
+}
+
+// Is 错误码相同时认为是同一错误
+func (e *errno) Is(target error) bool {
+	t, ok := target.(Errno)
+	return ok && t.Code() == e.code
+}
+
+// WithMetadata 附加用于日志的元数据
+func (e *errno) WithMetadata(key string, value interface{}) Errno {
+	c := e.clone()
+	if c.metadata == nil {
+		c.metadata = make(map[string]interface{})
+	}
+	c.metadata[key] = value
+	return c
+}
+
+// Metadata 返回元数据
+func (e *errno) Metadata() map[string]interface{} {
+	return e.metadata
+}
+
+// WithDetails 附加返回给客户端的错误详情
+func (e *errno) WithDetails(details interface{}) Errno {
+	c := e.clone()
+	c.details = details
+	return c
+}
+
+// Details 返回错误详情
+func (e *errno) Details() interface{} {
+	return e.details
+}
+
+// Stack 返回创建错误时的调用栈
+func (e *errno) Stack() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// callers 返回调用方的调用栈，跳过 errno 包内部的调用
+func callers() []uintptr {
+	pcs := make([]uintptr, 32)
+	// 跳过 runtime.Callers、callers、clone 和 WithXxx/Wrap
+	n := runtime.Callers(4, pcs)
+	return pcs[:n]
 }
 
-// New 创建一个新的错误码
+// New 创建一个新的错误码，message 作为该错误码的源语言信息
 func New(code int, message string, http int) Errno {
+	register(SourceLocale, code, message)
 	return &errno{
 		code:    code,
 		message: message,
@@ -89,8 +222,10 @@ var (
 	ErrInvalidToken = New(10008, "无效的Token", http.StatusUnauthorized)
 	// ErrTokenExpired 表示Token已过期
 	ErrTokenExpired = New(10009, "Token已过期", http.StatusUnauthorized)
+	// ErrTokenRevoked 表示Token已被注销
+	ErrTokenRevoked = New(10010, "Token已失效", http.StatusUnauthorized)
 
-	ErrBind    = New(100010, "参数错误", http.StatusBadGateway)
+	ErrBind    = New(100010, "参数错误", http.StatusBadRequest)
 	ErrUnknown = New(99999, "未知错误", http.StatusBadRequest)
 )
 
@@ -104,12 +239,27 @@ var (
 	ErrInvalidPassword   = New(20005, "密码格式不正确", http.StatusBadRequest)
 	ErrInvalidPhone      = New(20006, "手机号格式不正确", http.StatusBadRequest)
 	ErrInvalidEmail      = New(20007, "邮箱格式不正确", http.StatusBadRequest)
+	ErrAPIKeyNotFound    = New(20008, "API密钥不存在", http.StatusNotFound)
+	ErrResetTokenInvalid = New(20009, "重置链接无效或已过期", http.StatusBadRequest)
+	ErrVerifyLinkInvalid = New(20010, "验证链接无效或已过期", http.StatusBadRequest)
+	ErrEmailNotVerified  = New(20011, "邮箱未验证", http.StatusForbidden)
+	ErrMFACodeInvalid    = New(20012, "验证码错误", http.StatusUnauthorized)
+	ErrMFARequired       = New(20013, "当前角色必须启用两步验证", http.StatusForbidden)
+	ErrMFANotEnabled     = New(20014, "未启用两步验证", http.StatusBadRequest)
+	ErrSessionNotFound   = New(20015, "会话不存在", http.StatusNotFound)
+	// 第三方登录相关错误码
+	ErrOAuthProviderNotFound = New(20016, "身份提供方不存在", http.StatusNotFound)
+	ErrOAuthStateInvalid     = New(20017, "登录请求无效或已过期，请重新登录", http.StatusBadRequest)
+	ErrOAuthLoginFailed      = New(20018, "第三方登录失败", http.StatusUnauthorized)
+	ErrIdentityNotLinked     = New(20019, "第三方账号未关联用户", http.StatusForbidden)
+	ErrIdentityAlreadyLinked = New(20020, "第三方账号已关联其他用户", http.StatusConflict)
 
 	// 博客相关错误码 (3xxxx)
 	ErrPostNotFound       = New(30001, "博客不存在", http.StatusNotFound)
 	ErrPostAccessDenied   = New(30002, "无权访问该博客", http.StatusForbidden)
 	ErrInvalidPostTitle   = New(30003, "博客标题格式不正确", http.StatusBadRequest)
 	ErrInvalidPostContent = New(30004, "博客内容格式不正确", http.StatusBadRequest)
+	ErrPostAlreadyExist   = New(30005, "博客已存在", http.StatusConflict)
 )
 
 // IsRecordNotFound 判断是否是记录不存在错误
@@ -117,22 +267,25 @@ func IsRecordNotFound(err error) bool {
 	return errors.Is(err, gorm.ErrRecordNotFound)
 }
 
-// Decode 解码错误
+// Decode 解码错误，返回错误链中的第一个 Errno，保留其创建时的调用栈。
+// 其他错误包装为 ErrNotFound 或 ErrInternalServer，此时调用栈只能指向 Decode 的调用方，
+// 因此应在错误产生处使用 Wrap 包装，如存储层将数据库错误包装为 ErrDatabase
 func Decode(err error) Errno {
 	if err == nil {
 		return OK
 	}
 
-	// 尝试转换为Errno类型
-	if e, ok := err.(Errno); ok {
+	// 尝试从错误链中获取Errno类型
+	var e Errno
+	if errors.As(err, &e) {
 		return e
 	}
 
 	// 检查是否是记录不存在错误
 	if IsRecordNotFound(err) {
-		return ErrNotFound
+		return ErrNotFound.Wrap(err)
 	}
 
-	// 默认返回内部错误
-	return ErrInternalServer.WithMessage(err.Error())
+	// 默认返回内部错误，原因只记录在日志中
+	return ErrInternalServer.Wrap(err)
 }
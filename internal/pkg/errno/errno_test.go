@@ -0,0 +1,163 @@
+package errno
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{name: "同一个错误", err: ErrUserNotFound, target: ErrUserNotFound, want: true},
+		{name: "自定义信息", err: ErrUserNotFound.WithMessage("用户 alice 不存在"), target: ErrUserNotFound, want: true},
+		{name: "目标为派生的错误", err: ErrUserNotFound, target: ErrUserNotFound.WithMessage("用户 alice 不存在"), want: true},
+		{name: "设置原因", err: ErrDatabase.Wrap(io.EOF), target: ErrDatabase, want: true},
+		{name: "元数据和详情", err: ErrUserNotFound.WithMetadata("userID", "user-1").WithDetails("alice"), target: ErrUserNotFound, want: true},
+		{name: "被 fmt.Errorf 包装", err: fmt.Errorf("查询用户: %w", ErrUserNotFound.WithMessage("用户 alice 不存在")), target: ErrUserNotFound, want: true},
+		{name: "多层包装", err: fmt.Errorf("登录: %w", fmt.Errorf("查询用户: %w", ErrUserNotFound)), target: ErrUserNotFound, want: true},
+		{name: "被其他错误码包装", err: ErrInternalServer.Wrap(ErrUserNotFound), target: ErrUserNotFound, want: true},
+		{name: "错误码不同", err: ErrUserNotFound.WithMessage("用户不存在"), target: ErrPostNotFound},
+		{name: "包装的错误码不同", err: fmt.Errorf("查询博客: %w", ErrPostNotFound), target: ErrUserNotFound},
+		{name: "目标不是错误码", err: ErrUserNotFound, target: io.EOF},
+		{name: "原因", err: fmt.Errorf("查询: %w", ErrDatabase.Wrap(io.EOF)), target: io.EOF, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v，期望 %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+// causeError 用于测试 errors.As 的错误类型
+type causeError struct{ op string }
+
+func (e *causeError) Error() string { return e.op + " failed" }
+
+func TestWrap(t *testing.T) {
+	cause := &causeError{op: "query"}
+	err := fmt.Errorf("获取用户: %w", ErrDatabase.Wrap(cause))
+
+	var target *causeError
+	if !errors.As(err, &target) || target != cause {
+		t.Errorf("errors.As 未找到原因，got %v", target)
+	}
+	e := Decode(err)
+	if e.Code() != ErrDatabase.Code() || e.Message() != "数据库错误" {
+		t.Errorf("Decode = %d %q，期望 %d %q", e.Code(), e.Message(), ErrDatabase.Code(), "数据库错误")
+	}
+	if got, want := e.Error(), "错误码: 10002, 错误信息: 数据库错误, 原因: query failed"; got != want {
+		t.Errorf("Error = %q，期望 %q", got, want)
+	}
+	// 预定义的错误码不受影响
+	if ErrDatabase.Unwrap() != nil {
+		t.Error("Wrap 不应修改预定义的错误码")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		want      Errno
+		wantCause error
+	}{
+		{name: "没有错误", err: nil, want: OK},
+		{name: "错误码", err: ErrUserNotFound, want: ErrUserNotFound},
+		{name: "被包装的错误码", err: fmt.Errorf("查询: %w", ErrUserNotFound), want: ErrUserNotFound},
+		{name: "记录不存在", err: fmt.Errorf("查询: %w", gorm.ErrRecordNotFound), want: ErrNotFound, wantCause: gorm.ErrRecordNotFound},
+		{name: "其他错误", err: io.ErrUnexpectedEOF, want: ErrInternalServer, wantCause: io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Decode(tt.err)
+			if got.Code() != tt.want.Code() || got.HTTP() != tt.want.HTTP() || got.Message() != tt.want.Message() {
+				t.Errorf("Decode = %d %d %q，期望 %d %d %q", got.Code(), got.HTTP(), got.Message(), tt.want.Code(), tt.want.HTTP(), tt.want.Message())
+			}
+			if tt.wantCause != nil && !errors.Is(got.Unwrap(), tt.wantCause) {
+				t.Errorf("Unwrap = %v，期望 %v", got.Unwrap(), tt.wantCause)
+			}
+		})
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	parent := ErrUserNotFound.WithMetadata("userID", "user-1")
+	child := parent.WithMetadata("username", "alice")
+
+	if got := parent.Metadata(); len(got) != 1 || got["userID"] != "user-1" {
+		t.Errorf("派生错误修改了原错误的元数据: %v", got)
+	}
+	if got := child.Metadata(); len(got) != 2 || got["userID"] != "user-1" || got["username"] != "alice" {
+		t.Errorf("Metadata = %v", got)
+	}
+	if ErrUserNotFound.Metadata() != nil {
+		t.Error("WithMetadata 不应修改预定义的错误码")
+	}
+
+	details := child.WithDetails([]string{"username"})
+	if got, ok := details.Details().([]string); !ok || len(got) != 1 || got[0] != "username" {
+		t.Errorf("Details = %v", details.Details())
+	}
+	if child.Details() != nil {
+		t.Error("WithDetails 不应修改原错误")
+	}
+}
+
+// 从预定义的错误码派生错误的函数，派生错误的调用栈第一帧应为这些函数
+func withMessage() Errno  { return ErrUserNotFound.WithMessage("用户 alice 不存在") }
+func wrap() Errno         { return ErrUserNotFound.Wrap(io.EOF) }
+func withMetadata() Errno { return ErrUserNotFound.WithMetadata("userID", "user-1") }
+func withDetails() Errno  { return ErrUserNotFound.WithDetails("alice") }
+
+// firstFrame 返回调用栈第一帧的函数名
+func firstFrame(t *testing.T, e Errno) string {
+	t.Helper()
+	function, _, ok := strings.Cut(e.Stack(), "\n")
+	if !ok {
+		t.Fatalf("没有调用栈: %q", e.Stack())
+	}
+	return function
+}
+
+func TestStack(t *testing.T) {
+	if got := ErrUserNotFound.Stack(); got != "" {
+		t.Errorf("预定义的错误码不应有调用栈: %q", got)
+	}
+
+	// callers 跳过 errno 包内部的调用，第一帧为调用 WithXxx/Wrap 的函数
+	tests := []struct {
+		derive func() Errno
+		want   string
+	}{
+		{derive: withMessage, want: "errno.withMessage"},
+		{derive: wrap, want: "errno.wrap"},
+		{derive: withMetadata, want: "errno.withMetadata"},
+		{derive: withDetails, want: "errno.withDetails"},
+	}
+	for _, tt := range tests {
+		e := tt.derive()
+		if got := firstFrame(t, e); !strings.HasSuffix(got, "/"+tt.want) {
+			t.Errorf("调用栈第一帧 = %s，期望 %s", got, tt.want)
+		}
+		if !strings.Contains(e.Stack(), "errno.TestStack\n") {
+			t.Errorf("调用栈中没有测试函数:\n%s", e.Stack())
+		}
+	}
+
+	// 继续派生时保留第一次派生的调用栈
+	e := withMessage()
+	if got := e.WithMetadata("userID", "user-1").Wrap(io.EOF).Stack(); got != e.Stack() {
+		t.Errorf("继续派生后调用栈 = \n%s\n期望\n%s", got, e.Stack())
+	}
+}
@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Languages 按权重从高到低返回 Accept-Language 请求头中的语言标签，忽略 * 和权重为 0 的语言
+func Languages(acceptLanguage string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var items []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			q = parsed
+		}
+		items = append(items, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].q > items[j].q })
+
+	tags := make([]string, 0, len(items))
+	for _, item := range items {
+		tags = append(tags, item.tag)
+	}
+	return tags
+}
+
+// Base 返回语言标签中的主语言，如 zh-CN 返回 zh
+func Base(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return strings.ToLower(base)
+}
+
+// Match 按顺序为 preferred 中的语言在 supported 中查找匹配的语言，先忽略大小写精确匹配，
+// 再按主语言匹配，如 en 和 en-GB 均匹配 en-US，没有匹配的语言时返回 false
+func Match(supported []string, preferred ...string) (string, bool) {
+	for _, tag := range preferred {
+		if tag == "" {
+			continue
+		}
+		tag = strings.ReplaceAll(tag, "_", "-")
+		for _, s := range supported {
+			if strings.EqualFold(s, tag) {
+				return s, true
+			}
+		}
+		for _, s := range supported {
+			if Base(s) == Base(tag) {
+				return s, true
+			}
+		}
+	}
+	return "", false
+}
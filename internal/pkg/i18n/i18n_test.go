@@ -0,0 +1,51 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLanguages(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           []string
+	}{
+		{acceptLanguage: "", want: []string{}},
+		{acceptLanguage: "zh-CN", want: []string{"zh-CN"}},
+		{acceptLanguage: "zh-CN,zh;q=0.9,en;q=0.8", want: []string{"zh-CN", "zh", "en"}},
+		{acceptLanguage: "en;q=0.5, zh-CN;q=0.8, fr", want: []string{"fr", "zh-CN", "en"}},
+		{acceptLanguage: "en ; q=0.5 , ja", want: []string{"ja", "en"}},
+		{acceptLanguage: "*, en;q=0, de;q=abc, ja;q=0.1", want: []string{"ja"}},
+	}
+	for _, tt := range tests {
+		if got := Languages(tt.acceptLanguage); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Languages(%q) = %v，期望 %v", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	supported := []string{"en-US", "zh-CN"}
+	tests := []struct {
+		name      string
+		preferred []string
+		want      string
+		wantOK    bool
+	}{
+		{name: "精确匹配", preferred: []string{"zh-CN"}, want: "zh-CN", wantOK: true},
+		{name: "忽略大小写和下划线", preferred: []string{"en_us"}, want: "en-US", wantOK: true},
+		{name: "按主语言匹配", preferred: []string{"en-GB"}, want: "en-US", wantOK: true},
+		{name: "只有主语言", preferred: []string{"zh"}, want: "zh-CN", wantOK: true},
+		{name: "按顺序使用第一个支持的语言", preferred: []string{"", "fr-FR", "zh-TW", "en-US"}, want: "zh-CN", wantOK: true},
+		{name: "不支持的语言", preferred: []string{"fr-FR", "ja"}},
+		{name: "没有语言"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Match(supported, tt.preferred...)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Match(%v) = %q, %v，期望 %q, %v", tt.preferred, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 缓存键前缀，失败计数在时间窗口内有效，锁定键的值为解除锁定的时间(Unix 毫秒)
+const (
+	userFailuresPrefix = "lockout:user:failures:"
+	userBlockedPrefix  = "lockout:user:blocked:"
+	ipFailuresPrefix   = "lockout:ip:failures:"
+	ipBlockedPrefix    = "lockout:ip:blocked:"
+)
+
+// LockedError 表示登录因失败次数过多被暂时拒绝
+type LockedError struct {
+	errno.Errno
+	retryAfter time.Duration
+}
+
+// RetryAfter 返回距离可以再次尝试登录的时间
+func (e *LockedError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// newLockedError 创建 LockedError
+func newLockedError(retryAfter time.Duration) *LockedError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return &LockedError{
+		Errno:      errno.ErrTooManyRequests.WithMessage(fmt.Sprintf("登录失败次数过多，请在 %d 秒后重试", seconds)),
+		retryAfter: retryAfter,
+	}
+}
+
+// Options 登录防护参数
+type Options struct {
+	// Enabled 是否启用登录防护
+	Enabled bool
+	// Window 统计失败次数的时间窗口，从第一次失败开始计算
+	Window time.Duration
+	// DelayAfter 同一用户名失败达到该次数后，每次失败都需要等待一段时间才能再次尝试
+	DelayAfter int
+	// BaseDelay 第一次等待的时间，之后每次失败翻倍
+	BaseDelay time.Duration
+	// MaxDelay 等待时间的上限
+	MaxDelay time.Duration
+	// MaxAttempts 同一用户名在时间窗口内失败达到该次数后锁定账号
+	MaxAttempts int
+	// IPMaxAttempts 同一客户端 IP 在时间窗口内失败达到该次数后锁定该 IP
+	IPMaxAttempts int
+	// LockDuration 锁定时长
+	LockDuration time.Duration
+}
+
+// NewOptions 根据 auth.lockout 配置创建登录防护参数，未配置的参数使用默认值
+func NewOptions(config *viper.Viper) *Options {
+	opts := &Options{
+		// 未配置 enabled 时默认启用
+		Enabled:       !config.IsSet("auth.lockout.enabled") || config.GetBool("auth.lockout.enabled"),
+		Window:        time.Duration(config.GetInt("auth.lockout.window")) * time.Second,
+		DelayAfter:    config.GetInt("auth.lockout.delayAfter"),
+		BaseDelay:     time.Duration(config.GetInt("auth.lockout.baseDelay")) * time.Second,
+		MaxDelay:      time.Duration(config.GetInt("auth.lockout.maxDelay")) * time.Second,
+		MaxAttempts:   config.GetInt("auth.lockout.maxAttempts"),
+		IPMaxAttempts: config.GetInt("auth.lockout.ipMaxAttempts"),
+		LockDuration:  time.Duration(config.GetInt("auth.lockout.lockDuration")) * time.Second,
+	}
+	if opts.Window <= 0 {
+		opts.Window = 15 * time.Minute
+	}
+	if opts.DelayAfter <= 0 {
+		opts.DelayAfter = 3
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = time.Second
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = time.Minute
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.IPMaxAttempts <= 0 {
+		opts.IPMaxAttempts = 50
+	}
+	if opts.LockDuration <= 0 {
+		opts.LockDuration = 15 * time.Minute
+	}
+	return opts
+}
+
+// Guard 按用户名和客户端 IP 统计登录失败次数，失败次数增加时逐步延长等待时间，超过阈值后暂时锁定
+type Guard struct {
+	opts   *Options
+	store  cache.Store
+	logger *log.Logger
+}
+
+// NewGuard 创建登录防护组件，store 为 Redis 存储时锁定状态在多个实例间共享
+func NewGuard(opts *Options, store cache.Store, logger *log.Logger) (*Guard, error) {
+	if opts.DelayAfter > opts.MaxAttempts {
+		return nil, fmt.Errorf("auth.lockout.delayAfter 不能大于 auth.lockout.maxAttempts")
+	}
+	return &Guard{opts: opts, store: store, logger: logger}, nil
+}
+
+// Check 判断用户名和客户端 IP 当前能否尝试登录，被拒绝时返回 *LockedError
+func (g *Guard) Check(ctx context.Context, username, ip string) error {
+	if g == nil || !g.opts.Enabled {
+		return nil
+	}
+
+	var retryAfter time.Duration
+	for _, key := range g.blockedKeys(username, ip) {
+		wait, err := g.blocked(ctx, key)
+		if err != nil {
+			return err
+		}
+		retryAfter = max(retryAfter, wait)
+	}
+	if retryAfter > 0 {
+		return newLockedError(retryAfter)
+	}
+	return nil
+}
+
+// Fail 记录一次登录失败，并根据失败次数设置等待时间或锁定
+func (g *Guard) Fail(ctx context.Context, username, ip string) error {
+	if g == nil || !g.opts.Enabled {
+		return nil
+	}
+
+	if username != "" {
+		n, err := g.store.Incr(ctx, userFailuresPrefix+username, g.opts.Window)
+		if err != nil {
+			return fmt.Errorf("记录登录失败次数失败: %v", err)
+		}
+		switch {
+		case n >= int64(g.opts.MaxAttempts):
+			if err := g.block(ctx, userBlockedPrefix+username, g.opts.LockDuration); err != nil {
+				return err
+			}
+			g.logger.Warn("账号已锁定",
+				zap.String("event", "account_locked"),
+				zap.String("username", username),
+				zap.String("ip", ip),
+				zap.Int64("failures", n),
+				zap.Duration("duration", g.opts.LockDuration),
+			)
+		case n >= int64(g.opts.DelayAfter):
+			// 超过阈值后等待时间逐次翻倍
+			delay := g.opts.BaseDelay << min(n-int64(g.opts.DelayAfter), 30)
+			if err := g.block(ctx, userBlockedPrefix+username, min(delay, g.opts.MaxDelay)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ip != "" {
+		n, err := g.store.Incr(ctx, ipFailuresPrefix+ip, g.opts.Window)
+		if err != nil {
+			return fmt.Errorf("记录登录失败次数失败: %v", err)
+		}
+		if n >= int64(g.opts.IPMaxAttempts) {
+			if err := g.block(ctx, ipBlockedPrefix+ip, g.opts.LockDuration); err != nil {
+				return err
+			}
+			g.logger.Warn("客户端IP已锁定",
+				zap.String("event", "ip_locked"),
+				zap.String("ip", ip),
+				zap.String("username", username),
+				zap.Int64("failures", n),
+				zap.Duration("duration", g.opts.LockDuration),
+			)
+		}
+	}
+	return nil
+}
+
+// Succeed 登录成功后清除用户名的失败记录，客户端 IP 的失败记录保留到时间窗口结束
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	if g == nil || !g.opts.Enabled {
+		return nil
+	}
+	return g.store.Del(ctx, userFailuresPrefix+username, userBlockedPrefix+username)
+}
+
+// blockedKeys 返回需要检查的锁定键
+func (g *Guard) blockedKeys(username, ip string) []string {
+	keys := make([]string, 0, 2)
+	if username != "" {
+		keys = append(keys, userBlockedPrefix+username)
+	}
+	if ip != "" {
+		keys = append(keys, ipBlockedPrefix+ip)
+	}
+	return keys
+}
+
+// block 设置锁定键，已有更长的锁定时保持不变
+func (g *Guard) block(ctx context.Context, key string, duration time.Duration) error {
+	wait, err := g.blocked(ctx, key)
+	if err != nil {
+		return err
+	}
+	if wait >= duration {
+		return nil
+	}
+	until := time.Now().Add(duration).UnixMilli()
+	if err := g.store.Set(ctx, key, strconv.FormatInt(until, 10), duration); err != nil {
+		return fmt.Errorf("设置登录锁定失败: %v", err)
+	}
+	return nil
+}
+
+// blocked 返回锁定键剩余的锁定时间，未锁定时返回 0
+func (g *Guard) blocked(ctx context.Context, key string) (time.Duration, error) {
+	value, err := g.store.Get(ctx, key)
+	if errors.Is(err, cache.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询登录锁定状态失败: %v", err)
+	}
+	until, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return max(time.Until(time.UnixMilli(until)), 0), nil
+}
@@ -0,0 +1,152 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+)
+
+func newTestGuard(t *testing.T) *Guard {
+	t.Helper()
+	opts := &Options{
+		Enabled:       true,
+		Window:        time.Minute,
+		DelayAfter:    2,
+		BaseDelay:     10 * time.Second,
+		MaxDelay:      40 * time.Second,
+		MaxAttempts:   6,
+		IPMaxAttempts: 8,
+		LockDuration:  time.Hour,
+	}
+	g, err := NewGuard(opts, cache.NewMemoryStore(), &log.Logger{Logger: zap.NewNop()})
+	if err != nil {
+		t.Fatalf("NewGuard: %v", err)
+	}
+	return g
+}
+
+// retryAfter 返回 Check 拒绝时的等待时间，未拒绝时返回 0
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	if err == nil {
+		return 0
+	}
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("Check 返回了非 LockedError: %v", err)
+	}
+	if !errors.Is(err, errno.ErrTooManyRequests) {
+		t.Fatalf("LockedError 应为 ErrTooManyRequests: %v", err)
+	}
+	return locked.RetryAfter()
+}
+
+func TestGuardEscalation(t *testing.T) {
+	// 第 n 次失败之后 Check 返回的等待时间范围
+	tests := []struct {
+		failures int
+		min, max time.Duration
+	}{
+		{failures: 1, min: 0, max: 0},
+		{failures: 2, min: 9 * time.Second, max: 10 * time.Second},
+		{failures: 3, min: 19 * time.Second, max: 20 * time.Second},
+		{failures: 4, min: 39 * time.Second, max: 40 * time.Second},
+		// 等待时间不超过 MaxDelay
+		{failures: 5, min: 39 * time.Second, max: 40 * time.Second},
+		// 达到 MaxAttempts 后锁定账号
+		{failures: 6, min: 59 * time.Minute, max: time.Hour},
+	}
+
+	ctx := context.Background()
+	g := newTestGuard(t)
+	failures := 0
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("failures=%d", tt.failures), func(t *testing.T) {
+			for ; failures < tt.failures; failures++ {
+				if err := g.Fail(ctx, "alice", fmt.Sprintf("10.0.0.%d", failures)); err != nil {
+					t.Fatalf("Fail: %v", err)
+				}
+			}
+			wait := retryAfter(t, g.Check(ctx, "alice", "10.0.1.1"))
+			if wait < tt.min || wait > tt.max {
+				t.Errorf("等待时间 = %v，期望在 [%v, %v] 之间", wait, tt.min, tt.max)
+			}
+		})
+	}
+
+	// 其他用户不受影响
+	if err := g.Check(ctx, "bob", "10.0.1.1"); err != nil {
+		t.Errorf("其他用户被拒绝: %v", err)
+	}
+}
+
+func TestGuardSucceedResetsUser(t *testing.T) {
+	ctx := context.Background()
+	g := newTestGuard(t)
+	for range 3 {
+		if err := g.Fail(ctx, "alice", "10.0.0.1"); err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+	}
+	if err := g.Check(ctx, "alice", ""); err == nil {
+		t.Fatal("连续失败后应需要等待")
+	}
+	if err := g.Succeed(ctx, "alice"); err != nil {
+		t.Fatalf("Succeed: %v", err)
+	}
+	if err := g.Check(ctx, "alice", ""); err != nil {
+		t.Errorf("登录成功后仍被拒绝: %v", err)
+	}
+}
+
+func TestGuardIPLockout(t *testing.T) {
+	ctx := context.Background()
+	g := newTestGuard(t)
+	// 同一 IP 尝试不同用户名，每个用户名都未达到等待阈值
+	for i := range 8 {
+		if err := g.Fail(ctx, fmt.Sprintf("user%d", i), "10.0.0.1"); err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		ip       string
+		locked   bool
+	}{
+		{name: "锁定的 IP", username: "carol", ip: "10.0.0.1", locked: true},
+		{name: "其他 IP", username: "carol", ip: "10.0.0.2", locked: false},
+		{name: "未达到阈值的用户名", username: "user0", ip: "10.0.0.2", locked: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := g.Check(ctx, tt.username, tt.ip)
+			if locked := retryAfter(t, err) > 0; locked != tt.locked {
+				t.Errorf("locked = %v，期望 %v", locked, tt.locked)
+			}
+		})
+	}
+}
+
+func TestGuardDisabled(t *testing.T) {
+	ctx := context.Background()
+	g := newTestGuard(t)
+	g.opts.Enabled = false
+	for range 10 {
+		if err := g.Fail(ctx, "alice", "10.0.0.1"); err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+	}
+	if err := g.Check(ctx, "alice", "10.0.0.1"); err != nil {
+		t.Errorf("未启用时不应拒绝: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package mfa
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/spf13/viper"
+)
+
+const (
+	// period TOTP 验证码的时间步长
+	period = 30 * time.Second
+	// skew 允许客户端与服务端时钟相差的时间步数
+	skew = 1
+	// qrCodeSize 二维码图片的边长(像素)
+	qrCodeSize = 256
+	// recoveryCodeLength 恢复码的随机字节数，编码后为 10 个字符
+	recoveryCodeLength = 6
+	// EncryptionKeyEnv 未配置 auth.mfa.encryptionKey 时读取加密密钥的环境变量
+	EncryptionKeyEnv = "EASYBLOG_MFA_ENCRYPTION_KEY"
+)
+
+// totpOptions 验证码参数，与主流身份验证器应用的默认值保持一致
+var totpOptions = totp.ValidateOpts{
+	Period:    uint(period / time.Second),
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// Enrollment 表示一次两步验证注册生成的密钥
+type Enrollment struct {
+	// Secret Base32 编码的 TOTP 密钥，用于手动输入
+	Secret string
+	// URL otpauth:// 格式的密钥地址
+	URL string
+	// QRCode 密钥地址对应的二维码 PNG 图片
+	QRCode []byte
+}
+
+// Manager 负责生成和校验 TOTP 验证码，并加密保存 TOTP 密钥
+type Manager struct {
+	issuer string
+	aead   cipher.AEAD
+}
+
+// ErrEncryptionKeyNotSet 未配置 auth.mfa.encryptionKey 且未设置环境变量 EASYBLOG_MFA_ENCRYPTION_KEY
+var ErrEncryptionKeyNotSet = errors.New("未设置两步验证加密密钥")
+
+// NewManager 根据 auth.mfa 配置创建两步验证管理器。
+// auth.mfa.encryptionKey 为 Base64 编码的 32 字节密钥，用于使用 AES-256-GCM 加密 TOTP 密钥，
+// 为空时读取环境变量 EASYBLOG_MFA_ENCRYPTION_KEY，两者均未设置时返回 ErrEncryptionKeyNotSet
+func NewManager(config *viper.Viper) (*Manager, error) {
+	encoded := config.GetString("auth.mfa.encryptionKey")
+	if encoded == "" {
+		encoded = os.Getenv(EncryptionKeyEnv)
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("%w，请配置 auth.mfa.encryptionKey 或环境变量 %s", ErrEncryptionKeyNotSet, EncryptionKeyEnv)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("解析 auth.mfa.encryptionKey 失败: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("auth.mfa.encryptionKey 必须为 Base64 编码的 32 字节密钥")
+	}
+	return newManager(config, key)
+}
+
+// NewEphemeralManager 使用随机生成的加密密钥创建两步验证管理器。
+// 密钥只保存在进程内存中，重启后无法解密之前保存的 TOTP 密钥，只适用于数据同样不会持久化的内存存储
+func NewEphemeralManager(config *viper.Viper) (*Manager, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("生成两步验证加密密钥失败: %v", err)
+	}
+	return newManager(config, key)
+}
+
+// newManager 使用 32 字节的加密密钥创建两步验证管理器
+func newManager(config *viper.Viper, key []byte) (*Manager, error) {
+	issuer := config.GetString("auth.mfa.issuer")
+	if issuer == "" {
+		issuer = "easyblog"
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密组件失败: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密组件失败: %v", err)
+	}
+
+	return &Manager{issuer: issuer, aead: aead}, nil
+}
+
+// Enroll 为用户生成新的 TOTP 密钥及其二维码
+func (m *Manager) Enroll(account string) (*Enrollment, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      m.issuer,
+		AccountName: account,
+		Period:      totpOptions.Period,
+		Digits:      totpOptions.Digits,
+		Algorithm:   totpOptions.Algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("生成 TOTP 密钥失败: %v", err)
+	}
+
+	img, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("生成二维码失败: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("生成二维码失败: %v", err)
+	}
+
+	return &Enrollment{Secret: key.Secret(), URL: key.URL(), QRCode: buf.Bytes()}, nil
+}
+
+// Validate 校验验证码，成功时返回验证码对应的时间步。
+// 时间步不大于 lastStep 的验证码视为已使用过，防止验证码在有效期内被重放
+func (m *Manager) Validate(secret, code string, lastStep int64) (int64, bool) {
+	current := time.Now().Unix() / int64(totpOptions.Period)
+	for _, step := range []int64{current, current - skew, current + skew} {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*int64(totpOptions.Period), 0), totpOptions)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// Encrypt 加密 TOTP 密钥，返回 Base64 编码的随机数和密文
+func (m *Manager) Encrypt(secret string) (string, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("加密 TOTP 密钥失败: %v", err)
+	}
+	sealed := m.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 加密的 TOTP 密钥
+func (m *Manager) Decrypt(encrypted string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < m.aead.NonceSize() {
+		return "", fmt.Errorf("解密 TOTP 密钥失败: 密文格式不正确")
+	}
+	nonce, ciphertext := sealed[:m.aead.NonceSize()], sealed[m.aead.NonceSize():]
+	secret, err := m.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("解密 TOTP 密钥失败: %v", err)
+	}
+	return string(secret), nil
+}
+
+// GenerateRecoveryCodes 生成 n 个一次性恢复码，格式为 xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("生成恢复码失败: %v", err)
+		}
+		code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode 计算恢复码的哈希，忽略大小写和分隔符
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,178 @@
+package mfa
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/spf13/viper"
+)
+
+// testKey 测试使用的 32 字节加密密钥
+var testKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	config := viper.New()
+	config.Set("auth.mfa.encryptionKey", testKey)
+	m, err := NewManager(config)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m
+}
+
+// codeAt 生成时间步 step 对应的验证码
+func codeAt(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	code, err := totp.GenerateCodeCustom(secret, time.Unix(step*int64(period/time.Second), 0), totpOptions)
+	if err != nil {
+		t.Fatalf("GenerateCodeCustom: %v", err)
+	}
+	return code
+}
+
+func TestValidateReplay(t *testing.T) {
+	m := newTestManager(t)
+	enrollment, err := m.Enroll("alice")
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+
+	// 临近时间步边界时等到下一个时间步，避免执行过程中当前时间步发生变化
+	now := time.Now()
+	if next := now.Truncate(period).Add(period); next.Sub(now) < 2*time.Second {
+		time.Sleep(next.Sub(now))
+	}
+	current := time.Now().Unix() / int64(period/time.Second)
+
+	tests := []struct {
+		name     string
+		offset   int64
+		lastStep int64
+		wantOK   bool
+	}{
+		{name: "当前验证码", offset: 0, lastStep: 0, wantOK: true},
+		{name: "重放当前验证码", offset: 0, lastStep: current, wantOK: false},
+		{name: "上一个时间步的验证码", offset: -1, lastStep: current - 2, wantOK: true},
+		{name: "早于最近使用的时间步", offset: -1, lastStep: current, wantOK: false},
+		{name: "下一个时间步的验证码", offset: 1, lastStep: current, wantOK: true},
+		{name: "重放下一个时间步的验证码", offset: 1, lastStep: current + 1, wantOK: false},
+		{name: "超出允许的时钟偏差", offset: -2, lastStep: 0, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := m.Validate(enrollment.Secret, codeAt(t, enrollment.Secret, current+tt.offset), tt.lastStep)
+			if ok != tt.wantOK {
+				t.Fatalf("Validate ok = %v，期望 %v", ok, tt.wantOK)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("Validate 返回时间步 %d，期望 %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	m := newTestManager(t)
+	ciphertext, err := m.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if strings.Contains(ciphertext, "JBSWY3DPEHPK3PXP") {
+		t.Fatal("密文中不应包含明文密钥")
+	}
+	plaintext, err := m.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if plaintext != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Decrypt = %q，期望 %q", plaintext, "JBSWY3DPEHPK3PXP")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("恢复码 %q 格式不是 xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("恢复码 %q 重复", code)
+		}
+		seen[code] = true
+	}
+	if len(codes) != 10 {
+		t.Errorf("生成了 %d 个恢复码，期望 10", len(codes))
+	}
+
+	// 校验时忽略大小写、分隔符和首尾空白
+	if HashRecoveryCode(" "+strings.ToUpper(codes[0])+" ") != HashRecoveryCode(strings.ReplaceAll(codes[0], "-", "")) {
+		t.Error("恢复码的哈希应忽略大小写和分隔符")
+	}
+	if HashRecoveryCode(codes[0]) == HashRecoveryCode(codes[1]) {
+		t.Error("不同恢复码的哈希不应相同")
+	}
+}
+
+func TestNewManagerEncryptionKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		wantErr bool
+	}{
+		{name: "未设置密钥", wantErr: true},
+		{name: "从配置读取", config: testKey},
+		{name: "从环境变量读取", env: testKey},
+		{name: "配置优先于环境变量", config: testKey, env: "not base64!"},
+		{name: "环境变量中的密钥长度错误", env: base64.StdEncoding.EncodeToString([]byte("short")), wantErr: true},
+		{name: "密钥长度错误", config: base64.StdEncoding.EncodeToString([]byte("short")), wantErr: true},
+		{name: "密钥不是 Base64", config: "not base64!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EncryptionKeyEnv, tt.env)
+			config := viper.New()
+			config.Set("auth.mfa.encryptionKey", tt.config)
+			if _, err := NewManager(config); (err != nil) != tt.wantErr {
+				t.Errorf("NewManager err = %v，期望返回错误 %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Setenv(EncryptionKeyEnv, "")
+	if _, err := NewManager(viper.New()); !errors.Is(err, ErrEncryptionKeyNotSet) {
+		t.Errorf("未设置密钥时 NewManager err = %v，期望 %v", err, ErrEncryptionKeyNotSet)
+	}
+}
+
+func TestNewEphemeralManager(t *testing.T) {
+	first, err := NewEphemeralManager(viper.New())
+	if err != nil {
+		t.Fatalf("NewEphemeralManager: %v", err)
+	}
+	second, err := NewEphemeralManager(viper.New())
+	if err != nil {
+		t.Fatalf("NewEphemeralManager: %v", err)
+	}
+
+	encrypted, err := first.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if secret, err := first.Decrypt(encrypted); err != nil || secret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Decrypt = %q, %v", secret, err)
+	}
+	// 每次生成的密钥不同，重启后无法解密之前保存的密钥
+	if _, err := second.Decrypt(encrypted); err == nil {
+		t.Error("使用其他临时密钥解密应返回错误")
+	}
+}
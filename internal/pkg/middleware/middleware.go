@@ -1,16 +1,29 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/lichenglife/easyblog/internal/pkg/authz"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/core"
+	"github.com/lichenglife/easyblog/internal/pkg/cors"
 	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/i18n"
 	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/lichenglife/easyblog/internal/pkg/ratelimit"
+	"github.com/lichenglife/easyblog/internal/pkg/token"
 )
 
 // RequestID 生成请求ID
@@ -26,7 +39,41 @@ func RequestID() gin.HandlerFunc {
 	}
 }
 
-// Logger 记录请求日志
+// ClientInfo 将客户端 IP 和 User-Agent 保存到请求的 context 中，供登录防护和会话记录使用
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := contextx.WithClientIP(c.Request.Context(), c.ClientIP())
+		ctx = contextx.WithUserAgent(ctx, c.Request.UserAgent())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// localeParam 查询参数和 Cookie 中指定响应语言的名称
+const localeParam = "lang"
+
+// Locale 选择响应语言并保存到请求的 context 中，依次使用 lang 查询参数、lang Cookie(用户偏好)
+// 和 Accept-Language 请求头，均不支持时使用默认语言
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		preferred := []string{c.Query(localeParam)}
+		if cookie, err := c.Cookie(localeParam); err == nil {
+			preferred = append(preferred, cookie)
+		}
+		preferred = append(preferred, i18n.Languages(c.GetHeader("Accept-Language"))...)
+
+		locale, ok := i18n.Match(errno.Locales(), preferred...)
+		if !ok {
+			locale = errno.DefaultLocale()
+		}
+		c.Request = c.Request.WithContext(contextx.WithLocale(c.Request.Context(), locale))
+		c.Header("Content-Language", locale)
+		c.Header("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// Logger 记录请求日志，服务端错误按 Error 级别记录，并附带错误原因、元数据和调用栈
 func Logger(logger *log.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -36,80 +83,305 @@ func Logger(logger *log.Logger) gin.HandlerFunc {
 		c.Next()
 
 		cost := time.Since(start)
-		logger.Info(path,
-			zap.Int("status", c.Writer.Status()),
+		status := c.Writer.Status()
+		fields := []zap.Field{
+			zap.Int("status", status),
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
 			zap.String("query", query),
 			zap.String("ip", c.ClientIP()),
 			zap.String("user-agent", c.Request.UserAgent()),
+			zap.String("requestID", c.GetString("requestID")),
 			zap.String("errors", c.Errors.ByType(gin.ErrorTypePrivate).String()),
 			zap.Duration("cost", cost),
-		)
+		}
+		if status < http.StatusInternalServerError {
+			logger.Info(path, fields...)
+			return
+		}
+
+		for _, ginErr := range c.Errors.ByType(gin.ErrorTypePrivate) {
+			var e errno.Errno
+			if !errors.As(ginErr.Err, &e) {
+				continue
+			}
+			if metadata := e.Metadata(); len(metadata) > 0 {
+				fields = append(fields, zap.Any("metadata", metadata))
+			}
+			if stack := e.Stack(); stack != "" {
+				fields = append(fields, zap.String("stack", stack))
+			}
+			break
+		}
+		logger.Error(path, fields...)
 	}
 }
 
-// Recovery 恢复panic
+// Recovery 恢复panic，记录调用栈并返回 ErrInternalServer
 func Recovery(logger *log.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
-			if err := recover(); err != nil {
+			if r := recover(); r != nil {
 				logger.Error("panic recovered",
-					zap.Any("error", err),
+					zap.Any("error", r),
 					zap.String("requestID", c.GetString("requestID")),
+					zap.ByteString("stack", debug.Stack()),
 				)
 
-				// 根据错误类型返回友好的错误信息
-				var errMsg string
-				switch e := err.(type) {
-				case *validator.ValidationErrors:
-					errMsg = "请求参数验证失败"
-				case error:
-					errMsg = e.Error()
-				default:
-					errMsg = "服务器内部错误"
+				err, ok := r.(error)
+				if !ok {
+					err = fmt.Errorf("%v", r)
 				}
-
-				c.JSON(http.StatusOK, gin.H{
-					"code":    errno.ErrInvalidParams.Code(),
-					"message": errMsg,
-				})
-				c.Abort()
+				core.WriteResponse(c, errno.ErrInternalServer.Wrap(fmt.Errorf("panic: %w", err)), nil)
 			}
 		}()
 		c.Next()
 	}
 }
 
-// CORS 跨域中间件
-func CORS() gin.HandlerFunc {
+// CORS 跨域中间件，来源匹配时原样返回该来源并设置 Vary: Origin，
+// 预检请求在此直接响应，来源、方法或请求头不被允许时返回 403
+func CORS(opts *cors.Options) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		if !opts.Enabled() {
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !opts.AllowAll {
+			header.Add("Vary", "Origin")
+		}
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+		// 非跨域请求
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		requestedHeaders := c.GetHeader("Access-Control-Request-Headers")
+		if !opts.AllowOrigin(origin) ||
+			preflight && (!opts.AllowMethod(c.GetHeader("Access-Control-Request-Method")) || !opts.AllowHeaders(requestedHeaders)) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if opts.AllowAll {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			for k, v := range opts.PreflightHeaders(requestedHeaders) {
+				header[k] = v
+			}
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
+		if len(opts.ExposedHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(opts.ExposedHeaders, ", "))
+		}
 		c.Next()
 	}
 }
 
-// RateLimit 限流中间件
-func RateLimit() gin.HandlerFunc {
+// RateLimit 限流中间件，已认证的调用方按 API 密钥或用户 ID 限流，其余按客户端 IP 限流，
+// 需要在 Auth 中间件之后使用才能识别调用方身份。限流器出错时放行请求
+func RateLimit(limiter ratelimit.Limiter, opts *ratelimit.Options, logger *log.Logger) gin.HandlerFunc {
+	return rateLimit(limiter, opts, logger, clientKey)
+}
+
+// IPRateLimit 按客户端 IP 限流的中间件，用于不需要认证的接口
+func IPRateLimit(limiter ratelimit.Limiter, opts *ratelimit.Options, logger *log.Logger) gin.HandlerFunc {
+	return rateLimit(limiter, opts, logger, ipKey)
+}
+
+// AuthFailureLimit 按客户端 IP 限制认证失败次数的中间件，需要在 Auth 中间件之前使用。
+// 只有未能通过认证的请求消耗配额，同一 IP 后面的已认证用户互不影响；配额用完后该 IP 的请求在认证前直接被拒绝，
+// 拦截猜测令牌和 API 密钥的请求。限流器出错时放行请求
+func AuthFailureLimit(limiter ratelimit.Limiter, opts *ratelimit.Options, logger *log.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: 实现限流逻辑
+		if opts == nil || !opts.Enabled {
+			c.Next()
+			return
+		}
+
+		name, rule := opts.Match(c.Request.Method, c.FullPath())
+		key := "authfail:" + name + ":" + ipKey(c)
+		result, err := limiter.Peek(c.Request.Context(), key, rule)
+		if err != nil {
+			logger.Error("限流判断失败", zap.String("key", key), zap.Error(err))
+		} else if !result.Allowed {
+			core.WriteResponse(c, ratelimit.NewLimitedError(result.RetryAfter), nil)
+			return
+		}
+
 		c.Next()
+
+		// Auth 通过后会将调用方身份注入 context，没有身份说明认证失败
+		if _, ok := contextx.PrincipalFrom(c.Request.Context()); ok {
+			return
+		}
+		if _, err := limiter.Allow(c.Request.Context(), key, rule); err != nil {
+			logger.Error("记录认证失败次数失败", zap.String("key", key), zap.Error(err))
+		}
 	}
 }
 
-// Auth 认证中间件
-// func Auth() gin.HandlerFunc {
-// 	return func(c *gin.Context) {
-// 		// TODO: 实现认证逻辑
-// 		c.Next()
-// 	}
-// }
+// rateLimit 按 keyFunc 返回的调用方标识限流
+func rateLimit(limiter ratelimit.Limiter, opts *ratelimit.Options, logger *log.Logger, keyFunc func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts == nil || !opts.Enabled {
+			c.Next()
+			return
+		}
+
+		name, rule := opts.Match(c.Request.Method, c.FullPath())
+		key := name + ":" + keyFunc(c)
+		result, err := limiter.Allow(c.Request.Context(), key, rule)
+		if err != nil {
+			logger.Error("限流判断失败", zap.String("key", key), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		// RateLimit-* 响应头，参考 IETF RateLimit header fields 草案
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(rule.Burst))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))))
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", rule.Rate, int(rule.Period.Seconds()), rule.Burst))
+		if !result.Allowed {
+			core.WriteResponse(c, ratelimit.NewLimitedError(result.RetryAfter), nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientKey 返回限流使用的调用方标识
+func clientKey(c *gin.Context) string {
+	if principal, ok := contextx.PrincipalFrom(c.Request.Context()); ok {
+		if principal.IsAPIKey() {
+			return "apikey:" + principal.APIKeyID
+		}
+		return "user:" + principal.UserID
+	}
+	return ipKey(c)
+}
+
+// ipKey 返回按客户端 IP 限流使用的标识
+func ipKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// APIKeyAuthenticator 校验 API 密钥并返回调用方身份
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*contextx.Principal, error)
+}
+
+// Auth 认证中间件，校验 Authorization: Bearer <token> 请求头，
+// 并将调用方身份注入 gin.Context 和 context.Context。
+// 以 token.APIKeyPrefix 开头的令牌使用 apiKeys 校验，其余按 JWT 访问令牌校验
+func Auth(tokens *token.Manager, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			core.WriteResponse(c, errno.ErrUnauthorized, nil)
+			return
+		}
+
+		var (
+			principal *contextx.Principal
+			err       error
+		)
+		if strings.HasPrefix(tokenString, token.APIKeyPrefix) && apiKeys != nil {
+			principal, err = apiKeys.Authenticate(c.Request.Context(), tokenString)
+		} else {
+			principal, err = verifyAccessToken(c.Request.Context(), tokens, tokenString)
+		}
+		if err != nil {
+			core.WriteResponse(c, err, nil)
+			return
+		}
+
+		c.Set("userID", principal.UserID)
+		c.Set("username", principal.Username)
+		c.Set("role", principal.Role)
+		c.Request = c.Request.WithContext(contextx.WithPrincipal(c.Request.Context(), principal))
+
+		c.Next()
+	}
+}
+
+// Authorize 授权中间件，根据调用方角色和访问控制策略判断能否访问当前路由，
+// 需要在 Auth 中间件之后使用
+func Authorize(enforcer *authz.Enforcer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := contextx.PrincipalFrom(c.Request.Context())
+		if !ok {
+			core.WriteResponse(c, errno.ErrUnauthorized, nil)
+			return
+		}
+
+		if !enforcer.Enforce(principal.Role, c.Request.Method, c.FullPath()) {
+			core.WriteResponse(c, errno.ErrForbidden, nil)
+			return
+		}
+		// API 密钥还需要拥有与当前路由匹配的授权范围
+		if principal.IsAPIKey() && !enforcer.EnforceScopes(principal.Scopes, c.Request.Method, c.FullPath()) {
+			core.WriteResponse(c, errno.ErrForbidden.WithMessage("API密钥的授权范围不足"), nil)
+			return
+		}
+		// 策略要求启用两步验证的角色，只有使用验证码登录后才能访问豁免路由以外的接口
+		if !principal.IsAPIKey() && !principal.MFA && enforcer.MFARequired(principal.Role) && !enforcer.MFAExempt(c.FullPath()) {
+			core.WriteResponse(c, errno.ErrMFARequired, nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// verifyAccessToken 校验 JWT 访问令牌并返回调用方身份
+func verifyAccessToken(ctx context.Context, tokens *token.Manager, tokenString string) (*contextx.Principal, error) {
+	claims, err := tokens.Verify(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	return &contextx.Principal{
+		UserID:    claims.Subject,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		TokenID:   claims.ID,
+		Family:    claims.Family,
+		ExpiresAt: claims.ExpiresAt.Time,
+		MFA:       claims.MFA,
+	}, nil
+}
+
+// bearerToken 从 Authorization 请求头中解析 Bearer 令牌
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	scheme, tokenString, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || tokenString == "" {
+		return "", false
+	}
+	return strings.TrimSpace(tokenString), true
+}
@@ -0,0 +1,418 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/lichenglife/easyblog/internal/pkg/authz"
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/core"
+	"github.com/lichenglife/easyblog/internal/pkg/cors"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/lichenglife/easyblog/internal/pkg/ratelimit"
+	"github.com/lichenglife/easyblog/internal/pkg/token"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestTokens 创建使用内存缓存的令牌管理器
+func newTestTokens(t *testing.T) *token.Manager {
+	t.Helper()
+	config := viper.New()
+	config.Set("jwt.secret", "easyblog-test-secret-0123456789abcdef")
+	tokens, err := token.NewManager(config, cache.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return tokens
+}
+
+// accessToken 为指定角色的用户签发访问令牌，mfa 表示是否通过两步验证登录
+func accessToken(t *testing.T, tokens *token.Manager, role string, mfa bool) string {
+	t.Helper()
+	pair, err := tokens.Issue(context.Background(), token.Identity{UserID: "user-" + role, Username: role, Role: role, MFA: mfa})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return pair.AccessToken
+}
+
+// fakeAPIKeys 以密钥明文为键的 API 密钥认证器
+type fakeAPIKeys map[string]*contextx.Principal
+
+func (f fakeAPIKeys) Authenticate(_ context.Context, key string) (*contextx.Principal, error) {
+	if principal, ok := f[key]; ok {
+		return principal, nil
+	}
+	return nil, errno.ErrInvalidToken
+}
+
+func TestAuthorize(t *testing.T) {
+	tokens := newTestTokens(t)
+	enforcer, err := authz.NewEnforcer(viper.New())
+	if err != nil {
+		t.Fatalf("NewEnforcer: %v", err)
+	}
+	apiKeys := fakeAPIKeys{
+		token.APIKeyPrefix + "read":   {UserID: "user-1", Role: authz.RoleAuthor, APIKeyID: "read", Scopes: []string{authz.ScopePostsRead}},
+		token.APIKeyPrefix + "write":  {UserID: "user-1", Role: authz.RoleAuthor, APIKeyID: "write", Scopes: []string{authz.ScopePostsWrite}},
+		token.APIKeyPrefix + "reader": {UserID: "user-2", Role: authz.RoleReader, APIKeyID: "reader", Scopes: []string{authz.ScopePostsWrite}},
+		token.APIKeyPrefix + "admin":  {UserID: "user-3", Role: authz.RoleAdmin, APIKeyID: "admin", Scopes: []string{authz.ScopePostsRead}},
+		token.APIKeyPrefix + "users":  {UserID: "user-3", Role: authz.RoleAdmin, APIKeyID: "users", Scopes: []string{authz.ScopeUsersAdmin}},
+	}
+
+	engine := gin.New()
+	authed := engine.Group("/v1", Auth(tokens, apiKeys), Authorize(enforcer))
+	authed.GET("/user", func(c *gin.Context) { c.Status(http.StatusOK) })
+	authed.GET("/user/info", func(c *gin.Context) { c.Status(http.StatusOK) })
+	authed.POST("/post", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name          string
+		authorization string
+		method        string
+		path          string
+		status        int
+	}{
+		{name: "未携带令牌", path: "/v1/user/info", status: http.StatusUnauthorized},
+		{name: "无效的令牌", authorization: "Bearer invalid", path: "/v1/user/info", status: http.StatusUnauthorized},
+		{name: "读者获取当前用户信息", authorization: "Bearer " + accessToken(t, tokens, authz.RoleReader, false), path: "/v1/user/info", status: http.StatusOK},
+		{name: "读者获取用户列表", authorization: "Bearer " + accessToken(t, tokens, authz.RoleReader, false), path: "/v1/user", status: http.StatusForbidden},
+		{name: "编辑获取用户列表", authorization: "Bearer " + accessToken(t, tokens, authz.RoleEditor, false), path: "/v1/user", status: http.StatusForbidden},
+		{name: "管理员获取用户列表", authorization: "Bearer " + accessToken(t, tokens, authz.RoleAdmin, true), path: "/v1/user", status: http.StatusOK},
+		{name: "管理员未通过两步验证获取用户列表", authorization: "Bearer " + accessToken(t, tokens, authz.RoleAdmin, false), path: "/v1/user", status: http.StatusForbidden},
+		{name: "管理员未通过两步验证获取当前用户信息", authorization: "Bearer " + accessToken(t, tokens, authz.RoleAdmin, false), path: "/v1/user/info", status: http.StatusOK},
+		{name: "无效的 API 密钥", authorization: "Bearer " + token.APIKeyPrefix + "unknown", method: http.MethodPost, path: "/v1/post", status: http.StatusUnauthorized},
+		{name: "posts:read 发布帖子", authorization: "Bearer " + token.APIKeyPrefix + "read", method: http.MethodPost, path: "/v1/post", status: http.StatusForbidden},
+		{name: "posts:write 发布帖子", authorization: "Bearer " + token.APIKeyPrefix + "write", method: http.MethodPost, path: "/v1/post", status: http.StatusOK},
+		{name: "授权范围不能超过角色权限", authorization: "Bearer " + token.APIKeyPrefix + "reader", method: http.MethodPost, path: "/v1/post", status: http.StatusForbidden},
+		{name: "API 密钥不受两步验证策略限制", authorization: "Bearer " + token.APIKeyPrefix + "users", path: "/v1/user", status: http.StatusOK},
+		{name: "API 密钥不能访问授权范围以外的路由", authorization: "Bearer " + token.APIKeyPrefix + "admin", path: "/v1/user", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("状态码 = %d，期望 %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	opts := &ratelimit.Options{
+		Enabled: true,
+		Default: ratelimit.Rule{Rate: 1, Period: time.Minute, Burst: 2},
+	}
+	engine := gin.New()
+	engine.GET("/", RateLimit(ratelimit.NewLocalLimiter(), opts, &log.Logger{Logger: zap.NewNop()}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{name: "第一次请求", remoteAddr: "203.0.113.7:5000", status: http.StatusOK, remaining: "1", reset: "60"},
+		{name: "用完突发配额", remoteAddr: "203.0.113.7:5000", status: http.StatusOK, remaining: "0", reset: "120"},
+		{name: "超过限制", remoteAddr: "203.0.113.7:5000", status: http.StatusTooManyRequests, remaining: "0", reset: "120", retryAfter: "60"},
+		{name: "其他 IP 不受影响", remoteAddr: "198.51.100.1:5000", status: http.StatusOK, remaining: "1", reset: "60"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("状态码 = %d，期望 %d", w.Code, tt.status)
+			}
+			want := map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": tt.remaining,
+				"RateLimit-Reset":     tt.reset,
+				"RateLimit-Policy":    "1;w=60;burst=2",
+				"Retry-After":         tt.retryAfter,
+			}
+			for header, value := range want {
+				if got := w.Header().Get(header); got != value {
+					t.Errorf("%s = %q，期望 %q", header, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthFailureLimit(t *testing.T) {
+	tokens := newTestTokens(t)
+	opts := &ratelimit.Options{
+		Enabled: true,
+		Default: ratelimit.Rule{Rate: 1, Period: time.Minute, Burst: 2},
+	}
+	engine := gin.New()
+	engine.GET("/", AuthFailureLimit(ratelimit.NewLocalLimiter(), opts, &log.Logger{Logger: zap.NewNop()}), Auth(tokens, nil), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	valid := "Bearer " + accessToken(t, tokens, authz.RoleReader, false)
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		authorization string
+		status        int
+		retryAfter    string
+	}{
+		{name: "认证通过", remoteAddr: "203.0.113.7:5000", authorization: valid, status: http.StatusOK},
+		{name: "认证通过的请求不消耗配额", remoteAddr: "203.0.113.7:5000", authorization: valid, status: http.StatusOK},
+		{name: "同一 IP 的其他认证通过的请求", remoteAddr: "203.0.113.7:5000", authorization: valid, status: http.StatusOK},
+		{name: "第一次认证失败", remoteAddr: "203.0.113.7:5000", authorization: "Bearer invalid", status: http.StatusUnauthorized},
+		{name: "未携带令牌同样计入失败次数", remoteAddr: "203.0.113.7:5000", status: http.StatusUnauthorized},
+		{name: "超过失败次数后在认证前拒绝", remoteAddr: "203.0.113.7:5000", authorization: "Bearer invalid", status: http.StatusTooManyRequests, retryAfter: "60"},
+		{name: "其他 IP 不受影响", remoteAddr: "198.51.100.1:5000", authorization: "Bearer invalid", status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("状态码 = %d，期望 %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Retry-After = %q，期望 %q", got, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	config := viper.New()
+	config.Set("cors.allowedOrigins", []string{"https://*.easyblog.com"})
+	config.Set("cors.allowedMethods", []string{"GET", "POST"})
+	config.Set("cors.allowedHeaders", []string{"Authorization", "Content-Type"})
+	config.Set("cors.exposedHeaders", []string{"X-Request-ID"})
+	config.Set("cors.allowCredentials", true)
+	config.Set("cors.maxAge", 600)
+	opts, err := cors.NewOptions(config)
+	if err != nil {
+		t.Fatalf("NewOptions: %v", err)
+	}
+
+	engine := gin.New()
+	engine.Use(CORS(opts))
+	engine.GET("/v1/posts", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.OPTIONS("/v1/posts", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		status  int
+		want    map[string]string
+	}{
+		{
+			name:    "允许的来源",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://admin.easyblog.com"},
+			status:  http.StatusOK,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://admin.easyblog.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Request-ID",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:    "不允许的来源不返回跨域响应头",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://evil.example.com"},
+			status:  http.StatusOK,
+			want:    map[string]string{"Access-Control-Allow-Origin": "", "Vary": "Origin"},
+		},
+		{
+			name:   "预检请求",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://admin.easyblog.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type",
+			},
+			status: http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":  "https://admin.easyblog.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "Authorization, Content-Type",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "预检请求的方法不允许",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://admin.easyblog.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status: http.StatusForbidden,
+			want:   map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "预检请求的请求头不允许",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://admin.easyblog.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "预检请求的来源不允许",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:    "不带 Access-Control-Request-Method 的 OPTIONS 请求不是预检请求",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://admin.easyblog.com"},
+			status:  http.StatusOK,
+			want:    map[string]string{"Access-Control-Allow-Methods": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/posts", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("状态码 = %d，期望 %d", w.Code, tt.status)
+			}
+			for header, value := range tt.want {
+				if got := w.Header().Get(header); got != value {
+					t.Errorf("%s = %q，期望 %q", header, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestLocale(t *testing.T) {
+	engine := gin.New()
+	engine.Use(Locale())
+	engine.GET("/v1/user/:id", func(c *gin.Context) {
+		core.WriteResponse(c, errno.ErrUserNotFound, nil)
+	})
+
+	tests := []struct {
+		name           string
+		url            string
+		cookie         string
+		acceptLanguage string
+		wantLocale     string
+		wantMessage    string
+	}{
+		{name: "默认语言", url: "/v1/user/1", wantLocale: "zh-CN", wantMessage: "用户不存在"},
+		{name: "Accept-Language", url: "/v1/user/1", acceptLanguage: "fr-FR, en;q=0.8, zh;q=0.5", wantLocale: "en-US", wantMessage: "User not found"},
+		{name: "不支持的语言", url: "/v1/user/1", acceptLanguage: "fr-FR", wantLocale: "zh-CN", wantMessage: "用户不存在"},
+		{name: "Cookie 优先于 Accept-Language", url: "/v1/user/1", cookie: "en", acceptLanguage: "zh-CN", wantLocale: "en-US", wantMessage: "User not found"},
+		{name: "查询参数优先于 Cookie", url: "/v1/user/1?lang=zh-CN", cookie: "en-US", wantLocale: "zh-CN", wantMessage: "用户不存在"},
+		{name: "不支持的查询参数被忽略", url: "/v1/user/1?lang=xx", acceptLanguage: "en-GB", wantLocale: "en-US", wantMessage: "User not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Language"); got != tt.wantLocale {
+				t.Errorf("Content-Language = %q，期望 %q", got, tt.wantLocale)
+			}
+			var resp core.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应: %v", err)
+			}
+			if resp.Code != errno.ErrUserNotFound.Code() || resp.Message != tt.wantMessage {
+				t.Errorf("响应 = %d %q，期望 %d %q", resp.Code, resp.Message, errno.ErrUserNotFound.Code(), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestClientInfoTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		want           string
+	}{
+		{name: "未配置可信代理时忽略 X-Forwarded-For", remoteAddr: "203.0.113.7:5000", forwardedFor: "10.9.0.1", want: "203.0.113.7"},
+		{name: "来自可信代理时使用 X-Forwarded-For", trustedProxies: []string{"192.0.2.0/24"}, remoteAddr: "192.0.2.10:5000", forwardedFor: "198.51.100.1", want: "198.51.100.1"},
+		{name: "来自其他地址时忽略 X-Forwarded-For", trustedProxies: []string{"192.0.2.0/24"}, remoteAddr: "203.0.113.7:5000", forwardedFor: "10.9.0.1", want: "203.0.113.7"},
+		{name: "跳过可信代理追加的地址", trustedProxies: []string{"192.0.2.0/24"}, remoteAddr: "192.0.2.10:5000", forwardedFor: "10.9.0.1, 198.51.100.1, 192.0.2.11", want: "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			if err := engine.SetTrustedProxies(tt.trustedProxies); err != nil {
+				t.Fatalf("SetTrustedProxies: %v", err)
+			}
+			var got string
+			engine.GET("/", ClientInfo(), func(c *gin.Context) {
+				got = contextx.ClientIP(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			engine.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("ClientIP = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultFilePath 未配置 notify.file.path 时通知写入的文件
+const defaultFilePath = "logs/notify.log"
+
+// fileNotifier 将通知内容追加到文件中，便于本地开发时查看发送的邮件
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotifier 创建将通知内容追加到文件中的通知组件
+func NewFileNotifier(path string) (Notifier, error) {
+	if path == "" {
+		path = defaultFilePath
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建通知文件目录失败: %v", err)
+	}
+
+	return &fileNotifier{path: path}, nil
+}
+
+// Send 将通知内容追加到文件中
+func (n *fileNotifier) Send(ctx context.Context, msg *Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("打开通知文件失败: %v", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return fmt.Errorf("写入通知文件失败: %v", err)
+	}
+	return nil
+}
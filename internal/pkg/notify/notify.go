@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lichenglife/easyblog/internal/pkg/log"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 支持的通知发送方式
+const (
+	// DriverLog 将通知内容写入日志，仅用于本地开发
+	DriverLog = "log"
+	// DriverFile 将通知内容追加到文件中，仅用于本地开发
+	DriverFile = "file"
+	// DriverSMTP 通过 SMTP 服务器发送邮件
+	DriverSMTP = "smtp"
+)
+
+// Message 表示一条发送给用户的通知
+type Message struct {
+	// To 接收方地址
+	To string
+	// Subject 通知标题
+	Subject string
+	// Body 通知正文
+	Body string
+}
+
+// Notifier 定义了向用户发送通知的接口，目前通知均以邮件形式发送给用户邮箱
+type Notifier interface {
+	// Send 发送通知
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewNotifier 根据 notify.driver 配置创建通知组件
+func NewNotifier(config *viper.Viper, logger *log.Logger) (Notifier, error) {
+	switch driver := config.GetString("notify.driver"); driver {
+	case DriverLog, "":
+		return NewLogNotifier(logger), nil
+	case DriverFile:
+		return NewFileNotifier(config.GetString("notify.file.path"))
+	case DriverSMTP:
+		return NewSMTPNotifier(config)
+	default:
+		return nil, fmt.Errorf("不支持的通知发送方式: %s", driver)
+	}
+}
+
+// logNotifier 将通知内容写入日志
+type logNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier 创建将通知内容写入日志的通知组件
+func NewLogNotifier(logger *log.Logger) Notifier {
+	return &logNotifier{logger: logger}
+}
+
+// Send 将通知内容写入日志
+func (n *logNotifier) Send(ctx context.Context, msg *Message) error {
+	n.logger.Info("发送通知",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// defaultSMTPTimeout 发送一封邮件的默认超时时间
+const defaultSMTPTimeout = 10 * time.Second
+
+// smtpNotifier 通过 SMTP 服务器发送邮件
+type smtpNotifier struct {
+	host     string
+	addr     string
+	username string
+	password string
+	from     mail.Address
+	// implicitTLS 为 true 时直接建立 TLS 连接(通常为 465 端口)，
+	// 否则在服务器支持时通过 STARTTLS 升级连接
+	implicitTLS bool
+	timeout     time.Duration
+}
+
+// NewSMTPNotifier 根据 notify.smtp 配置创建通过 SMTP 发送邮件的通知组件
+func NewSMTPNotifier(config *viper.Viper) (Notifier, error) {
+	host := config.GetString("notify.smtp.host")
+	if host == "" {
+		return nil, fmt.Errorf("notify.smtp.host 不能为空")
+	}
+	port := config.GetInt("notify.smtp.port")
+	if port == 0 {
+		port = 587
+	}
+	from, err := mail.ParseAddress(config.GetString("notify.smtp.from"))
+	if err != nil {
+		return nil, fmt.Errorf("notify.smtp.from 格式不正确: %v", err)
+	}
+	timeout := time.Duration(config.GetInt("notify.smtp.timeout")) * time.Second
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+
+	return &smtpNotifier{
+		host:        host,
+		addr:        net.JoinHostPort(host, strconv.Itoa(port)),
+		username:    config.GetString("notify.smtp.username"),
+		password:    config.GetString("notify.smtp.password"),
+		from:        *from,
+		implicitTLS: config.GetBool("notify.smtp.tls"),
+		timeout:     timeout,
+	}, nil
+}
+
+// Send 通过 SMTP 服务器发送邮件
+func (n *smtpNotifier) Send(ctx context.Context, msg *Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("收件人地址格式不正确: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	conn, err := n.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %v", err)
+	}
+	// 连接的读写同样受超时时间限制
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 SMTP 服务器失败: %v", err)
+	}
+	defer client.Close()
+
+	if !n.implicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+				return fmt.Errorf("SMTP STARTTLS 失败: %v", err)
+			}
+		}
+	}
+	if n.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %v", err)
+		}
+	}
+	if err := client.Mail(n.from.Address); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if _, err := w.Write(n.message(to, msg)); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+
+	return client.Quit()
+}
+
+// dial 建立到 SMTP 服务器的连接
+func (n *smtpNotifier) dial(ctx context.Context) (net.Conn, error) {
+	if n.implicitTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: n.host}}
+		return dialer.DialContext(ctx, "tcp", n.addr)
+	}
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", n.addr)
+}
+
+// message 生成邮件内容，标题和正文使用 UTF-8 编码
+func (n *smtpNotifier) message(to *mail.Address, msg *Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	// base64 编码后每行不超过 76 个字符
+	body := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(body) > 76 {
+		buf.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	buf.WriteString(body + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,282 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// stateKeyPrefix 授权请求在缓存中的键前缀，值为 JSON 格式的 authRequest
+	stateKeyPrefix = "oauth:state:"
+	// defaultStateExpire 授权请求默认有效期
+	defaultStateExpire = 10 * time.Minute
+	// httpTimeout 访问身份提供方的超时时间
+	httpTimeout = 10 * time.Second
+)
+
+// ProviderConfig 身份提供方配置，对应 oauth.providers 中的一项
+type ProviderConfig struct {
+	// Name 身份提供方名称，用于路由 /v1/user/oauth/:provider
+	Name string `mapstructure:"name"`
+	// Issuer OIDC 签发方地址，通过 {issuer}/.well-known/openid-configuration 发现端点
+	Issuer       string `mapstructure:"issuer"`
+	ClientID     string `mapstructure:"clientID"`
+	ClientSecret string `mapstructure:"clientSecret"`
+	// RedirectURL 回调地址，需要在身份提供方中登记
+	RedirectURL string `mapstructure:"redirectURL"`
+	// Scopes 申请的授权范围，为空时使用 openid、profile 和 email
+	Scopes []string `mapstructure:"scopes"`
+	// AutoProvision 未关联的账号首次登录时自动创建用户
+	AutoProvision bool `mapstructure:"autoProvision"`
+	// LinkByEmail 未关联的账号首次登录时，关联邮箱相同的已有用户，要求身份提供方已验证该邮箱
+	LinkByEmail bool `mapstructure:"linkByEmail"`
+}
+
+// Claims 表示从 ID 令牌中读取的用户信息
+type Claims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+}
+
+// Result 表示一次完成的授权
+type Result struct {
+	// Provider 身份提供方
+	Provider *Provider
+	// Claims ID 令牌中的用户信息
+	Claims *Claims
+	// UserID 关联账号时为发起关联的用户 ID，登录时为空
+	UserID string
+}
+
+// authRequest 表示一次进行中的授权请求，回调时通过 state 取出
+type authRequest struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	UserID   string `json:"userID,omitempty"`
+}
+
+// Provider 表示一个 OIDC 身份提供方，端点和签名公钥在首次使用时通过发现文档获取
+type Provider struct {
+	config ProviderConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// Name 返回身份提供方名称
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// AutoProvision 返回是否自动创建用户
+func (p *Provider) AutoProvision() bool {
+	return p.config.AutoProvision
+}
+
+// LinkByEmail 返回是否按邮箱关联已有用户
+func (p *Provider) LinkByEmail() bool {
+	return p.config.LinkByEmail
+}
+
+// discover 获取身份提供方的发现文档，获取失败时下次调用会重试
+func (p *Provider) discover() (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+
+	// 签名公钥会在之后按需刷新，因此不能使用请求的 context
+	provider, err := oidc.NewProvider(oidc.ClientContext(context.Background(), p.client), p.config.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取身份提供方 %s 的发现文档失败: %v", p.config.Name, err)
+	}
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.config.ClientID})
+	return p.oauth2, p.verifier, nil
+}
+
+// exchange 使用授权码和 PKCE verifier 换取令牌，并校验 ID 令牌的签名、签发方、受众、有效期和 nonce
+func (p *Provider) exchange(ctx context.Context, code string, req *authRequest) (*Claims, error) {
+	config, verifier, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(req.Verifier))
+	if err != nil {
+		return nil, errno.ErrOAuthLoginFailed.WithMessage("换取令牌失败").Wrap(err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errno.ErrOAuthLoginFailed.WithMessage("身份提供方未返回 ID 令牌")
+	}
+
+	idToken, err := verifier.Verify(oidc.ClientContext(ctx, p.client), rawIDToken)
+	if err != nil {
+		return nil, errno.ErrOAuthLoginFailed.WithMessage("ID 令牌校验失败").Wrap(err)
+	}
+	var claims Claims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, errno.ErrOAuthLoginFailed.WithMessage("解析 ID 令牌失败").Wrap(err)
+	}
+	if claims.Nonce != req.Nonce {
+		return nil, errno.ErrOAuthLoginFailed.WithMessage("ID 令牌 nonce 不匹配")
+	}
+	return &claims, nil
+}
+
+// Manager 管理配置的身份提供方，并保存进行中的授权请求
+type Manager struct {
+	providers   map[string]*Provider
+	store       cache.Store
+	stateExpire time.Duration
+}
+
+// NewManager 根据 oauth 配置创建身份提供方管理器，未配置身份提供方时第三方登录不可用。
+// 授权请求保存在 store 中，store 为 Redis 存储时授权请求可以在多个实例间共享
+func NewManager(config *viper.Viper, store cache.Store) (*Manager, error) {
+	var providers []ProviderConfig
+	if err := config.UnmarshalKey("oauth.providers", &providers); err != nil {
+		return nil, fmt.Errorf("解析 oauth.providers 失败: %v", err)
+	}
+
+	m := &Manager{
+		providers:   make(map[string]*Provider, len(providers)),
+		store:       store,
+		stateExpire: time.Duration(config.GetInt("oauth.stateExpire")) * time.Second,
+	}
+	if m.stateExpire <= 0 {
+		m.stateExpire = defaultStateExpire
+	}
+	for _, p := range providers {
+		if p.Name == "" || p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+			return nil, fmt.Errorf("身份提供方 %q 缺少 name、issuer、clientID 或 redirectURL", p.Name)
+		}
+		if _, ok := m.providers[p.Name]; ok {
+			return nil, fmt.Errorf("身份提供方 %q 重复", p.Name)
+		}
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+		}
+		if !slices.Contains(p.Scopes, oidc.ScopeOpenID) {
+			p.Scopes = append([]string{oidc.ScopeOpenID}, p.Scopes...)
+		}
+		m.providers[p.Name] = &Provider{config: p, client: &http.Client{Timeout: httpTimeout}}
+	}
+	return m, nil
+}
+
+// Provider 根据名称获取身份提供方，不存在时返回 errno.ErrOAuthProviderNotFound
+func (m *Manager) Provider(name string) (*Provider, error) {
+	if m != nil {
+		if p, ok := m.providers[name]; ok {
+			return p, nil
+		}
+	}
+	return nil, errno.ErrOAuthProviderNotFound
+}
+
+// Begin 开始一次授权，生成 state、nonce 和 PKCE verifier 并保存，返回授权地址和 state。
+// userID 不为空时表示为该用户关联第三方账号
+func (m *Manager) Begin(ctx context.Context, name, userID string) (string, string, error) {
+	p, err := m.Provider(name)
+	if err != nil {
+		return "", "", err
+	}
+	config, _, err := p.discover()
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	req := &authRequest{Provider: name, Verifier: oauth2.GenerateVerifier(), Nonce: nonce, UserID: userID}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", "", fmt.Errorf("序列化授权请求失败: %v", err)
+	}
+	if err := m.store.Set(ctx, stateKeyPrefix+state, string(data), m.stateExpire); err != nil {
+		return "", "", fmt.Errorf("保存授权请求失败: %v", err)
+	}
+
+	authURL := config.AuthCodeURL(state, oauth2.S256ChallengeOption(req.Verifier), oidc.Nonce(nonce))
+	return authURL, state, nil
+}
+
+// Complete 校验回调中的 state 并使用授权码换取用户信息，每个 state 只能使用一次
+func (m *Manager) Complete(ctx context.Context, name, state, code string) (*Result, error) {
+	p, err := m.Provider(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := m.store.Get(ctx, stateKeyPrefix+state)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return nil, errno.ErrOAuthStateInvalid
+		}
+		return nil, fmt.Errorf("查询授权请求失败: %v", err)
+	}
+	if err := m.store.Del(ctx, stateKeyPrefix+state); err != nil {
+		return nil, fmt.Errorf("删除授权请求失败: %v", err)
+	}
+	var req authRequest
+	if err := json.Unmarshal([]byte(data), &req); err != nil {
+		return nil, fmt.Errorf("解析授权请求失败: %v", err)
+	}
+	// state 只能在发起授权的身份提供方回调中使用
+	if req.Provider != name {
+		return nil, errno.ErrOAuthStateInvalid
+	}
+
+	claims, err := p.exchange(ctx, code, &req)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Provider: p, Claims: claims, UserID: req.UserID}, nil
+}
+
+// randomString 生成 32 字节的随机字符串
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,166 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/oauth/oauthtest"
+)
+
+// newTestManager 启动模拟身份提供方，并创建配置了 mock 和 other 两个身份提供方的管理器，
+// 两者使用同一个模拟身份提供方
+func newTestManager(t *testing.T) (*Manager, *oauthtest.Provider) {
+	t.Helper()
+
+	p, err := oauthtest.NewProvider("easyblog", "easyblog-secret")
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	t.Cleanup(p.Close)
+
+	config := viper.New()
+	providers := make([]map[string]any, 0, 2)
+	for _, name := range []string{"mock", "other"} {
+		providers = append(providers, map[string]any{
+			"name":         name,
+			"issuer":       p.Issuer(),
+			"clientID":     p.ClientID,
+			"clientSecret": p.ClientSecret,
+			"redirectURL":  "https://blog.example.com/v1/oauth/" + name + "/callback",
+		})
+	}
+	config.Set("oauth.providers", providers)
+
+	m, err := NewManager(config, cache.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m, p
+}
+
+// authorize 在 name 身份提供方开始授权并由模拟用户同意，返回授权码和 state
+func authorize(t *testing.T, m *Manager, p *oauthtest.Provider, name, userID string) (string, string) {
+	t.Helper()
+	authURL, state, err := m.Begin(context.Background(), name, userID)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	code, callbackState, err := p.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if callbackState != state {
+		t.Fatalf("回调 state = %q，期望 %q", callbackState, state)
+	}
+	return code, state
+}
+
+func TestComplete(t *testing.T) {
+	m, p := newTestManager(t)
+	p.SetUser(oauthtest.User{Subject: "alice", Email: "alice@example.com", EmailVerified: true, Name: "Alice"})
+
+	code, state := authorize(t, m, p, "mock", "user-1")
+	result, err := m.Complete(context.Background(), "mock", state, code)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if result.Provider.Name() != "mock" || result.UserID != "user-1" {
+		t.Errorf("Complete = {Provider: %s, UserID: %s}，期望 {mock, user-1}", result.Provider.Name(), result.UserID)
+	}
+	if c := result.Claims; c.Subject != "alice" || c.Email != "alice@example.com" || !c.EmailVerified || c.Name != "Alice" {
+		t.Errorf("Claims = %+v", c)
+	}
+}
+
+func TestCompleteInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		complete func(t *testing.T, m *Manager, p *oauthtest.Provider) error
+		want     error
+	}{
+		{
+			name: "未知的 state",
+			complete: func(t *testing.T, m *Manager, p *oauthtest.Provider) error {
+				code, _ := authorize(t, m, p, "mock", "")
+				_, err := m.Complete(context.Background(), "mock", "unknown", code)
+				return err
+			},
+			want: errno.ErrOAuthStateInvalid,
+		},
+		{
+			name: "重复使用 state",
+			complete: func(t *testing.T, m *Manager, p *oauthtest.Provider) error {
+				code, state := authorize(t, m, p, "mock", "")
+				if _, err := m.Complete(context.Background(), "mock", state, code); err != nil {
+					t.Fatalf("Complete: %v", err)
+				}
+				_, err := m.Complete(context.Background(), "mock", state, code)
+				return err
+			},
+			want: errno.ErrOAuthStateInvalid,
+		},
+		{
+			name: "在其他身份提供方的回调中使用 state",
+			complete: func(t *testing.T, m *Manager, p *oauthtest.Provider) error {
+				code, state := authorize(t, m, p, "mock", "")
+				_, err := m.Complete(context.Background(), "other", state, code)
+				return err
+			},
+			want: errno.ErrOAuthStateInvalid,
+		},
+		{
+			name: "PKCE verifier 与授权时的 challenge 不匹配",
+			complete: func(t *testing.T, m *Manager, p *oauthtest.Provider) error {
+				code, _ := authorize(t, m, p, "mock", "")
+				_, state := authorize(t, m, p, "mock", "")
+				_, err := m.Complete(context.Background(), "mock", state, code)
+				return err
+			},
+			want: errno.ErrOAuthLoginFailed,
+		},
+		{
+			name: "ID 令牌 nonce 不匹配",
+			complete: func(t *testing.T, m *Manager, p *oauthtest.Provider) error {
+				p.IDTokenHook = func(claims jwt.MapClaims) { claims["nonce"] = "forged" }
+				code, state := authorize(t, m, p, "mock", "")
+				_, err := m.Complete(context.Background(), "mock", state, code)
+				return err
+			},
+			want: errno.ErrOAuthLoginFailed,
+		},
+		{
+			name: "ID 令牌受众不正确",
+			complete: func(t *testing.T, m *Manager, p *oauthtest.Provider) error {
+				p.IDTokenHook = func(claims jwt.MapClaims) { claims["aud"] = "other-client" }
+				code, state := authorize(t, m, p, "mock", "")
+				_, err := m.Complete(context.Background(), "mock", state, code)
+				return err
+			},
+			want: errno.ErrOAuthLoginFailed,
+		},
+		{
+			name: "身份提供方不存在",
+			complete: func(t *testing.T, m *Manager, p *oauthtest.Provider) error {
+				code, state := authorize(t, m, p, "mock", "")
+				_, err := m.Complete(context.Background(), "missing", state, code)
+				return err
+			},
+			want: errno.ErrOAuthProviderNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, p := newTestManager(t)
+			err := tt.complete(t, m, p)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Complete err = %v，期望 %v", err, tt.want)
+			}
+		})
+	}
+}
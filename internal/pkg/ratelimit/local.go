@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 清理过期状态的请求间隔
+const sweepInterval = 1024
+
+// localLimiter 进程内限流器，适用于单实例部署和未启用 Redis 的场景
+type localLimiter struct {
+	mu sync.Mutex
+	// tats 每个键的理论到达时间，早于当前时间的键配额已完全恢复，可以清理
+	tats map[string]time.Time
+	// calls 调用计数，每调用 sweepInterval 次清理一次过期状态
+	calls int
+}
+
+// NewLocalLimiter 创建进程内限流器
+func NewLocalLimiter() Limiter {
+	return &localLimiter{tats: make(map[string]time.Time)}
+}
+
+// Allow 判断能否发起一次请求
+func (l *localLimiter) Allow(_ context.Context, key string, rule Rule) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	result, tat := gcra(now, l.tats[key], rule)
+	l.tats[key] = tat
+
+	l.calls++
+	if l.calls%sweepInterval == 0 {
+		for k, t := range l.tats {
+			if !t.After(now) {
+				delete(l.tats, k)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Peek 判断此时能否发起一次请求，不消耗配额
+func (l *localLimiter) Peek(_ context.Context, key string, rule Rule) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result, _ := gcra(time.Now(), l.tats[key], rule)
+	return result, nil
+}
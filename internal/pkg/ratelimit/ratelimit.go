@@ -0,0 +1,179 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/spf13/viper"
+)
+
+// keyPrefix 限流状态在缓存中的键前缀
+const keyPrefix = "ratelimit:"
+
+// Rule 限流规则，每个客户端每 Period 可以发起 Rate 次请求，短时间内最多连续发起 Burst 次请求
+type Rule struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// interval 返回两次请求之间的平均间隔
+func (r Rule) interval() time.Duration {
+	return r.Period / time.Duration(r.Rate)
+}
+
+// Result 一次限流判断的结果
+type Result struct {
+	// Allowed 是否允许本次请求
+	Allowed bool
+	// Remaining 本次请求之后还可以连续发起的请求数
+	Remaining int
+	// ResetAfter 配额完全恢复还需要的时间
+	ResetAfter time.Duration
+	// RetryAfter 请求被拒绝时，距离可以再次请求的时间
+	RetryAfter time.Duration
+}
+
+// Limiter 限流器，使用 GCRA(通用信元速率算法)实现令牌桶，每个键只需要保存一个时间戳
+type Limiter interface {
+	// Allow 判断键为 key 的客户端能否按 rule 发起一次请求，允许时消耗一次配额
+	Allow(ctx context.Context, key string, rule Rule) (*Result, error)
+	// Peek 返回键为 key 的客户端此时按 rule 发起请求的结果，不消耗配额
+	Peek(ctx context.Context, key string, rule Rule) (*Result, error)
+}
+
+// LimitedError 表示请求因超过限流规则被拒绝
+type LimitedError struct {
+	errno.Errno
+	retryAfter time.Duration
+}
+
+// RetryAfter 返回距离可以再次请求的时间
+func (e *LimitedError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// NewLimitedError 创建 LimitedError
+func NewLimitedError(retryAfter time.Duration) *LimitedError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return &LimitedError{
+		Errno:      errno.ErrTooManyRequests.WithMessage(fmt.Sprintf("请求过于频繁，请在 %d 秒后重试", seconds)),
+		retryAfter: retryAfter,
+	}
+}
+
+// RouteRule 针对单个路由的限流规则，替代默认规则
+type RouteRule struct {
+	Rule
+	// Method HTTP 方法，为空或 "*" 时匹配全部方法
+	Method string
+	// Path gin 路由模板，如 /v1/user/login
+	Path string
+}
+
+// Options 限流参数
+type Options struct {
+	// Enabled 是否启用限流
+	Enabled bool
+	// Default 默认规则
+	Default Rule
+	// Routes 路由规则，按配置顺序匹配
+	Routes []RouteRule
+}
+
+// routeConfig 对应 rateLimit.routes 中的一项
+type routeConfig struct {
+	Method string `mapstructure:"method"`
+	Path   string `mapstructure:"path"`
+	Rate   int    `mapstructure:"rate"`
+	Period int    `mapstructure:"period"`
+	Burst  int    `mapstructure:"burst"`
+}
+
+// NewOptions 根据 rateLimit 配置创建限流参数，period 以秒为单位，未配置时为 1 秒，burst 未配置时与 rate 相同
+func NewOptions(config *viper.Viper) (*Options, error) {
+	opts := &Options{Enabled: config.GetBool("rateLimit.enabled")}
+	if !opts.Enabled {
+		return opts, nil
+	}
+
+	rule, err := newRule(config.GetInt("rateLimit.rate"), config.GetInt("rateLimit.period"), config.GetInt("rateLimit.burst"))
+	if err != nil {
+		return nil, fmt.Errorf("rateLimit 配置无效: %v", err)
+	}
+	opts.Default = rule
+
+	var routes []routeConfig
+	if err := config.UnmarshalKey("rateLimit.routes", &routes); err != nil {
+		return nil, fmt.Errorf("解析 rateLimit.routes 失败: %v", err)
+	}
+	for _, r := range routes {
+		if r.Path == "" {
+			return nil, fmt.Errorf("rateLimit.routes 缺少 path")
+		}
+		rule, err := newRule(r.Rate, r.Period, r.Burst)
+		if err != nil {
+			return nil, fmt.Errorf("路由 %s %s 的限流配置无效: %v", r.Method, r.Path, err)
+		}
+		opts.Routes = append(opts.Routes, RouteRule{Rule: rule, Method: r.Method, Path: r.Path})
+	}
+	return opts, nil
+}
+
+// newRule 校验并创建限流规则
+func newRule(rate, period, burst int) (Rule, error) {
+	if period <= 0 {
+		period = 1
+	}
+	if burst <= 0 {
+		burst = rate
+	}
+	if rate <= 0 {
+		return Rule{}, fmt.Errorf("rate 必须大于 0")
+	}
+	rule := Rule{Rate: rate, Period: time.Duration(period) * time.Second, Burst: burst}
+	// Redis 限流器以微秒为单位计算
+	if rule.interval() < time.Microsecond {
+		return Rule{}, fmt.Errorf("rate 过大")
+	}
+	return rule, nil
+}
+
+// Match 返回与请求匹配的规则，以及区分不同规则配额的名称
+func (o *Options) Match(method, path string) (string, Rule) {
+	for _, r := range o.Routes {
+		if r.Path != path {
+			continue
+		}
+		if r.Method == "" || r.Method == "*" {
+			return r.Path, r.Rule
+		}
+		if r.Method == method {
+			return r.Method + " " + r.Path, r.Rule
+		}
+	}
+	return "default", o.Default
+}
+
+// gcra 根据上次保存的理论到达时间 tat 计算本次请求的结果，返回结果和新的理论到达时间，拒绝时 tat 不变
+func gcra(now, tat time.Time, rule Rule) (*Result, time.Time) {
+	interval := rule.interval()
+	tolerance := interval * time.Duration(rule.Burst)
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(interval)
+	allowAt := newTat.Add(-tolerance)
+	if now.Before(allowAt) {
+		return &Result{RetryAfter: allowAt.Sub(now), ResetAfter: tat.Sub(now)}, tat
+	}
+	return &Result{
+		Allowed:    true,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTat.Sub(now),
+	}, newTat
+}
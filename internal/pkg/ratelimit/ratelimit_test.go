@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestGCRA(t *testing.T) {
+	// 每 10 秒 1 次，最多连续 3 次
+	rule := Rule{Rate: 1, Period: 10 * time.Second, Burst: 3}
+	start := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    Result
+	}{
+		{name: "第一次请求", elapsed: 0, want: Result{Allowed: true, Remaining: 2, ResetAfter: 10 * time.Second}},
+		{name: "连续第二次请求", elapsed: 0, want: Result{Allowed: true, Remaining: 1, ResetAfter: 20 * time.Second}},
+		{name: "用完突发配额", elapsed: 0, want: Result{Allowed: true, Remaining: 0, ResetAfter: 30 * time.Second}},
+		{name: "超过突发配额", elapsed: 0, want: Result{RetryAfter: 10 * time.Second, ResetAfter: 30 * time.Second}},
+		{name: "被拒绝的请求不消耗配额", elapsed: 5 * time.Second, want: Result{RetryAfter: 5 * time.Second, ResetAfter: 25 * time.Second}},
+		{name: "恢复一次配额", elapsed: 10 * time.Second, want: Result{Allowed: true, Remaining: 0, ResetAfter: 30 * time.Second}},
+		{name: "配额完全恢复", elapsed: time.Minute, want: Result{Allowed: true, Remaining: 2, ResetAfter: 10 * time.Second}},
+	}
+
+	var tat time.Time
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Result
+			got, tat = gcra(start.Add(tt.elapsed), tat, rule)
+			if *got != tt.want {
+				t.Errorf("gcra = %+v，期望 %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalLimiter(t *testing.T) {
+	limiter := NewLocalLimiter()
+	rule := Rule{Rate: 1, Period: time.Hour, Burst: 2}
+	ctx := context.Background()
+
+	for i, want := range []bool{true, true, false} {
+		result, err := limiter.Allow(ctx, "a", rule)
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if result.Allowed != want {
+			t.Errorf("第 %d 次请求 Allowed = %v，期望 %v", i+1, result.Allowed, want)
+		}
+	}
+
+	// 不同的键配额相互独立
+	result, err := limiter.Allow(ctx, "b", rule)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !result.Allowed {
+		t.Error("其他键的请求应被允许")
+	}
+}
+
+func TestLocalLimiterPeek(t *testing.T) {
+	limiter := NewLocalLimiter()
+	rule := Rule{Rate: 1, Period: time.Hour, Burst: 1}
+	ctx := context.Background()
+
+	// Peek 不消耗配额
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Peek(ctx, "a", rule)
+		if err != nil {
+			t.Fatalf("Peek: %v", err)
+		}
+		if !result.Allowed {
+			t.Fatalf("第 %d 次 Peek Allowed = false，期望 true", i+1)
+		}
+	}
+	if _, err := limiter.Allow(ctx, "a", rule); err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	result, err := limiter.Peek(ctx, "a", rule)
+	if err != nil {
+		t.Fatalf("Peek: %v", err)
+	}
+	if result.Allowed || result.RetryAfter <= 0 {
+		t.Errorf("配额用完后 Peek = %+v，期望拒绝", *result)
+	}
+}
+
+func TestOptionsMatch(t *testing.T) {
+	config := viper.New()
+	config.Set("rateLimit.enabled", true)
+	config.Set("rateLimit.rate", 10)
+	config.Set("rateLimit.routes", []map[string]interface{}{
+		{"method": "POST", "path": "/v1/user/login", "rate": 5, "period": 60},
+		{"path": "/v1/posts", "rate": 2, "burst": 4},
+	})
+	opts, err := NewOptions(config)
+	if err != nil {
+		t.Fatalf("NewOptions: %v", err)
+	}
+
+	tests := []struct {
+		method, path string
+		wantName     string
+		wantRule     Rule
+	}{
+		{method: "POST", path: "/v1/user/login", wantName: "POST /v1/user/login", wantRule: Rule{Rate: 5, Period: time.Minute, Burst: 5}},
+		{method: "GET", path: "/v1/user/login", wantName: "default", wantRule: Rule{Rate: 10, Period: time.Second, Burst: 10}},
+		{method: "GET", path: "/v1/posts", wantName: "/v1/posts", wantRule: Rule{Rate: 2, Period: time.Second, Burst: 4}},
+		{method: "DELETE", path: "/v1/posts", wantName: "/v1/posts", wantRule: Rule{Rate: 2, Period: time.Second, Burst: 4}},
+	}
+	for _, tt := range tests {
+		name, rule := opts.Match(tt.method, tt.path)
+		if name != tt.wantName || rule != tt.wantRule {
+			t.Errorf("Match(%s, %s) = %s %+v，期望 %s %+v", tt.method, tt.path, name, rule, tt.wantName, tt.wantRule)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript 原子地执行 GCRA 限流判断，时间单位均为微秒。
+// ARGV[1] 为当前时间，ARGV[2] 为请求间隔，ARGV[3] 为允许的突发量对应的时长。
+// 返回 {是否允许, 剩余请求数, 距离可以再次请求的时间, 配额完全恢复的时间}
+var gcraScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local tolerance = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if now < allow_at then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+redis.call("SET", KEYS[1], string.format("%.0f", new_tat), "PX", math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+// redisLimiter 基于 Redis 的限流器，多个实例共享同一份配额。
+// 当前时间由调用方传入，各实例的时钟偏差会体现为配额恢复时间的偏差
+type redisLimiter struct {
+	client redis.UniversalClient
+}
+
+// NewRedisLimiter 创建基于 Redis 的限流器
+func NewRedisLimiter(c *cache.Cache) Limiter {
+	return &redisLimiter{client: c.Client}
+}
+
+// Allow 判断能否发起一次请求
+func (l *redisLimiter) Allow(ctx context.Context, key string, rule Rule) (*Result, error) {
+	interval := rule.interval()
+	tolerance := interval * time.Duration(rule.Burst)
+
+	values, err := gcraScript.Run(ctx, l.client, []string{keyPrefix + key},
+		time.Now().UnixMicro(), interval.Microseconds(), tolerance.Microseconds()).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("执行限流脚本失败: %v", err)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("限流脚本返回值无效: %v", values)
+	}
+	return &Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// Peek 判断此时能否发起一次请求，不消耗配额。只读取理论到达时间，不需要原子执行
+func (l *redisLimiter) Peek(ctx context.Context, key string, rule Rule) (*Result, error) {
+	var tat time.Time
+	micros, err := l.client.Get(ctx, keyPrefix+key).Int64()
+	switch {
+	case err == nil:
+		tat = time.UnixMicro(micros)
+	case !errors.Is(err, redis.Nil):
+		return nil, fmt.Errorf("读取限流状态失败: %v", err)
+	}
+	result, _ := gcra(time.Now(), tat, rule)
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/contextx"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// 会话在缓存中的键前缀
+const (
+	// sessionKeyPrefix 会话信息，键为令牌家族 ID，过期时间与家族一致
+	sessionKeyPrefix = "token:session:"
+	// sessionIndexKeyPrefix 用户的会话 ID 集合，家族被注销后由 Sessions 清理
+	sessionIndexKeyPrefix = "token:sessions:"
+)
+
+// Session 表示一次登录产生的会话，会话 ID 即令牌家族 ID，刷新令牌时更新最近活跃时间和设备信息
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"userID"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
+
+// Sessions 返回用户当前有效的会话，按最近活跃时间倒序排列
+func (m *Manager) Sessions(ctx context.Context, userID string) ([]*Session, error) {
+	ids, err := m.store.SMembers(ctx, sessionIndexKeyPrefix+userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询会话列表失败: %v", err)
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	var stale []string
+	for _, id := range ids {
+		session, err := m.session(ctx, id)
+		if errors.Is(err, errno.ErrSessionNotFound) {
+			stale = append(stale, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	// 清理已过期或已注销的会话
+	if err := m.store.SRem(ctx, sessionIndexKeyPrefix+userID, stale...); err != nil {
+		return nil, fmt.Errorf("清理会话列表失败: %v", err)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession 注销用户的指定会话，会话内的访问令牌和刷新令牌立即失效
+func (m *Manager) RevokeSession(ctx context.Context, userID, id string) error {
+	session, err := m.session(ctx, id)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return errno.ErrSessionNotFound
+	}
+	return m.RevokeFamily(ctx, id)
+}
+
+// recordSession 在签发令牌后记录会话，设备信息从 context 中获取
+func (m *Manager) recordSession(ctx context.Context, userID, family string, created bool) error {
+	now := time.Now()
+	session := &Session{ID: family, UserID: userID, CreatedAt: now}
+	if !created {
+		existing, err := m.session(ctx, family)
+		switch {
+		case err == nil:
+			session = existing
+		case !errors.Is(err, errno.ErrSessionNotFound):
+			return err
+		}
+	}
+	session.UserAgent = contextx.UserAgent(ctx)
+	session.IP = contextx.ClientIP(ctx)
+	session.LastSeenAt = now
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("序列化会话失败: %v", err)
+	}
+	if err := m.store.Set(ctx, sessionKeyPrefix+family, string(data), m.refreshExpire); err != nil {
+		return fmt.Errorf("保存会话失败: %v", err)
+	}
+	if err := m.store.SAdd(ctx, sessionIndexKeyPrefix+userID, family, m.refreshExpire); err != nil {
+		return fmt.Errorf("保存会话失败: %v", err)
+	}
+	return nil
+}
+
+// session 获取会话信息，令牌家族已失效的会话视为不存在
+func (m *Manager) session(ctx context.Context, id string) (*Session, error) {
+	data, err := m.store.Get(ctx, sessionKeyPrefix+id)
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, errno.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询会话失败: %v", err)
+	}
+	active, err := m.store.Exists(ctx, familyKeyPrefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("查询令牌家族失败: %v", err)
+	}
+	if !active {
+		return nil, errno.ErrSessionNotFound
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, fmt.Errorf("解析会话失败: %v", err)
+	}
+	return &session, nil
+}
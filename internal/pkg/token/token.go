@@ -0,0 +1,521 @@
+package token
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/spf13/viper"
+)
+
+// 支持的签名算法
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SecretEnv 未配置 jwt.secret 时读取 HS256 签名密钥的环境变量
+const SecretEnv = "EASYBLOG_JWT_SECRET"
+
+// minSecretLength HS256 签名密钥的最小字节数
+const minSecretLength = 32
+
+// placeholderSecrets 示例配置和文档中常见的占位密钥，使用这些密钥签发的令牌可以被任何人伪造
+var placeholderSecrets = map[string]bool{
+	"your-secret-key": true,
+	"secret":          true,
+	"changeme":        true,
+	"change-me":       true,
+}
+
+// APIKeyPrefix API 密钥前缀，Authorization 头中以该前缀开头的令牌按 API 密钥处理
+const APIKeyPrefix = "ebk_"
+
+// 令牌类型
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+	// TypeEmailVerification 邮箱验证令牌，只用于验证链接，不能访问接口
+	TypeEmailVerification = "email_verification"
+	// TypeMFAChallenge 两步验证挑战令牌，只用于提交验证码完成登录，不能访问接口
+	TypeMFAChallenge = "mfa_challenge"
+)
+
+// 令牌服务端状态在缓存中的键前缀
+const (
+	// denyKeyPrefix 已注销的访问令牌黑名单，值为空，过期时间与令牌一致
+	denyKeyPrefix = "token:deny:"
+	// familyKeyPrefix 刷新令牌家族，值为家族当前有效的刷新令牌 jti
+	familyKeyPrefix = "token:family:"
+	// generationKeyPrefix 用户令牌代次，值为随机 ID，注销用户全部令牌时更新。
+	// 该键不设置过期时间，否则过期后会误将新代次的令牌判定为失效
+	generationKeyPrefix = "token:generation:"
+)
+
+// Claims 定义了令牌中携带的声明，Subject 为用户唯一 ID
+type Claims struct {
+	jwt.RegisteredClaims
+	// Username 用户名
+	Username string `json:"username"`
+	// Role 用户角色
+	Role string `json:"role"`
+	// Type 令牌类型 (access, refresh)
+	Type string `json:"typ"`
+	// Family 令牌家族 ID，同一次登录签发及轮换得到的令牌属于同一家族
+	Family string `json:"fid"`
+	// Generation 签发时用户的令牌代次，与当前代次不一致的令牌均已失效
+	Generation string `json:"gen,omitempty"`
+	// MFA 令牌是否通过两步验证登录获得，轮换后保持不变
+	MFA bool `json:"mfa,omitempty"`
+}
+
+// EmailClaims 定义了邮箱验证令牌中携带的声明，Subject 为用户唯一 ID
+type EmailClaims struct {
+	jwt.RegisteredClaims
+	// Email 待验证的邮箱，用户修改邮箱后旧的验证令牌随即失效
+	Email string `json:"email"`
+	// Type 令牌类型，固定为 TypeEmailVerification
+	Type string `json:"typ"`
+}
+
+// ChallengeClaims 定义了两步验证挑战令牌中携带的声明，Subject 为用户唯一 ID
+type ChallengeClaims struct {
+	jwt.RegisteredClaims
+	// Type 令牌类型，固定为 TypeMFAChallenge
+	Type string `json:"typ"`
+}
+
+// Pair 表示一次签发得到的访问令牌和刷新令牌
+type Pair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Identity 表示签发令牌的用户身份
+type Identity struct {
+	UserID   string
+	Username string
+	Role     string
+	// MFA 是否通过两步验证登录
+	MFA bool
+}
+
+// Manager 负责签发、校验、轮换和注销 JWT 令牌
+type Manager struct {
+	method        jwt.SigningMethod
+	signKey       interface{}
+	verifyKey     interface{}
+	issuer        string
+	expire        time.Duration
+	refreshExpire time.Duration
+	// store 保存访问令牌黑名单、刷新令牌家族和会话
+	store cache.Store
+}
+
+// NewManager 根据 jwt 配置创建令牌管理器。
+// HS256 使用 jwt.secret（或环境变量 EASYBLOG_JWT_SECRET）签名；RS256/EdDSA 使用 jwt.privateKeyFile 签名，
+// 其他服务可以通过对应的公钥校验令牌
+func NewManager(config *viper.Viper, store cache.Store) (*Manager, error) {
+	m := &Manager{
+		issuer:        config.GetString("jwt.issuer"),
+		expire:        time.Duration(config.GetInt("jwt.expire")) * time.Second,
+		refreshExpire: time.Duration(config.GetInt("jwt.refreshExpire")) * time.Second,
+		store:         store,
+	}
+	if m.expire <= 0 {
+		m.expire = 15 * time.Minute
+	}
+	if m.refreshExpire <= 0 {
+		m.refreshExpire = 7 * 24 * time.Hour
+	}
+	if m.refreshExpire < m.expire {
+		return nil, fmt.Errorf("jwt.refreshExpire 不能小于 jwt.expire")
+	}
+
+	algorithm := config.GetString("jwt.algorithm")
+	if algorithm == "" {
+		algorithm = AlgorithmHS256
+	}
+
+	var err error
+	switch algorithm {
+	case AlgorithmHS256:
+		secret := config.GetString("jwt.secret")
+		if secret == "" {
+			secret = os.Getenv(SecretEnv)
+		}
+		if err := checkSecret(secret); err != nil {
+			return nil, err
+		}
+		m.method, m.signKey, m.verifyKey = jwt.SigningMethodHS256, []byte(secret), []byte(secret)
+	case AlgorithmRS256:
+		m.method = jwt.SigningMethodRS256
+		m.signKey, m.verifyKey, err = loadKeyPair(config,
+			func(b []byte) (interface{}, error) { return jwt.ParseRSAPrivateKeyFromPEM(b) },
+			func(b []byte) (interface{}, error) { return jwt.ParseRSAPublicKeyFromPEM(b) },
+		)
+	case AlgorithmEdDSA:
+		m.method = jwt.SigningMethodEdDSA
+		m.signKey, m.verifyKey, err = loadKeyPair(config,
+			func(b []byte) (interface{}, error) { return jwt.ParseEdPrivateKeyFromPEM(b) },
+			func(b []byte) (interface{}, error) { return jwt.ParseEdPublicKeyFromPEM(b) },
+		)
+	default:
+		return nil, fmt.Errorf("不支持的 JWT 签名算法: %s", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// checkSecret 拒绝为空、使用占位值或长度不足的 HS256 签名密钥
+func checkSecret(secret string) error {
+	switch {
+	case secret == "":
+		return fmt.Errorf("未设置 JWT 签名密钥，请配置 jwt.secret 或环境变量 %s", SecretEnv)
+	case placeholderSecrets[strings.ToLower(secret)]:
+		return fmt.Errorf("JWT 签名密钥不能使用示例值 %q", secret)
+	case len(secret) < minSecretLength:
+		return fmt.Errorf("JWT 签名密钥至少需要 %d 字节，当前为 %d 字节", minSecretLength, len(secret))
+	}
+	return nil
+}
+
+// Issue 为用户登录签发一对新的访问令牌和刷新令牌，并创建新的令牌家族
+func (m *Manager) Issue(ctx context.Context, identity Identity) (*Pair, error) {
+	return m.issue(ctx, identity, uuid.New().String(), "")
+}
+
+// Verify 校验访问令牌，并确认令牌未被注销且所属家族仍然有效
+func (m *Manager) Verify(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := m.parse(tokenString, TypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	denied, err := m.store.Exists(ctx, denyKeyPrefix+claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询令牌黑名单失败: %v", err)
+	}
+	if denied {
+		return nil, errno.ErrTokenRevoked
+	}
+	active, err := m.store.Exists(ctx, familyKeyPrefix+claims.Family)
+	if err != nil {
+		return nil, fmt.Errorf("查询令牌家族失败: %v", err)
+	}
+	if !active {
+		return nil, errno.ErrTokenRevoked
+	}
+	generation, err := m.generation(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Generation != generation {
+		return nil, errno.ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// ParseRefresh 校验刷新令牌的签名和有效期，不检查服务端状态
+func (m *Manager) ParseRefresh(tokenString string) (*Claims, error) {
+	return m.parse(tokenString, TypeRefresh)
+}
+
+// Rotate 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效。
+// 刷新令牌被重复使用时视为泄露，整个令牌家族会被注销
+func (m *Manager) Rotate(ctx context.Context, refresh *Claims, identity Identity) (*Pair, error) {
+	generation, err := m.generation(ctx, refresh.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if refresh.Generation != generation {
+		if err := m.RevokeFamily(ctx, refresh.Family); err != nil {
+			return nil, err
+		}
+		return nil, errno.ErrTokenRevoked
+	}
+
+	return m.issue(ctx, identity, refresh.Family, refresh.ID)
+}
+
+// Revoke 注销访问令牌及其所属的令牌家族，访问令牌在过期前会一直处于黑名单中
+func (m *Manager) Revoke(ctx context.Context, jti, family string, expiresAt time.Time) error {
+	if ttl := time.Until(expiresAt); ttl > 0 {
+		if err := m.store.Set(ctx, denyKeyPrefix+jti, "", ttl); err != nil {
+			return fmt.Errorf("注销令牌失败: %v", err)
+		}
+	}
+	return m.RevokeFamily(ctx, family)
+}
+
+// RevokeFamily 注销整个令牌家族，家族内的访问令牌和刷新令牌都将失效
+func (m *Manager) RevokeFamily(ctx context.Context, family string) error {
+	if err := m.store.Del(ctx, familyKeyPrefix+family, sessionKeyPrefix+family); err != nil {
+		return fmt.Errorf("注销令牌家族失败: %v", err)
+	}
+	return nil
+}
+
+// RevokeUser 注销用户已签发的全部令牌和会话，之后签发的令牌不受影响
+func (m *Manager) RevokeUser(ctx context.Context, userID string) error {
+	if err := m.store.Set(ctx, generationKeyPrefix+userID, uuid.New().String(), 0); err != nil {
+		return fmt.Errorf("注销用户令牌失败: %v", err)
+	}
+
+	// 令牌代次更新后旧令牌已经失效，这里只清理会话记录
+	families, err := m.store.SMembers(ctx, sessionIndexKeyPrefix+userID)
+	if err != nil {
+		return fmt.Errorf("查询会话列表失败: %v", err)
+	}
+	keys := []string{sessionIndexKeyPrefix + userID}
+	for _, family := range families {
+		keys = append(keys, familyKeyPrefix+family, sessionKeyPrefix+family)
+	}
+	if err := m.store.Del(ctx, keys...); err != nil {
+		return fmt.Errorf("清理会话失败: %v", err)
+	}
+	return nil
+}
+
+// generation 返回用户当前的令牌代次，从未注销过全部令牌的用户代次为空
+func (m *Manager) generation(ctx context.Context, userID string) (string, error) {
+	generation, err := m.store.Get(ctx, generationKeyPrefix+userID)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("查询用户令牌代次失败: %v", err)
+	}
+	return generation, nil
+}
+
+// SignEmailVerification 签发用于验证用户邮箱的令牌，令牌不保存服务端状态
+func (m *Manager) SignEmailVerification(userID, email string, expire time.Duration) (string, error) {
+	now := time.Now()
+	claims := &EmailClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    m.issuer,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expire)),
+		},
+		Email: email,
+		Type:  TypeEmailVerification,
+	}
+
+	token, err := jwt.NewWithClaims(m.method, claims).SignedString(m.signKey)
+	if err != nil {
+		return "", fmt.Errorf("签发邮箱验证令牌失败: %v", err)
+	}
+	return token, nil
+}
+
+// ParseEmailVerification 校验邮箱验证令牌并返回其中的声明
+func (m *Manager) ParseEmailVerification(tokenString string) (*EmailClaims, error) {
+	claims := &EmailClaims{}
+	if err := m.parseWithClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" || claims.Email == "" || claims.Type != TypeEmailVerification {
+		return nil, errno.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// SignMFAChallenge 签发两步验证挑战令牌，用户在有效期内提交验证码后完成登录
+func (m *Manager) SignMFAChallenge(userID string, expire time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(expire)
+	claims := &ChallengeClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    m.issuer,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Type: TypeMFAChallenge,
+	}
+
+	token, err := jwt.NewWithClaims(m.method, claims).SignedString(m.signKey)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("签发两步验证挑战令牌失败: %v", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ParseMFAChallenge 校验两步验证挑战令牌并返回其中的声明
+func (m *Manager) ParseMFAChallenge(tokenString string) (*ChallengeClaims, error) {
+	claims := &ChallengeClaims{}
+	if err := m.parseWithClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" || claims.Type != TypeMFAChallenge {
+		return nil, errno.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// issue 在指定家族下签发令牌对。previous 为空表示新建家族，
+// 否则只有当 previous 为家族当前的刷新令牌时才允许轮换
+func (m *Manager) issue(ctx context.Context, identity Identity, family, previous string) (*Pair, error) {
+	now := time.Now()
+	pair := &Pair{
+		AccessExpiresAt:  now.Add(m.expire),
+		RefreshExpiresAt: now.Add(m.refreshExpire),
+	}
+	refreshID := uuid.New().String()
+
+	generation, err := m.generation(ctx, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if pair.AccessToken, err = m.sign(identity, TypeAccess, uuid.New().String(), family, generation, now, pair.AccessExpiresAt); err != nil {
+		return nil, err
+	}
+	if pair.RefreshToken, err = m.sign(identity, TypeRefresh, refreshID, family, generation, now, pair.RefreshExpiresAt); err != nil {
+		return nil, err
+	}
+
+	key := familyKeyPrefix + family
+	if previous == "" {
+		if err := m.store.Set(ctx, key, refreshID, m.refreshExpire); err != nil {
+			return nil, fmt.Errorf("保存令牌家族失败: %v", err)
+		}
+		if err := m.recordSession(ctx, identity.UserID, family, true); err != nil {
+			return nil, err
+		}
+		return pair, nil
+	}
+
+	swapped, err := m.store.CompareAndSwap(ctx, key, previous, refreshID, m.refreshExpire)
+	if err != nil {
+		return nil, fmt.Errorf("轮换刷新令牌失败: %v", err)
+	}
+	if !swapped {
+		// 刷新令牌已被使用过或家族已被注销，注销整个家族以阻止泄露的令牌继续使用
+		if err := m.RevokeFamily(ctx, family); err != nil {
+			return nil, err
+		}
+		return nil, errno.ErrTokenRevoked
+	}
+	if err := m.recordSession(ctx, identity.UserID, family, false); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// sign 签发指定类型的令牌
+func (m *Manager) sign(identity Identity, typ, jti, family, generation string, issuedAt, expiresAt time.Time) (string, error) {
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    m.issuer,
+			Subject:   identity.UserID,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Username:   identity.Username,
+		Role:       identity.Role,
+		Type:       typ,
+		Family:     family,
+		Generation: generation,
+		MFA:        identity.MFA,
+	}
+
+	token, err := jwt.NewWithClaims(m.method, claims).SignedString(m.signKey)
+	if err != nil {
+		return "", fmt.Errorf("签发令牌失败: %v", err)
+	}
+	return token, nil
+}
+
+// parse 校验令牌并返回其中的声明，令牌过期时返回 errno.ErrTokenExpired，
+// 其他校验失败时返回 errno.ErrInvalidToken
+func (m *Manager) parse(tokenString, typ string) (*Claims, error) {
+	claims := &Claims{}
+	if err := m.parseWithClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" || claims.ID == "" || claims.Family == "" || claims.Type != typ {
+		return nil, errno.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// parseWithClaims 校验令牌的签名、签发方和有效期，并将声明解析到 claims 中
+func (m *Manager) parseWithClaims(tokenString string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims,
+		func(*jwt.Token) (interface{}, error) { return m.verifyKey, nil },
+		jwt.WithValidMethods([]string{m.method.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return errno.ErrTokenExpired
+		}
+		return errno.ErrInvalidToken
+	}
+	return nil
+}
+
+// loadKeyPair 从配置的 PEM 文件中加载签名私钥和校验公钥，未配置公钥文件时从私钥推导
+func loadKeyPair(
+	config *viper.Viper,
+	parsePrivate func([]byte) (interface{}, error),
+	parsePublic func([]byte) (interface{}, error),
+) (interface{}, interface{}, error) {
+	privateKeyFile := config.GetString("jwt.privateKeyFile")
+	if privateKeyFile == "" {
+		return nil, nil, fmt.Errorf("jwt.privateKeyFile 不能为空")
+	}
+	data, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取 JWT 私钥失败: %v", err)
+	}
+	privateKey, err := parsePrivate(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析 JWT 私钥失败: %v", err)
+	}
+
+	publicKeyFile := config.GetString("jwt.publicKeyFile")
+	if publicKeyFile == "" {
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("无法从 JWT 私钥推导公钥")
+		}
+		return privateKey, signer.Public(), nil
+	}
+	data, err = os.ReadFile(publicKeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取 JWT 公钥失败: %v", err)
+	}
+	publicKey, err := parsePublic(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析 JWT 公钥失败: %v", err)
+	}
+
+	return privateKey, publicKey, nil
+}
@@ -0,0 +1,191 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/lichenglife/easyblog/internal/pkg/cache"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+// testSecret 测试使用的 HS256 签名密钥
+const testSecret = "easyblog-test-secret-0123456789abcdef"
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	config := viper.New()
+	config.Set("jwt.secret", testSecret)
+	config.Set("jwt.issuer", "easyblog-test")
+	m, err := NewManager(config, cache.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m
+}
+
+var testIdentity = Identity{UserID: "user-1", Username: "alice"}
+
+// rotate 使用刷新令牌换取新的令牌对
+func rotate(ctx context.Context, m *Manager, refreshToken string) (*Pair, error) {
+	claims, err := m.ParseRefresh(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return m.Rotate(ctx, claims, testIdentity)
+}
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+
+	first, err := m.Issue(ctx, testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	second, err := rotate(ctx, m, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("第一次轮换: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("轮换后应签发新的刷新令牌")
+	}
+	// 轮换不影响同一家族中未过期的访问令牌
+	for _, access := range []string{first.AccessToken, second.AccessToken} {
+		if _, err := m.Verify(ctx, access); err != nil {
+			t.Errorf("轮换后 Verify: %v", err)
+		}
+	}
+	third, err := rotate(ctx, m, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("第二次轮换: %v", err)
+	}
+
+	// 重复使用已轮换的刷新令牌视为泄露，注销整个家族
+	if _, err := rotate(ctx, m, first.RefreshToken); !errors.Is(err, errno.ErrTokenRevoked) {
+		t.Fatalf("重复使用刷新令牌: err = %v，期望 %v", err, errno.ErrTokenRevoked)
+	}
+	if _, err := rotate(ctx, m, third.RefreshToken); !errors.Is(err, errno.ErrTokenRevoked) {
+		t.Errorf("家族注销后轮换最新的刷新令牌: err = %v，期望 %v", err, errno.ErrTokenRevoked)
+	}
+	if _, err := m.Verify(ctx, third.AccessToken); !errors.Is(err, errno.ErrTokenRevoked) {
+		t.Errorf("家族注销后 Verify: err = %v，期望 %v", err, errno.ErrTokenRevoked)
+	}
+
+	// 其他家族(其他设备的登录)不受影响
+	other, err := m.Issue(ctx, testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := rotate(ctx, m, other.RefreshToken); err != nil {
+		t.Errorf("其他家族轮换: %v", err)
+	}
+}
+
+func TestRevocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		revoke func(ctx context.Context, m *Manager, pair *Pair) error
+	}{
+		{
+			name: "退出登录",
+			revoke: func(ctx context.Context, m *Manager, pair *Pair) error {
+				claims, err := m.Verify(ctx, pair.AccessToken)
+				if err != nil {
+					return err
+				}
+				return m.Revoke(ctx, claims.ID, claims.Family, claims.ExpiresAt.Time)
+			},
+		},
+		{
+			name: "注销令牌家族",
+			revoke: func(ctx context.Context, m *Manager, pair *Pair) error {
+				claims, err := m.ParseRefresh(pair.RefreshToken)
+				if err != nil {
+					return err
+				}
+				return m.RevokeFamily(ctx, claims.Family)
+			},
+		},
+		{
+			name: "注销用户全部令牌",
+			revoke: func(ctx context.Context, m *Manager, _ *Pair) error {
+				return m.RevokeUser(ctx, testIdentity.UserID)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := newTestManager(t)
+			pair, err := m.Issue(ctx, testIdentity)
+			if err != nil {
+				t.Fatalf("Issue: %v", err)
+			}
+			if err := tt.revoke(ctx, m, pair); err != nil {
+				t.Fatalf("注销: %v", err)
+			}
+
+			if _, err := m.Verify(ctx, pair.AccessToken); !errors.Is(err, errno.ErrTokenRevoked) {
+				t.Errorf("Verify: err = %v，期望 %v", err, errno.ErrTokenRevoked)
+			}
+			if _, err := rotate(ctx, m, pair.RefreshToken); !errors.Is(err, errno.ErrTokenRevoked) {
+				t.Errorf("轮换: err = %v，期望 %v", err, errno.ErrTokenRevoked)
+			}
+			// 注销之后重新登录签发的令牌有效
+			fresh, err := m.Issue(ctx, testIdentity)
+			if err != nil {
+				t.Fatalf("Issue: %v", err)
+			}
+			if _, err := m.Verify(ctx, fresh.AccessToken); err != nil {
+				t.Errorf("重新登录后 Verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestTokenTypes(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	pair, err := m.Issue(ctx, testIdentity)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if _, err := m.ParseRefresh(pair.AccessToken); !errors.Is(err, errno.ErrInvalidToken) {
+		t.Errorf("访问令牌用作刷新令牌: err = %v，期望 %v", err, errno.ErrInvalidToken)
+	}
+	if _, err := m.Verify(ctx, pair.RefreshToken); !errors.Is(err, errno.ErrInvalidToken) {
+		t.Errorf("刷新令牌用作访问令牌: err = %v，期望 %v", err, errno.ErrInvalidToken)
+	}
+}
+
+func TestNewManagerSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		wantErr bool
+	}{
+		{name: "未设置密钥", wantErr: true},
+		{name: "从配置读取", config: testSecret},
+		{name: "从环境变量读取", env: testSecret},
+		{name: "配置优先于环境变量", config: testSecret, env: "short"},
+		{name: "示例密钥", config: "your-secret-key", wantErr: true},
+		{name: "大小写不同的示例密钥", env: "ChangeMe", wantErr: true},
+		{name: "密钥长度不足", config: "easyblog-test-secret", wantErr: true},
+		{name: "环境变量中的密钥长度不足", env: "easyblog-test-secret", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SecretEnv, tt.env)
+			config := viper.New()
+			config.Set("jwt.secret", tt.config)
+			if _, err := NewManager(config, cache.NewMemoryStore()); (err != nil) != tt.wantErr {
+				t.Errorf("NewManager err = %v，期望返回错误 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entrans "github.com/go-playground/validator/v10/translations/en"
+	zhtrans "github.com/go-playground/validator/v10/translations/zh"
+
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+	"github.com/lichenglife/easyblog/internal/pkg/i18n"
+)
+
+var (
+	// usernameRegexp 用户名只能包含字母、数字和下划线，长度 3-20
+	usernameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_]{3,20}$`)
+	// phoneRegexp 中国大陆手机号
+	phoneRegexp = regexp.MustCompile(`^1[3-9]\d{9}$`)
+)
+
+// 密码长度范围
+const (
+	passwordMinLen = 6
+	passwordMaxLen = 30
+)
+
+// uni 保存各语言的翻译器，默认语言为中文
+var uni = ut.New(zh.New(), zh.New(), en.New())
+
+// customRule 自定义校验规则及其各语言的错误信息
+type customRule struct {
+	tag      string
+	fn       validator.Func
+	messages map[string]string
+}
+
+var customRules = []customRule{
+	{
+		tag: "username",
+		fn:  func(fl validator.FieldLevel) bool { return usernameRegexp.MatchString(fl.Field().String()) },
+		messages: map[string]string{
+			"zh": "{0}只能包含字母、数字和下划线，长度为3到20个字符",
+			"en": "{0} must be 3 to 20 characters long and contain only letters, digits and underscores",
+		},
+	},
+	{
+		tag: "password",
+		fn:  validatePassword,
+		messages: map[string]string{
+			"zh": "{0}长度为6到30个字符，且必须同时包含字母和数字",
+			"en": "{0} must be 6 to 30 characters long and contain both letters and digits",
+		},
+	},
+	{
+		tag: "phone",
+		fn:  func(fl validator.FieldLevel) bool { return phoneRegexp.MatchString(fl.Field().String()) },
+		messages: map[string]string{
+			"zh": "{0}必须是有效的手机号",
+			"en": "{0} must be a valid mobile phone number",
+		},
+	},
+}
+
+// validatePassword 密码长度为 6-30 个字符，且同时包含字母和数字
+func validatePassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if n := len([]rune(password)); n < passwordMinLen || n > passwordMaxLen {
+		return false
+	}
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return letter && digit
+}
+
+// Register 向 gin 的校验器注册自定义校验规则和中英文翻译，需要在处理请求前调用一次
+func Register() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("不支持的校验器类型 %T", binding.Validator.Engine())
+	}
+
+	// 错误信息中使用 json 或 form 标签中的字段名
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	for _, rule := range customRules {
+		if err := v.RegisterValidation(rule.tag, rule.fn); err != nil {
+			return fmt.Errorf("注册校验规则 %s 失败: %v", rule.tag, err)
+		}
+	}
+
+	registers := map[string]func(*validator.Validate, ut.Translator) error{
+		"zh": zhtrans.RegisterDefaultTranslations,
+		"en": entrans.RegisterDefaultTranslations,
+	}
+	for lang, register := range registers {
+		trans, _ := uni.GetTranslator(lang)
+		if err := register(v, trans); err != nil {
+			return fmt.Errorf("注册 %s 校验翻译失败: %v", lang, err)
+		}
+		for _, rule := range customRules {
+			message := rule.messages[lang]
+			err := v.RegisterTranslation(rule.tag, trans,
+				func(t ut.Translator) error { return t.Add(rule.tag, message, true) },
+				func(t ut.Translator, fe validator.FieldError) string {
+					msg, _ := t.T(fe.Tag(), fe.Field())
+					return msg
+				})
+			if err != nil {
+				return fmt.Errorf("注册 %s 校验规则 %s 的翻译失败: %v", lang, rule.tag, err)
+			}
+		}
+	}
+	return nil
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// Field 字段名，与请求中的字段名一致
+	Field string `json:"field"`
+	// Rule 未通过的校验规则
+	Rule string `json:"rule"`
+	// Message 错误信息
+	Message string `json:"message"`
+}
+
+// Error 表示请求参数校验失败，Details 返回每个字段的错误
+type Error struct {
+	errno.Errno
+	details []FieldError
+}
+
+// Details 返回字段校验错误列表
+func (e *Error) Details() interface{} {
+	if len(e.details) == 0 {
+		return nil
+	}
+	return e.details
+}
+
+// Localize 返回错误信息，错误信息在创建时已经按请求的语言翻译
+func (e *Error) Localize(string) string {
+	return e.Message()
+}
+
+// 请求体无法解析时的错误信息
+var malformedMessages = map[string]string{
+	"zh": "请求体格式错误",
+	"en": "malformed request body",
+}
+
+// 字段类型不匹配时的错误信息
+var typeMessages = map[string]string{
+	"zh": "%s类型错误，应为%s",
+	"en": "%s must be of type %s",
+}
+
+// Translate 将请求绑定错误转换为 errno.ErrInvalidParams，字段校验错误按 locale 翻译后放入 Details
+func Translate(err error, locale string) error {
+	trans := translator(locale)
+	lang := trans.Locale()
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return newError(FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf(typeMessages[lang], typeErr.Field, typeErr.Type.String()),
+		})
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return &Error{Errno: errno.ErrInvalidParams.WithMessage(malformedMessages[lang]).Wrap(err)}
+		}
+		// 其他错误的信息可能包含内部细节，只返回通用的参数错误，原始错误保留在 cause 中写入日志
+		return errno.ErrInvalidParams.Wrap(err)
+	}
+
+	details := make([]FieldError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		details = append(details, FieldError{
+			Field:   fieldName(fe),
+			Rule:    fe.Tag(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return newError(details...)
+}
+
+// newError 创建参数校验错误，错误信息为第一个字段的错误信息
+func newError(details ...FieldError) *Error {
+	return &Error{
+		Errno:   errno.ErrInvalidParams.WithMessage(details[0].Message),
+		details: details,
+	}
+}
+
+// fieldName 返回去掉顶层结构体名称的字段路径，如 scopes[0]
+func fieldName(fe validator.FieldError) string {
+	_, name, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok || name == "" {
+		return fe.Field()
+	}
+	return name
+}
+
+// translator 返回 locale 对应的翻译器，不支持的语言使用中文
+func translator(locale string) ut.Translator {
+	trans, _ := uni.FindTranslator(i18n.Base(locale))
+	return trans
+}
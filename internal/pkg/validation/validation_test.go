@@ -0,0 +1,224 @@
+package validation
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
+)
+
+func TestMain(m *testing.M) {
+	if err := Register(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// testRequest 测试使用的请求结构
+type testRequest struct {
+	Username string   `json:"username" binding:"required,username"`
+	Password string   `json:"password" binding:"omitempty,password"`
+	Phone    string   `json:"phone" binding:"omitempty,phone"`
+	Age      int      `json:"age" binding:"omitempty,min=18"`
+	Scopes   []string `json:"scopes" binding:"omitempty,dive,oneof=read write"`
+}
+
+// bind 像 gin 的 ShouldBindJSON 一样解析并校验请求体，返回按 locale 翻译后的错误
+func bind(body, locale string) error {
+	var req testRequest
+	if err := binding.JSON.BindBody([]byte(body), &req); err != nil {
+		return Translate(err, locale)
+	}
+	return nil
+}
+
+// details 返回参数校验错误中的字段错误
+func details(t *testing.T, err error) []FieldError {
+	t.Helper()
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("err = %v，期望 *validation.Error", err)
+	}
+	if e.Code() != errno.ErrInvalidParams.Code() {
+		t.Errorf("错误码 = %d，期望 %d", e.Code(), errno.ErrInvalidParams.Code())
+	}
+	return e.Details().([]FieldError)
+}
+
+func TestCustomRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{name: "有效的用户名", body: `{"username":"alice_01"}`},
+		{name: "用户名过短", body: `{"username":"al"}`, field: "username"},
+		{name: "用户名过长", body: `{"username":"alice_0123456789_abcde"}`, field: "username"},
+		{name: "用户名包含非法字符", body: `{"username":"alice-01"}`, field: "username"},
+		{name: "有效的密码", body: `{"username":"alice","password":"Passw0rd"}`},
+		{name: "密码没有数字", body: `{"username":"alice","password":"password"}`, field: "password"},
+		{name: "密码没有字母", body: `{"username":"alice","password":"12345678"}`, field: "password"},
+		{name: "密码过短", body: `{"username":"alice","password":"a1b2"}`, field: "password"},
+		{name: "密码过长", body: `{"username":"alice","password":"a123456789012345678901234567890"}`, field: "password"},
+		{name: "密码按字符计算长度", body: `{"username":"alice","password":"密码1"}`, field: "password"},
+		{name: "有效的手机号", body: `{"username":"alice","phone":"13800138000"}`},
+		{name: "手机号号段无效", body: `{"username":"alice","phone":"12800138000"}`, field: "phone"},
+		{name: "手机号位数错误", body: `{"username":"alice","phone":"1380013800"}`, field: "phone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bind(tt.body, "")
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("校验失败: %v", err)
+				}
+				return
+			}
+			got := details(t, err)
+			if len(got) != 1 || got[0].Field != tt.field || got[0].Rule != tt.field {
+				t.Errorf("Details = %+v，期望字段 %s 未通过规则 %s", got, tt.field, tt.field)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	body := `{"password":"password","phone":"123","age":10,"scopes":["read","admin"]}`
+	tests := []struct {
+		name   string
+		locale string
+		want   []FieldError
+	}{
+		{
+			name: "默认使用中文",
+			want: []FieldError{
+				{Field: "username", Rule: "required", Message: "username为必填字段"},
+				{Field: "password", Rule: "password", Message: "password长度为6到30个字符，且必须同时包含字母和数字"},
+				{Field: "phone", Rule: "phone", Message: "phone必须是有效的手机号"},
+				{Field: "age", Rule: "min", Message: "age最小只能为18"},
+				{Field: "scopes[1]", Rule: "oneof", Message: "scopes[1]必须是[read write]中的一个"},
+			},
+		},
+		{
+			name:   "英文",
+			locale: "en-US",
+			want: []FieldError{
+				{Field: "username", Rule: "required", Message: "username is a required field"},
+				{Field: "password", Rule: "password", Message: "password must be 6 to 30 characters long and contain both letters and digits"},
+				{Field: "phone", Rule: "phone", Message: "phone must be a valid mobile phone number"},
+				{Field: "age", Rule: "min", Message: "age must be 18 or greater"},
+				{Field: "scopes[1]", Rule: "oneof", Message: "scopes[1] must be one of [read write]"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bind(body, tt.locale)
+			if got := details(t, err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Details = %+v\n期望 %+v", got, tt.want)
+			}
+			// 错误信息为第一个字段的错误信息
+			if got := errno.Decode(err).Message(); got != tt.want[0].Message {
+				t.Errorf("Message = %q，期望 %q", got, tt.want[0].Message)
+			}
+		})
+	}
+}
+
+func TestTranslateLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "", want: "username为必填字段"},
+		{locale: "zh-CN", want: "username为必填字段"},
+		{locale: "en-US", want: "username is a required field"},
+		{locale: "EN-gb", want: "username is a required field"},
+		{locale: "ja-JP", want: "username为必填字段"},
+	}
+	for _, tt := range tests {
+		if got := details(t, bind(`{}`, tt.locale))[0].Message; got != tt.want {
+			t.Errorf("locale %q: Message = %q，期望 %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateBodyErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		locale      string
+		wantMessage string
+		wantDetails []FieldError
+	}{
+		{
+			name:        "字段类型错误",
+			body:        `{"username":"alice","age":"18"}`,
+			wantMessage: "age类型错误，应为int",
+			wantDetails: []FieldError{{Field: "age", Rule: "type", Message: "age类型错误，应为int"}},
+		},
+		{
+			name:        "字段类型错误(英文)",
+			body:        `{"username":"alice","age":"18"}`,
+			locale:      "en-US",
+			wantMessage: "age must be of type int",
+			wantDetails: []FieldError{{Field: "age", Rule: "type", Message: "age must be of type int"}},
+		},
+		{
+			name:        "请求体不是有效的 JSON",
+			body:        `{"username":`,
+			wantMessage: "请求体格式错误",
+		},
+		{
+			name:        "请求体不是有效的 JSON(英文)",
+			body:        `{"username":`,
+			locale:      "en-US",
+			wantMessage: "malformed request body",
+		},
+		{
+			name:        "请求体不是对象",
+			body:        `[]`,
+			wantMessage: "请求体格式错误",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bind(tt.body, tt.locale)
+			e := errno.Decode(err)
+			if e.Code() != errno.ErrInvalidParams.Code() || e.Message() != tt.wantMessage {
+				t.Errorf("err = %d %q，期望 %d %q", e.Code(), e.Message(), errno.ErrInvalidParams.Code(), tt.wantMessage)
+			}
+			if tt.wantDetails == nil {
+				var detailed *Error
+				if errors.As(err, &detailed) && detailed.Details() != nil {
+					t.Errorf("不应返回字段错误，Details = %+v", detailed.Details())
+				}
+				return
+			}
+			if got := details(t, err); !reflect.DeepEqual(got, tt.wantDetails) {
+				t.Errorf("Details = %+v，期望 %+v", got, tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestTranslateOtherErrors(t *testing.T) {
+	cause := errors.New(`strconv.ParseInt: parsing "abc": invalid syntax`)
+	err := Translate(cause, "en-US")
+
+	// 不返回可能包含内部细节的原始错误信息，原始错误保留在错误链中
+	e := errno.Decode(err)
+	if e.Code() != errno.ErrInvalidParams.Code() || e.Message() != errno.ErrInvalidParams.Message() {
+		t.Errorf("err = %d %q，期望通用的参数错误", e.Code(), e.Message())
+	}
+	if got := e.Localize("en-US"); got != "Invalid parameters" {
+		t.Errorf("Localize(en-US) = %q，期望 %q", got, "Invalid parameters")
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("错误链中没有原始错误: %v", err)
+	}
+}
@@ -7,10 +7,17 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lichenglife/easyblog/internal/apiserver/biz"
+	postv1 "github.com/lichenglife/easyblog/internal/apiserver/biz/v1/post"
+	userv1 "github.com/lichenglife/easyblog/internal/apiserver/biz/v1/user"
 	handler "github.com/lichenglife/easyblog/internal/apiserver/handler/http"
 	"github.com/lichenglife/easyblog/internal/app"
 	"github.com/lichenglife/easyblog/internal/pkg/core"
+	"github.com/lichenglife/easyblog/internal/pkg/cors"
+	"github.com/lichenglife/easyblog/internal/pkg/errno"
 	"github.com/lichenglife/easyblog/internal/pkg/middleware"
+	"github.com/lichenglife/easyblog/internal/pkg/ratelimit"
+	"github.com/lichenglife/easyblog/internal/pkg/validation"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
@@ -28,6 +35,12 @@ type HTTPServer struct {
 	http *http.Server
 	// handler 处理器
 	handler handler.Handler
+	// apiKeys API 密钥认证器
+	apiKeys middleware.APIKeyAuthenticator
+	// rateLimit 限流参数
+	rateLimit *ratelimit.Options
+	// cors 跨域参数
+	cors *cors.Options
 }
 
 func NewHttpServer(config *viper.Viper, app app.IApp) (*HTTPServer, error) {
@@ -39,10 +52,55 @@ func NewHttpServer(config *viper.Viper, app app.IApp) (*HTTPServer, error) {
 
 	factory := app.GetStoreFactory()
 
-	// TODO 创建业务处理器handler
-	handler := handler.NewHandler(app.GetLogger(), factory)
+	rateLimit, err := ratelimit.NewOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	server.rateLimit = rateLimit
+
+	corsOpts, err := cors.NewOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	server.cors = corsOpts
+
+	// 加载错误信息的其他语言
+	if err := errno.LoadLocales(config.GetString("i18n.localesDir")); err != nil {
+		return nil, err
+	}
+	if locale := config.GetString("i18n.defaultLocale"); locale != "" {
+		if err := errno.SetDefaultLocale(locale); err != nil {
+			return nil, fmt.Errorf("i18n.defaultLocale 配置无效: %v", err)
+		}
+	}
+
+	opts := &biz.Options{
+		User: userv1.Options{
+			Hasher:          app.GetPasswordHasher(),
+			Tokens:          app.GetTokenManager(),
+			DefaultRole:     config.GetString("authz.defaultRole"),
+			Notifier:        app.GetNotifier(),
+			ResetExpire:     time.Duration(config.GetInt("auth.passwordReset.expire")) * time.Second,
+			ResetURL:        config.GetString("auth.passwordReset.url"),
+			VerifyExpire:    time.Duration(config.GetInt("auth.emailVerification.expire")) * time.Second,
+			VerifyURL:       config.GetString("auth.emailVerification.url"),
+			MFA:             app.GetMFAManager(),
+			Enforcer:        app.GetEnforcer(),
+			ChallengeExpire: time.Duration(config.GetInt("auth.mfa.challengeExpire")) * time.Second,
+			Lockout:         app.GetLockoutGuard(),
+			OAuth:           app.GetOAuthManager(),
+		},
+		Post: postv1.Options{
+			RequireVerifiedEmail: config.GetBool("auth.emailVerification.requiredToPost"),
+		},
+	}
 
-	server.handler = handler
+	// 业务层只创建一次，处理器和 API 密钥认证共用同一个实例
+	b := biz.NewBiz(app.GetLogger(), factory, opts)
+
+	// 创建业务处理器handler
+	server.handler = handler.NewHandler(app.GetLogger(), b)
+	server.apiKeys = b.APIKeyV1()
 
 	return server, nil
 }
@@ -73,18 +131,33 @@ func (s *HTTPServer) initEngine() error {
 	// 设置运行模式
 	gin.SetMode(s.config.GetString("server.http.mode"))
 
+	// 注册自定义校验规则和校验错误翻译
+	if err := validation.Register(); err != nil {
+		return err
+	}
+
 	engine := gin.New()
 
+	// 只信任配置的反向代理转发的 X-Forwarded-For 和 X-Real-IP，未配置时客户端 IP 为连接的对端地址，
+	// 限流、登录防护和会话记录都依赖该 IP
+	if err := engine.SetTrustedProxies(s.config.GetStringSlice("server.trustedProxies")); err != nil {
+		return fmt.Errorf("server.trustedProxies 配置无效: %v", err)
+	}
+
 	// 注册中间件
 	engine.Use(
 		// RequestID
 		middleware.RequestID(),
+		// 客户端信息
+		middleware.ClientInfo(),
+		// 响应语言
+		middleware.Locale(),
 		// 请求日志记录
 		middleware.Logger(s.app.GetLogger()),
 		// 故障恢复
 		middleware.Recovery(s.app.GetLogger()),
 		// 跨域
-		middleware.CORS(),
+		middleware.CORS(s.cors),
 	)
 	s.engine = engine
 
@@ -101,29 +174,66 @@ func (s *HTTPServer) registerRoutes() error {
 	// swagger api接口文档
 	//s.engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// 中间件
+	// 非认证接口按客户端 IP 限流；认证接口在 Auth 之前只按 IP 统计认证失败的请求，拦截猜测令牌和 API 密钥的请求，
+	// 认证通过后按调用方身份限流，同一 IP 后面的多个用户不会共用配额
+	rateLimit := middleware.RateLimit(s.app.GetRateLimiter(), s.rateLimit, s.app.GetLogger())
+	ipRateLimit := middleware.IPRateLimit(s.app.GetRateLimiter(), s.rateLimit, s.app.GetLogger())
+	authFailureLimit := middleware.AuthFailureLimit(s.app.GetRateLimiter(), s.rateLimit, s.app.GetLogger())
 
 	// 非认证接口路由规则
-	v1 := s.engine.Group("/v1")
+	public := s.engine.Group("/v1", ipRateLimit)
 	{
 		// 用户服务接口
+		public.POST("/user", s.handler.Users().CreateUser)                            // 用户注册
+		public.POST("/user/login", s.handler.Users().UserLogin)                       // 用户登录
+		public.POST("/user/login/mfa", s.handler.Users().LoginMFA)                    // 两步验证登录
+		public.POST("/user/token/refresh", s.handler.Users().RefreshToken)            // 刷新访问令牌
+		public.POST("/user/password/reset", s.handler.Users().RequestPasswordReset)   // 申请重置密码
+		public.POST("/user/password/reset/confirm", s.handler.Users().ResetPassword)  // 确认重置密码
+		public.GET("/user/verify", s.handler.Users().VerifyEmail)                     // 验证邮箱
+		public.GET("/user/oauth/:provider/login", s.handler.Users().OAuthLogin)       // 跳转到身份提供方登录
+		public.GET("/user/oauth/:provider/callback", s.handler.Users().OAuthCallback) // 身份提供方登录回调
 
-		v1.POST("/user", s.handler.Users().CreateUser)          // 用户注册
-		v1.POST("/user/login", s.handler.Users().UserLogin)     // 用户登录
-		v1.GET("/user/info", s.handler.Users().UserInfo)        // 获取用户信息
-		v1.POST("/user/logout", s.handler.Users().UserLogout)   // 用户登出
-		v1.GET("/user/list", s.handler.Users().ListUsers)       // 获取用户列表
-		v1.GET("/user/:id", s.handler.Users().GetUserByID)      // 根据 ID 获取用户
-		v1.PUT("/user/:username", s.handler.Users().UpdateUser) // 更新用户
-		v1.DELETE("/user/:id", s.handler.Users().DeleteUser)    // 删除用户
+		// 博客服务接口
+		// 列表使用集合路径，避免与 /post/:id 产生歧义
+		public.GET("/post", s.handler.Posts().ListPosts)                     // 获取帖子列表
+		public.GET("/post/:id", s.handler.Posts().GetPostByID)               // 根据 ID 获取帖子
+		public.GET("/post/user/:userID", s.handler.Posts().GetPostsByUserID) // 根据用户ID获取帖子列表
+	}
+
+	// 认证接口路由规则
+	// 认证通过后再根据访问控制策略校验调用方角色
+	authed := s.engine.Group("/v1", authFailureLimit, middleware.Auth(s.app.GetTokenManager(), s.apiKeys), rateLimit, middleware.Authorize(s.app.GetEnforcer()))
+	{
+		// 用户服务接口
+		authed.GET("/user", s.handler.Users().ListUsers)                              // 获取用户列表
+		authed.GET("/user/info", s.handler.Users().UserInfo)                          // 获取当前用户信息
+		authed.POST("/user/logout", s.handler.Users().UserLogout)                     // 用户登出
+		authed.POST("/user/verify", s.handler.Users().ResendVerification)             // 重新发送邮箱验证链接
+		authed.GET("/user/sessions", s.handler.Users().ListSessions)                  // 获取当前用户已登录的会话
+		authed.DELETE("/user/sessions", s.handler.Users().RevokeAllSessions)          // 注销全部会话
+		authed.DELETE("/user/sessions/:id", s.handler.Users().RevokeSession)          // 注销指定会话
+		authed.GET("/user/identities", s.handler.Users().ListIdentities)              // 获取已关联的第三方账号
+		authed.POST("/user/identities/:provider", s.handler.Users().LinkIdentity)     // 关联第三方账号
+		authed.DELETE("/user/identities/:provider", s.handler.Users().UnlinkIdentity) // 解除第三方账号关联
+		authed.POST("/user/mfa/enroll", s.handler.Users().EnrollMFA)                  // 注册两步验证
+		authed.POST("/user/mfa/enable", s.handler.Users().EnableMFA)                  // 校验验证码并启用两步验证
+		authed.POST("/user/mfa/disable", s.handler.Users().DisableMFA)                // 关闭两步验证
+		authed.GET("/user/:id", s.handler.Users().GetUserByID)                        // 根据 ID 获取用户
+		authed.PUT("/user/:id", s.handler.Users().UpdateUser)                         // 更新用户
+		authed.PUT("/user/:id/password", s.handler.Users().ChangePassword)            // 修改密码
+		authed.PUT("/user/:id/role", s.handler.Users().UpdateUserRole)                // 修改用户角色
+		authed.DELETE("/user/:id", s.handler.Users().DeleteUser)                      // 删除用户
+
+		// API 密钥接口，API 密钥本身不能管理 API 密钥
+		authed.GET("/user/tokens", s.handler.APIKeys().ListAPIKeys)         // 获取当前用户的 API 密钥列表
+		authed.POST("/user/tokens", s.handler.APIKeys().CreateAPIKey)       // 创建 API 密钥
+		authed.DELETE("/user/tokens/:id", s.handler.APIKeys().DeleteAPIKey) // 删除 API 密钥
 
 		// 博客服务接口
-		v1.POST("/post", s.handler.Posts().CreatePost)                   // 创建帖子
-		v1.GET("/post/:id", s.handler.Posts().GetPostByID)               // 根据 ID 获取帖子
-		v1.GET("/post/list", s.handler.Posts().ListPosts)                // 获取帖子列表
-		v1.PUT("/post/:id", s.handler.Posts().UpdatePost)                // 更新帖子
-		v1.DELETE("/post/:id", s.handler.Posts().DeletePost)             // 删除帖子
-		v1.GET("/post/user/:userID", s.handler.Posts().GetPostsByUserID) // 根据用户ID获取帖子列表
+		authed.POST("/post", s.handler.Posts().CreatePost)       // 创建帖子
+		authed.PUT("/post/:id", s.handler.Posts().UpdatePost)    // 更新帖子
+		authed.DELETE("/post/:id", s.handler.Posts().DeletePost) // 删除帖子
 	}
 
 	return nil